@@ -14,6 +14,9 @@ interface CharacterData {
   talkColor?: string;
 }
 
+// Il motore disegna in bianco le battute dei personaggi senza colore
+const DEFAULT_TALK_COLOR = '#FFFFFF';
+
 export const CharacterEditor: React.FC = () => {
   const { entities, setEntities } = useDiagramContext();
 
@@ -46,7 +49,7 @@ export const CharacterEditor: React.FC = () => {
           name: selectedEntity.name,
           description: selectedEntity.details?.description || '',
           animations: selectedEntity.details?.animations || [],
-          talkColor: selectedEntity.details?.talkColor || '#000000',
+          talkColor: selectedEntity.details?.talkColor || DEFAULT_TALK_COLOR,
         });
       } else {
         setSelectedCharacterId(null);
@@ -98,7 +101,7 @@ export const CharacterEditor: React.FC = () => {
             ...(currentEntity.details || {}),
             description: formData.description || '',
             animations: formData.animations || [], // Aggiungi questa riga
-            talkColor: formData.talkColor || '#000000',
+            talkColor: formData.talkColor || DEFAULT_TALK_COLOR,
           },
         };
       }
@@ -488,7 +491,7 @@ export const CharacterEditor: React.FC = () => {
               <input
                 type="color"
                 name="talkColor"
-                value={formData.talkColor || '#000000'}
+                value={formData.talkColor || DEFAULT_TALK_COLOR}
                 onChange={handleChange}
                 className="w-12 h-8 p-0 border-0 bg-transparent cursor-pointer"
                 title="Colore del testo dei dialoghi"
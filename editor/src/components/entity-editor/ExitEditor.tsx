@@ -0,0 +1,288 @@
+'use client';
+
+import React, { useState, useEffect, useRef, useCallback, useMemo, Dispatch, SetStateAction } from 'react';
+import { Entity, LocationEntity, Exit, Point } from '../flow-diagram/types/index';
+
+interface ExitEditorProps {
+  locationId: string;
+  locationImageUrl: string | null;
+  exits: Exit[]; // Uscite della location, salvate in details.exits
+  entities: Entity[]; // Per scegliere la location di arrivo e gli script
+  setEntities: Dispatch<SetStateAction<Entity[]>>;
+}
+
+const DIRECTIONS = ['', 'LEFT', 'RIGHT', 'UP', 'DOWN'] as const;
+
+const inputClassName = 'px-2 py-1 text-sm border border-gray-300 rounded-md dark:bg-gray-700 dark:border-gray-600 dark:text-white';
+
+// Editor delle uscite: il click sull'immagine disegna l'area dell'uscita selezionata.
+// Il punto di arrivo è nelle coordinate della location di arrivo, per questo si scrive a mano.
+export const ExitEditor: React.FC<ExitEditorProps> = ({
+  locationId,
+  locationImageUrl,
+  exits,
+  entities,
+  setEntities,
+}) => {
+  const [selectedIndex, setSelectedIndex] = useState<number | null>(null);
+  const [imageSize, setImageSize] = useState<{ width: number; height: number } | null>(null);
+
+  const canvasRef = useRef<HTMLCanvasElement>(null);
+  const imageRef = useRef<HTMLImageElement>(null);
+
+  const selectedExit = selectedIndex !== null ? exits[selectedIndex] || null : null;
+  const locations = useMemo(() => entities.filter(e => e.type === 'Location' && !e.internal), [entities]);
+  const scripts = useMemo(() => entities.filter(e => e.type === 'Script'), [entities]);
+
+  useEffect(() => {
+    setSelectedIndex(null);
+  }, [locationId]);
+
+  // Aggiorna le uscite della location nel contesto
+  const updateExits = useCallback((update: (exits: Exit[]) => Exit[]) => {
+    setEntities(prevEntities => prevEntities.map(entity => {
+      if (entity.id !== locationId || entity.type !== 'Location') return entity;
+      const locEntity = entity as LocationEntity;
+      return {
+        ...locEntity,
+        details: {
+          ...locEntity.details,
+          exits: update(locEntity.details?.exits || []),
+        },
+      } as LocationEntity;
+    }));
+  }, [locationId, setEntities]);
+
+  const updateSelectedExit = (changes: Partial<Exit>) => {
+    if (selectedIndex === null) return;
+    updateExits(prev => prev.map((exit, i) => i === selectedIndex ? { ...exit, ...changes } : exit));
+  };
+
+  const handleCreateExit = () => {
+    const exit: Exit = { name: `Exit_${exits.length + 1}`, area: [], targetLocation: '', entryPoint: { x: 0, y: 0 } };
+    updateExits(prev => [...prev, exit]);
+    setSelectedIndex(exits.length);
+  };
+
+  const handleDeleteExit = (index: number) => {
+    if (!window.confirm('Sei sicuro di voler cancellare l\'uscita?')) return;
+    updateExits(prev => prev.filter((_, i) => i !== index));
+    setSelectedIndex(null);
+  };
+
+  const handleEntryPointChange = (field: keyof Point, value: string) => {
+    if (!selectedExit) return;
+    const number = parseInt(value, 10);
+    updateSelectedExit({ entryPoint: { ...selectedExit.entryPoint, [field]: isNaN(number) ? 0 : number } });
+  };
+
+  const handleImageLoad = () => {
+    const img = imageRef.current;
+    if (img && img.naturalWidth > 0 && img.naturalHeight > 0) {
+      setImageSize({ width: img.naturalWidth, height: img.naturalHeight });
+    } else {
+      setImageSize(null);
+    }
+  };
+
+  // Disegna le aree di tutte le uscite, quella selezionata in evidenza
+  const draw = useCallback(() => {
+    const canvas = canvasRef.current;
+    if (!canvas || !imageSize) return;
+    canvas.width = imageSize.width;
+    canvas.height = imageSize.height;
+    const ctx = canvas.getContext('2d');
+    if (!ctx) return;
+    ctx.clearRect(0, 0, canvas.width, canvas.height);
+
+    exits.forEach((exit, index) => {
+      if (exit.area.length === 0) return;
+      const isSelected = index === selectedIndex;
+      const color = isSelected ? 'rgba(255, 165, 0, 0.9)' : 'rgba(220, 20, 60, 0.7)';
+      ctx.beginPath();
+      ctx.moveTo(exit.area[0].x, exit.area[0].y);
+      exit.area.slice(1).forEach(p => ctx.lineTo(p.x, p.y));
+      ctx.closePath();
+      ctx.fillStyle = isSelected ? 'rgba(255, 165, 0, 0.2)' : 'rgba(220, 20, 60, 0.1)';
+      ctx.fill();
+      ctx.strokeStyle = color;
+      ctx.lineWidth = 2;
+      ctx.stroke();
+      exit.area.forEach(p => {
+        ctx.beginPath();
+        ctx.arc(p.x, p.y, 4, 0, 2 * Math.PI);
+        ctx.fillStyle = color;
+        ctx.fill();
+      });
+      const target = locations.find(l => l.id === exit.targetLocation);
+      ctx.fillStyle = color;
+      ctx.font = '12px sans-serif';
+      ctx.fillText(`${exit.name || 'Exit'} → ${target?.name || '?'}`, exit.area[0].x + 6, exit.area[0].y - 6);
+    });
+  }, [exits, selectedIndex, imageSize, locations]);
+
+  useEffect(() => {
+    draw();
+  }, [draw]);
+
+  // Il canvas ha le dimensioni naturali dell'immagine: le coordinate del click sono già quelle della location
+  const getNaturalCoordinates = (e: React.MouseEvent<HTMLCanvasElement>): Point | null => {
+    if (!canvasRef.current || !imageSize) return null;
+    const rect = canvasRef.current.getBoundingClientRect();
+    const x = Math.max(0, Math.min(imageSize.width, e.clientX - rect.left));
+    const y = Math.max(0, Math.min(imageSize.height, e.clientY - rect.top));
+    return { x: Math.round(x), y: Math.round(y) };
+  };
+
+  const handleCanvasClick = (e: React.MouseEvent<HTMLCanvasElement>) => {
+    if (!selectedExit) return;
+    const point = getNaturalCoordinates(e);
+    if (!point) return;
+    updateSelectedExit({ area: [...selectedExit.area, point] });
+  };
+
+  return (
+    <div className="flex flex-col h-full">
+      {/* Lista delle uscite e proprietà di quella selezionata */}
+      <div className="mb-4 p-2 border-b space-y-2">
+        <div className="flex flex-wrap items-center gap-2">
+          <button
+            onClick={handleCreateExit}
+            className="px-3 py-1 text-sm bg-green-500 hover:bg-green-600 text-white rounded"
+          >
+            + New Exit
+          </button>
+          {exits.map((exit, index) => (
+            <span
+              key={index}
+              className={`flex items-center px-2 py-1 rounded text-sm cursor-pointer ${index === selectedIndex ? 'bg-orange-200 dark:bg-orange-800 font-semibold' : 'bg-gray-200 dark:bg-gray-600'}`}
+            >
+              <span onClick={() => setSelectedIndex(index)}>{exit.name || `Exit ${index + 1}`}</span>
+              <button
+                onClick={() => handleDeleteExit(index)}
+                className="ml-2 text-red-500 hover:text-red-700"
+                aria-label={`Delete ${exit.name || `exit ${index + 1}`}`}
+                title={`Delete ${exit.name || `exit ${index + 1}`}`}
+              >
+                ×
+              </button>
+            </span>
+          ))}
+        </div>
+
+        {selectedExit && (
+          <div className="flex flex-wrap items-end gap-2">
+            <label className="text-xs text-gray-600 dark:text-gray-400">
+              Name
+              <input
+                type="text"
+                value={selectedExit.name || ''}
+                onChange={(e) => updateSelectedExit({ name: e.target.value || undefined })}
+                className={`block ${inputClassName}`}
+              />
+            </label>
+            <label className="text-xs text-gray-600 dark:text-gray-400">
+              Target location
+              <select
+                value={selectedExit.targetLocation}
+                onChange={(e) => updateSelectedExit({ targetLocation: e.target.value })}
+                className={`block ${inputClassName}`}
+              >
+                <option value="">-- Select location --</option>
+                {locations.filter(l => l.id !== locationId).map(l => (
+                  <option key={l.id} value={l.id}>{l.name}</option>
+                ))}
+              </select>
+            </label>
+            <label className="text-xs text-gray-600 dark:text-gray-400 w-20">
+              Entry X
+              <input
+                type="number"
+                step={1}
+                value={selectedExit.entryPoint.x}
+                onChange={(e) => handleEntryPointChange('x', e.target.value)}
+                className={`block w-full ${inputClassName}`}
+              />
+            </label>
+            <label className="text-xs text-gray-600 dark:text-gray-400 w-20">
+              Entry Y
+              <input
+                type="number"
+                step={1}
+                value={selectedExit.entryPoint.y}
+                onChange={(e) => handleEntryPointChange('y', e.target.value)}
+                className={`block w-full ${inputClassName}`}
+              />
+            </label>
+            <label className="text-xs text-gray-600 dark:text-gray-400">
+              Entry direction
+              <select
+                value={selectedExit.entryDirection || ''}
+                onChange={(e) => updateSelectedExit({ entryDirection: (e.target.value || undefined) as Exit['entryDirection'] })}
+                className={`block ${inputClassName}`}
+              >
+                {DIRECTIONS.map(direction => (
+                  <option key={direction} value={direction}>{direction || 'Unchanged'}</option>
+                ))}
+              </select>
+            </label>
+            {(['onExit', 'onEnter'] as const).map(field => (
+              <label key={field} className="text-xs text-gray-600 dark:text-gray-400">
+                {field === 'onExit' ? 'On exit script' : 'On enter script'}
+                <select
+                  value={selectedExit[field] || ''}
+                  onChange={(e) => updateSelectedExit({ [field]: e.target.value || undefined })}
+                  className={`block ${inputClassName}`}
+                >
+                  <option value="">-- None --</option>
+                  {scripts.map(s => (
+                    <option key={s.id} value={s.name}>{s.name}</option>
+                  ))}
+                </select>
+              </label>
+            ))}
+            <button
+              onClick={() => updateSelectedExit({ area: selectedExit.area.slice(0, -1) })}
+              disabled={selectedExit.area.length === 0}
+              className="px-3 py-1 text-sm bg-red-500 hover:bg-red-600 text-white rounded disabled:bg-gray-400 disabled:cursor-not-allowed"
+            >
+              Remove Last Point ({selectedExit.area.length} pts)
+            </button>
+          </div>
+        )}
+      </div>
+
+      {/* Sfondo con le uscite disegnate sopra */}
+      <div className="flex-grow relative border rounded overflow-auto bg-gray-100 dark:bg-gray-900">
+        {locationImageUrl ? (
+          <div
+            className="relative"
+            style={imageSize ? { width: `${imageSize.width}px`, height: `${imageSize.height}px`, margin: 'auto' } : undefined}
+          >
+            <img
+              ref={imageRef}
+              src={locationImageUrl}
+              alt="Location background"
+              onLoad={handleImageLoad}
+              style={{ display: 'block', position: 'absolute', top: 0, left: 0 }}
+            />
+            <canvas
+              ref={canvasRef}
+              onClick={handleCanvasClick}
+              style={{
+                position: 'absolute',
+                top: 0,
+                left: 0,
+                cursor: selectedExit ? 'crosshair' : 'default',
+              }}
+            />
+          </div>
+        ) : (
+          <div className="text-center text-gray-500 dark:text-gray-400 mt-10">
+            Load a background image to draw exits.
+          </div>
+        )}
+      </div>
+    </div>
+  );
+};
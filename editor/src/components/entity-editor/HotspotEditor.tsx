@@ -0,0 +1,266 @@
+'use client';
+
+import React, { useState, useEffect, useRef, useCallback, Dispatch, SetStateAction } from 'react';
+import { Entity, LocationEntity, Hotspot, Point } from '../flow-diagram/types/index';
+import { v4 as uuidv4 } from 'uuid';
+
+interface HotspotEditorProps {
+  locationId: string;
+  locationImageUrl: string | null;
+  hotspots: Hotspot[]; // Hotspot della location, salvati in details.hotspots
+  setEntities: Dispatch<SetStateAction<Entity[]>>;
+}
+
+// Cosa fa il click sull'immagine per l'hotspot selezionato
+type ClickMode = 'area' | 'spot';
+
+const DIRECTIONS = ['', 'LEFT', 'RIGHT', 'UP', 'DOWN'] as const;
+
+// Editor delle regioni interattive dello sfondo: ogni hotspot ha un poligono, un punto di interazione
+// e la direzione verso cui si gira il personaggio. Le azioni lo usano come to/with tramite il suo id.
+export const HotspotEditor: React.FC<HotspotEditorProps> = ({
+  locationId,
+  locationImageUrl,
+  hotspots,
+  setEntities,
+}) => {
+  const [selectedHotspotId, setSelectedHotspotId] = useState<string | null>(null);
+  const [clickMode, setClickMode] = useState<ClickMode>('area');
+  const [imageSize, setImageSize] = useState<{ width: number; height: number } | null>(null);
+
+  const canvasRef = useRef<HTMLCanvasElement>(null);
+  const imageRef = useRef<HTMLImageElement>(null);
+
+  const selectedHotspot = hotspots.find(h => h.id === selectedHotspotId) || null;
+
+  useEffect(() => {
+    setSelectedHotspotId(null);
+    setClickMode('area');
+  }, [locationId]);
+
+  // Aggiorna gli hotspot della location nel contesto
+  const updateHotspots = useCallback((update: (hotspots: Hotspot[]) => Hotspot[]) => {
+    setEntities(prevEntities => prevEntities.map(entity => {
+      if (entity.id !== locationId || entity.type !== 'Location') return entity;
+      const locEntity = entity as LocationEntity;
+      return {
+        ...locEntity,
+        details: {
+          ...locEntity.details,
+          hotspots: update(locEntity.details?.hotspots || []),
+        },
+      } as LocationEntity;
+    }));
+  }, [locationId, setEntities]);
+
+  const updateSelectedHotspot = (changes: Partial<Hotspot>) => {
+    if (!selectedHotspotId) return;
+    updateHotspots(prev => prev.map(h => h.id === selectedHotspotId ? { ...h, ...changes } : h));
+  };
+
+  const handleCreateHotspot = () => {
+    const baseName = 'Hotspot';
+    let name = baseName;
+    let counter = 1;
+    while (hotspots.some(h => h.name === name)) {
+      name = `${baseName}_${counter}`;
+      counter++;
+    }
+    const hotspot: Hotspot = { id: uuidv4(), name, area: [], interactionSpot: { x: 0, y: 0 } };
+    updateHotspots(prev => [...prev, hotspot]);
+    setSelectedHotspotId(hotspot.id);
+    setClickMode('area');
+  };
+
+  const handleDeleteHotspot = (id: string) => {
+    if (!window.confirm('Sei sicuro di voler cancellare l\'hotspot? Le azioni che lo usano non avranno più un bersaglio.')) return;
+    updateHotspots(prev => prev.filter(h => h.id !== id));
+    if (selectedHotspotId === id) {
+      setSelectedHotspotId(null);
+    }
+  };
+
+  const handleImageLoad = () => {
+    const img = imageRef.current;
+    if (img && img.naturalWidth > 0 && img.naturalHeight > 0) {
+      setImageSize({ width: img.naturalWidth, height: img.naturalHeight });
+    } else {
+      setImageSize(null);
+    }
+  };
+
+  // Disegna tutti gli hotspot, quello selezionato in evidenza con il suo punto di interazione
+  const draw = useCallback(() => {
+    const canvas = canvasRef.current;
+    if (!canvas || !imageSize) return;
+    canvas.width = imageSize.width;
+    canvas.height = imageSize.height;
+    const ctx = canvas.getContext('2d');
+    if (!ctx) return;
+    ctx.clearRect(0, 0, canvas.width, canvas.height);
+
+    hotspots.forEach(hotspot => {
+      const isSelected = hotspot.id === selectedHotspotId;
+      const color = isSelected ? 'rgba(255, 165, 0, 0.9)' : 'rgba(0, 191, 255, 0.7)';
+      if (hotspot.area.length > 0) {
+        ctx.beginPath();
+        ctx.moveTo(hotspot.area[0].x, hotspot.area[0].y);
+        hotspot.area.slice(1).forEach(p => ctx.lineTo(p.x, p.y));
+        ctx.closePath();
+        ctx.fillStyle = isSelected ? 'rgba(255, 165, 0, 0.2)' : 'rgba(0, 191, 255, 0.1)';
+        ctx.fill();
+        ctx.strokeStyle = color;
+        ctx.lineWidth = 2;
+        ctx.stroke();
+        hotspot.area.forEach(p => {
+          ctx.beginPath();
+          ctx.arc(p.x, p.y, 4, 0, 2 * Math.PI);
+          ctx.fillStyle = color;
+          ctx.fill();
+        });
+        ctx.fillStyle = color;
+        ctx.font = '12px sans-serif';
+        ctx.fillText(hotspot.name, hotspot.area[0].x + 6, hotspot.area[0].y - 6);
+      }
+      if (isSelected) {
+        const spot = hotspot.interactionSpot;
+        ctx.beginPath();
+        ctx.moveTo(spot.x - 6, spot.y - 6);
+        ctx.lineTo(spot.x + 6, spot.y + 6);
+        ctx.moveTo(spot.x + 6, spot.y - 6);
+        ctx.lineTo(spot.x - 6, spot.y + 6);
+        ctx.strokeStyle = 'rgba(255, 0, 0, 0.9)';
+        ctx.lineWidth = 2;
+        ctx.stroke();
+      }
+    });
+  }, [hotspots, selectedHotspotId, imageSize]);
+
+  useEffect(() => {
+    draw();
+  }, [draw]);
+
+  // Il canvas ha le dimensioni naturali dell'immagine: le coordinate del click sono già quelle della location
+  const getNaturalCoordinates = (e: React.MouseEvent<HTMLCanvasElement>): Point | null => {
+    if (!canvasRef.current || !imageSize) return null;
+    const rect = canvasRef.current.getBoundingClientRect();
+    const x = Math.max(0, Math.min(imageSize.width, e.clientX - rect.left));
+    const y = Math.max(0, Math.min(imageSize.height, e.clientY - rect.top));
+    return { x: Math.round(x), y: Math.round(y) };
+  };
+
+  const handleCanvasClick = (e: React.MouseEvent<HTMLCanvasElement>) => {
+    if (!selectedHotspot) return;
+    const point = getNaturalCoordinates(e);
+    if (!point) return;
+    if (clickMode === 'spot') {
+      updateSelectedHotspot({ interactionSpot: point });
+    } else {
+      updateSelectedHotspot({ area: [...selectedHotspot.area, point] });
+    }
+  };
+
+  return (
+    <div className="flex flex-col h-full">
+      {/* Lista degli hotspot e proprietà di quello selezionato */}
+      <div className="mb-4 p-2 border-b space-y-2">
+        <div className="flex flex-wrap items-center gap-2">
+          <button
+            onClick={handleCreateHotspot}
+            className="px-3 py-1 text-sm bg-green-500 hover:bg-green-600 text-white rounded"
+          >
+            + New Hotspot
+          </button>
+          {hotspots.map(hotspot => (
+            <span
+              key={hotspot.id}
+              className={`flex items-center px-2 py-1 rounded text-sm cursor-pointer ${hotspot.id === selectedHotspotId ? 'bg-orange-200 dark:bg-orange-800 font-semibold' : 'bg-gray-200 dark:bg-gray-600'}`}
+            >
+              <span onClick={() => setSelectedHotspotId(hotspot.id)}>{hotspot.name}</span>
+              <button
+                onClick={() => handleDeleteHotspot(hotspot.id)}
+                className="ml-2 text-red-500 hover:text-red-700"
+                aria-label={`Delete ${hotspot.name}`}
+                title={`Delete ${hotspot.name}`}
+              >
+                ×
+              </button>
+            </span>
+          ))}
+        </div>
+
+        {selectedHotspot && (
+          <div className="flex flex-wrap items-center gap-2">
+            <input
+              type="text"
+              value={selectedHotspot.name}
+              onChange={(e) => updateSelectedHotspot({ name: e.target.value })}
+              className="px-2 py-1 text-sm border border-gray-300 rounded-md dark:bg-gray-700 dark:border-gray-600 dark:text-white"
+            />
+            <select
+              value={selectedHotspot.direction || ''}
+              onChange={(e) => updateSelectedHotspot({ direction: (e.target.value || undefined) as Hotspot['direction'] })}
+              className="px-2 py-1 text-sm border border-gray-300 rounded-md dark:bg-gray-700 dark:border-gray-600 dark:text-white"
+            >
+              {DIRECTIONS.map(direction => (
+                <option key={direction} value={direction}>{direction || 'Any direction'}</option>
+              ))}
+            </select>
+            <button
+              onClick={() => setClickMode('area')}
+              className={`px-3 py-1 rounded text-sm ${clickMode === 'area' ? 'bg-indigo-600 text-white' : 'bg-gray-200 dark:bg-gray-600'}`}
+            >
+              Draw Area ({selectedHotspot.area.length} pts)
+            </button>
+            <button
+              onClick={() => setClickMode('spot')}
+              className={`px-3 py-1 rounded text-sm ${clickMode === 'spot' ? 'bg-indigo-600 text-white' : 'bg-gray-200 dark:bg-gray-600'}`}
+            >
+              Set Interaction Spot
+            </button>
+            <button
+              onClick={() => updateSelectedHotspot({ area: selectedHotspot.area.slice(0, -1) })}
+              disabled={selectedHotspot.area.length === 0}
+              className="px-3 py-1 text-sm bg-red-500 hover:bg-red-600 text-white rounded disabled:bg-gray-400 disabled:cursor-not-allowed"
+            >
+              Remove Last Point
+            </button>
+            <span className="text-xs text-gray-500 dark:text-gray-400">ID: {selectedHotspot.id}</span>
+          </div>
+        )}
+      </div>
+
+      {/* Sfondo con gli hotspot disegnati sopra */}
+      <div className="flex-grow relative border rounded overflow-auto bg-gray-100 dark:bg-gray-900">
+        {locationImageUrl ? (
+          <div
+            className="relative"
+            style={imageSize ? { width: `${imageSize.width}px`, height: `${imageSize.height}px`, margin: 'auto' } : undefined}
+          >
+            <img
+              ref={imageRef}
+              src={locationImageUrl}
+              alt="Location background"
+              onLoad={handleImageLoad}
+              style={{ display: 'block', position: 'absolute', top: 0, left: 0 }}
+            />
+            <canvas
+              ref={canvasRef}
+              onClick={handleCanvasClick}
+              style={{
+                position: 'absolute',
+                top: 0,
+                left: 0,
+                cursor: selectedHotspot ? 'crosshair' : 'default',
+              }}
+            />
+          </div>
+        ) : (
+          <div className="text-center text-gray-500 dark:text-gray-400 mt-10">
+            Load a background image to draw hotspots.
+          </div>
+        )}
+      </div>
+    </div>
+  );
+};
@@ -0,0 +1,153 @@
+'use client';
+
+import React, { useCallback, Dispatch, SetStateAction } from 'react';
+import { Entity, LocationEntity, LocationLayer } from '../flow-diagram/types/index';
+
+interface LayerEditorProps {
+  locationId: string;
+  layers: LocationLayer[]; // Layer della location, salvati in details.layers
+  setEntities: Dispatch<SetStateAction<Entity[]>>;
+  imageUploadService?: (file: File) => Promise<string>;
+}
+
+const inputClassName = 'w-full px-2 py-1 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-indigo-500 focus:border-indigo-500 dark:bg-gray-700 dark:border-gray-600 dark:text-white';
+
+// Editor delle immagini disegnate sopra lo sfondo: zOrder > 0 davanti ai personaggi,
+// parallax 1 (default) scorre con la stanza, 0 resta fermo; i pixel del colore trasparente non si disegnano
+export const LayerEditor: React.FC<LayerEditorProps> = ({
+  locationId,
+  layers,
+  setEntities,
+  imageUploadService,
+}) => {
+  // Aggiorna i layer della location nel contesto
+  const updateLayers = useCallback((update: (layers: LocationLayer[]) => LocationLayer[]) => {
+    setEntities(prevEntities => prevEntities.map(entity => {
+      if (entity.id !== locationId || entity.type !== 'Location') return entity;
+      const locEntity = entity as LocationEntity;
+      return {
+        ...locEntity,
+        details: {
+          ...locEntity.details,
+          layers: update(locEntity.details?.layers || []),
+        },
+      } as LocationEntity;
+    }));
+  }, [locationId, setEntities]);
+
+  const updateLayer = (index: number, changes: Partial<LocationLayer>) => {
+    updateLayers(prev => prev.map((layer, i) => i === index ? { ...layer, ...changes } : layer));
+  };
+
+  const handleAddLayer = () => {
+    updateLayers(prev => [...prev, { image: '', zOrder: 1 }]);
+  };
+
+  const handleRemoveLayer = (index: number) => {
+    if (!window.confirm('Sei sicuro di voler rimuovere il layer?')) return;
+    updateLayers(prev => prev.filter((_, i) => i !== index));
+  };
+
+  // Carica l'immagine del layer con il servizio di upload, o in base64 se non c'è
+  const handleImageChange = async (index: number, event: React.ChangeEvent<HTMLInputElement>) => {
+    const file = event.target.files?.[0];
+    if (!file) return;
+    try {
+      if (imageUploadService) {
+        updateLayer(index, { image: await imageUploadService(file) });
+        return;
+      }
+      const reader = new FileReader();
+      reader.onloadend = () => updateLayer(index, { image: reader.result as string });
+      reader.onerror = () => alert('Failed to convert image to base64.');
+      reader.readAsDataURL(file);
+    } catch (error) {
+      console.error('Error uploading layer image:', error);
+      alert('Failed to upload the layer image. Please try again.');
+    }
+  };
+
+  const handleZOrderChange = (index: number, value: string) => {
+    const zOrder = parseInt(value, 10);
+    updateLayer(index, { zOrder: isNaN(zOrder) ? undefined : zOrder });
+  };
+
+  // Un parallax vuoto torna al default (1, scorre con la stanza)
+  const handleParallaxChange = (index: number, value: string) => {
+    const parallax = parseFloat(value);
+    updateLayer(index, { parallax: isNaN(parallax) ? undefined : parallax });
+  };
+
+  return (
+    <div className="mb-4">
+      <div className="flex justify-between items-center mb-1">
+        <span className="block text-sm font-medium text-gray-700 dark:text-gray-300">
+          Layers (over the background)
+        </span>
+        <button
+          onClick={handleAddLayer}
+          className="px-2 py-1 bg-green-500 text-white rounded hover:bg-green-600 text-xs"
+        >
+          + Add Layer
+        </button>
+      </div>
+      {layers.map((layer, index) => (
+        <div key={index} className="flex items-end gap-2 p-2 mb-1 border rounded bg-gray-50 dark:bg-gray-900">
+          {layer.image ? (
+            <img src={layer.image} alt={`Layer ${index + 1}`} className="w-16 h-12 object-contain border rounded bg-white" />
+          ) : (
+            <div className="w-16 h-12 flex items-center justify-center border rounded text-xs text-gray-500">No image</div>
+          )}
+          <label className="text-xs text-gray-600 dark:text-gray-400">
+            Image
+            <input
+              type="file"
+              accept="image/*"
+              onChange={(e) => handleImageChange(index, e)}
+              className="block w-40 text-xs"
+            />
+          </label>
+          <label className="text-xs text-gray-600 dark:text-gray-400 w-20">
+            Z order
+            <input
+              type="number"
+              step={1}
+              value={layer.zOrder ?? ''}
+              onChange={(e) => handleZOrderChange(index, e.target.value)}
+              className={inputClassName}
+            />
+          </label>
+          <label className="text-xs text-gray-600 dark:text-gray-400 w-20">
+            Parallax
+            <input
+              type="number"
+              step={0.1}
+              placeholder="1"
+              value={layer.parallax ?? ''}
+              onChange={(e) => handleParallaxChange(index, e.target.value)}
+              className={inputClassName}
+            />
+          </label>
+          <label className="text-xs text-gray-600 dark:text-gray-400 w-28">
+            Transparent color
+            <input
+              type="text"
+              placeholder="#RRGGBB"
+              value={layer.transparentColor || ''}
+              onChange={(e) => updateLayer(index, { transparentColor: e.target.value || undefined })}
+              className={inputClassName}
+            />
+          </label>
+          <button
+            onClick={() => handleRemoveLayer(index)}
+            className="ml-auto p-1 text-red-500 hover:text-red-700 dark:text-red-400 dark:hover:text-red-300 rounded-full hover:bg-red-100 dark:hover:bg-red-700/50"
+            aria-label={`Remove layer ${index + 1}`}
+            title={`Remove layer ${index + 1}`}
+          >
+            ×
+          </button>
+        </div>
+      ))}
+    </div>
+  );
+};
@@ -2,9 +2,14 @@
 
 import React, { useState, useEffect, useMemo, useCallback, Dispatch, SetStateAction, useRef } from 'react'; // Added useRef
 import { useDiagramContext } from '../flow-diagram/contexts/DiagramContext';
-import { Entity, LocationEntity, ItemEntity, CharacterEntity, LocationDetails } from '../flow-diagram/types/index'; // Importa ItemEntity, CharacterEntity, AND LocationDetails
+import { Entity, LocationEntity, ItemEntity, CharacterEntity, LocationDetails, ScaleArea } from '../flow-diagram/types/index'; // Importa ItemEntity, CharacterEntity, AND LocationDetails
 import { PolygonEditor } from './PolygonEditor';
 import { PlacementEditor } from './PlacementEditor'; // Assicurati che questo import sia corretto
+import { HotspotEditor } from './HotspotEditor';
+import { LayerEditor } from './LayerEditor';
+import { WalkBehindEditor } from './WalkBehindEditor';
+import { WalkableAreaEditor } from './WalkableAreaEditor';
+import { ExitEditor } from './ExitEditor';
 import { v4 as uuidv4 } from 'uuid'; // Import uuid
 
 // Interface for the detailed location data used in the form
@@ -25,7 +30,7 @@ export interface LocationEditorProps { // Aggiunta interfaccia Props // Ensure t
 }
 
 // Definiamo i tipi per le modalità di editing
-type EditorMode = 'polygons' | 'placement';
+type EditorMode = 'polygons' | 'walkableAreas' | 'placement' | 'hotspots' | 'exits';
 
 export const LocationEditor: React.FC<LocationEditorProps> = ({ imageUploadService, onLocationSelectedForSubEditing }) => {
   const { entities, setEntities } = useDiagramContext(); // Get setEntities
@@ -160,6 +165,37 @@ export const LocationEditor: React.FC<LocationEditorProps> = ({ imageUploadServi
     // TODO: Implement logic to save changes back (e.g., update details associated with formData.id)
   };
 
+  // Aggiorna la scala dei personaggi (profondità) della location selezionata
+  const handleScaleAreaChange = (field: keyof ScaleArea, value: string) => {
+    if (!selectedLocationId) return;
+    const number = parseFloat(value);
+    setEntities(prevEntities => prevEntities.map(entity => {
+      if (entity.id !== selectedLocationId || entity.type !== 'Location') return entity;
+      const locEntity = entity as LocationEntity;
+      const scaleArea: ScaleArea = locEntity.details?.scaleArea || { farY: 0, farScale: 1, nearY: 0, nearScale: 1 };
+      return {
+        ...locEntity,
+        details: {
+          ...locEntity.details,
+          scaleArea: { ...scaleArea, [field]: isNaN(number) ? 0 : number },
+        },
+      } as LocationEntity;
+    }));
+  };
+
+  // Senza scala i personaggi restano sempre a grandezza naturale
+  const handleRemoveScaleArea = () => {
+    if (!selectedLocationId) return;
+    setEntities(prevEntities => prevEntities.map(entity => {
+      if (entity.id !== selectedLocationId || entity.type !== 'Location') return entity;
+      const locEntity = entity as LocationEntity;
+      return {
+        ...locEntity,
+        details: { ...locEntity.details, scaleArea: undefined },
+      } as LocationEntity;
+    }));
+  };
+
   // Handler for selecting a location from the list
   const handleSelectLocation = (id: string) => {
     setSelectedLocationId(id);
@@ -312,6 +348,57 @@ export const LocationEditor: React.FC<LocationEditorProps> = ({ imageUploadServi
               />
             </div>
 
+            {/* Scala dei personaggi: più piccoli man mano che si allontanano */}
+            <div className="mb-4">
+              <div className="flex justify-between items-center mb-1">
+                <span className="block text-sm font-medium text-gray-700 dark:text-gray-300">
+                  Character Scale (far line / near line)
+                </span>
+                {currentSelectedLocationDetails?.scaleArea && (
+                  <button
+                    onClick={handleRemoveScaleArea}
+                    className="px-2 py-1 bg-red-500 text-white rounded hover:bg-red-600 text-xs"
+                  >
+                    Remove Scale
+                  </button>
+                )}
+              </div>
+              <div className="grid grid-cols-4 gap-2">
+                {([['farY', 'Far Y', 1], ['farScale', 'Far scale', 0.1], ['nearY', 'Near Y', 1], ['nearScale', 'Near scale', 0.1]] as const).map(([field, label, step]) => (
+                  <label key={field} className="text-xs text-gray-600 dark:text-gray-400">
+                    {label}
+                    <input
+                      type="number"
+                      step={step}
+                      value={currentSelectedLocationDetails?.scaleArea?.[field] ?? ''}
+                      onChange={(e) => handleScaleAreaChange(field, e.target.value)}
+                      className="w-full px-2 py-1 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-indigo-500 focus:border-indigo-500 dark:bg-gray-700 dark:border-gray-600 dark:text-white"
+                    />
+                  </label>
+                ))}
+              </div>
+            </div>
+
+            {/* Immagini sopra lo sfondo */}
+            {currentSelectedLocationDetails && (
+              <LayerEditor
+                locationId={selectedLocationId}
+                layers={currentSelectedLocationDetails.layers || []}
+                setEntities={setEntities}
+                imageUploadService={imageUploadService}
+              />
+            )}
+
+            {/* Parti dello sfondo davanti ai personaggi */}
+            {currentSelectedLocationDetails && (
+              <WalkBehindEditor
+                locationId={selectedLocationId}
+                walkBehinds={currentSelectedLocationDetails.walkBehinds || []}
+                setEntities={setEntities}
+                imageUploadService={imageUploadService}
+              />
+            )}
+
             {/* Mode Toggles */}
             <div className="mb-4 flex space-x-2 border-b pb-2">
               <button 
@@ -320,12 +407,30 @@ export const LocationEditor: React.FC<LocationEditorProps> = ({ imageUploadServi
               >
                 Edit Walkable Area / Background
               </button>
+              <button
+                onClick={() => setEditorMode('walkableAreas')}
+                className={`px-3 py-1 rounded text-sm ${editorMode === 'walkableAreas' ? 'bg-indigo-600 text-white' : 'bg-gray-200 dark:bg-gray-600 hover:bg-gray-300 dark:hover:bg-gray-500'}`}
+              >
+                Named Walkable Areas
+              </button>
               <button 
                 onClick={() => setEditorMode('placement')} 
                 className={`px-3 py-1 rounded text-sm ${editorMode === 'placement' ? 'bg-indigo-600 text-white' : 'bg-gray-200 dark:bg-gray-600 hover:bg-gray-300 dark:hover:bg-gray-500'}`}
               >
                 Place Entities
               </button>
+              <button 
+                onClick={() => setEditorMode('hotspots')} 
+                className={`px-3 py-1 rounded text-sm ${editorMode === 'hotspots' ? 'bg-indigo-600 text-white' : 'bg-gray-200 dark:bg-gray-600 hover:bg-gray-300 dark:hover:bg-gray-500'}`}
+              >
+                Edit Hotspots
+              </button>
+              <button
+                onClick={() => setEditorMode('exits')}
+                className={`px-3 py-1 rounded text-sm ${editorMode === 'exits' ? 'bg-indigo-600 text-white' : 'bg-gray-200 dark:bg-gray-600 hover:bg-gray-300 dark:hover:bg-gray-500'}`}
+              >
+                Edit Exits
+              </button>
             </div>
 
             {/* Conditional rendering of PolygonEditor or PlacementEditor based on mode */}
@@ -339,6 +444,14 @@ export const LocationEditor: React.FC<LocationEditorProps> = ({ imageUploadServi
                 imageUploadService={imageUploadService}
               />
             )}
+            {editorMode === 'walkableAreas' && currentSelectedLocationDetails && (
+              <WalkableAreaEditor
+                locationId={selectedLocationId}
+                locationImageUrl={currentSelectedLocationDetails.backgroundImage || null}
+                walkableAreas={currentSelectedLocationDetails.walkableAreas || []}
+                setEntities={setEntities}
+              />
+            )}
             {editorMode === 'placement' && currentSelectedLocationDetails && (
               <PlacementEditor
                 locationId={selectedLocationId}
@@ -351,6 +464,23 @@ export const LocationEditor: React.FC<LocationEditorProps> = ({ imageUploadServi
                 setEntities={setEntities}
               />
             )}
+            {editorMode === 'hotspots' && currentSelectedLocationDetails && (
+              <HotspotEditor
+                locationId={selectedLocationId}
+                locationImageUrl={currentSelectedLocationDetails.backgroundImage || null}
+                hotspots={currentSelectedLocationDetails.hotspots || []}
+                setEntities={setEntities}
+              />
+            )}
+            {editorMode === 'exits' && currentSelectedLocationDetails && (
+              <ExitEditor
+                locationId={selectedLocationId}
+                locationImageUrl={currentSelectedLocationDetails.backgroundImage || null}
+                exits={currentSelectedLocationDetails.exits || []}
+                entities={entities}
+                setEntities={setEntities}
+              />
+            )}
           </>
         ) : (
           <div className="text-center text-gray-500 dark:text-gray-400 mt-10">
@@ -0,0 +1,127 @@
+'use client';
+
+import React, { useCallback, Dispatch, SetStateAction } from 'react';
+import { Entity, LocationEntity, WalkBehind } from '../flow-diagram/types/index';
+
+interface WalkBehindEditorProps {
+  locationId: string;
+  walkBehinds: WalkBehind[]; // Walk-behind della location, salvati in details.walkBehinds
+  setEntities: Dispatch<SetStateAction<Entity[]>>;
+  imageUploadService?: (file: File) => Promise<string>;
+}
+
+const inputClassName = 'w-full px-2 py-1 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-indigo-500 focus:border-indigo-500 dark:bg-gray-700 dark:border-gray-600 dark:text-white';
+
+// Editor delle parti dello sfondo che coprono i personaggi: la maschera è grande quanto lo sfondo,
+// i suoi pixel opachi coprono chi ha i piedi sopra la baseline (y minore)
+export const WalkBehindEditor: React.FC<WalkBehindEditorProps> = ({
+  locationId,
+  walkBehinds,
+  setEntities,
+  imageUploadService,
+}) => {
+  // Aggiorna i walk-behind della location nel contesto
+  const updateWalkBehinds = useCallback((update: (walkBehinds: WalkBehind[]) => WalkBehind[]) => {
+    setEntities(prevEntities => prevEntities.map(entity => {
+      if (entity.id !== locationId || entity.type !== 'Location') return entity;
+      const locEntity = entity as LocationEntity;
+      return {
+        ...locEntity,
+        details: {
+          ...locEntity.details,
+          walkBehinds: update(locEntity.details?.walkBehinds || []),
+        },
+      } as LocationEntity;
+    }));
+  }, [locationId, setEntities]);
+
+  const updateWalkBehind = (index: number, changes: Partial<WalkBehind>) => {
+    updateWalkBehinds(prev => prev.map((walkBehind, i) => i === index ? { ...walkBehind, ...changes } : walkBehind));
+  };
+
+  const handleAddWalkBehind = () => {
+    updateWalkBehinds(prev => [...prev, { mask: '', baseline: 0 }]);
+  };
+
+  const handleRemoveWalkBehind = (index: number) => {
+    if (!window.confirm('Sei sicuro di voler rimuovere il walk-behind?')) return;
+    updateWalkBehinds(prev => prev.filter((_, i) => i !== index));
+  };
+
+  // Carica la maschera con il servizio di upload, o in base64 se non c'è
+  const handleMaskChange = async (index: number, event: React.ChangeEvent<HTMLInputElement>) => {
+    const file = event.target.files?.[0];
+    if (!file) return;
+    try {
+      if (imageUploadService) {
+        updateWalkBehind(index, { mask: await imageUploadService(file) });
+        return;
+      }
+      const reader = new FileReader();
+      reader.onloadend = () => updateWalkBehind(index, { mask: reader.result as string });
+      reader.onerror = () => alert('Failed to convert image to base64.');
+      reader.readAsDataURL(file);
+    } catch (error) {
+      console.error('Error uploading walk-behind mask:', error);
+      alert('Failed to upload the walk-behind mask. Please try again.');
+    }
+  };
+
+  const handleBaselineChange = (index: number, value: string) => {
+    const baseline = parseInt(value, 10);
+    updateWalkBehind(index, { baseline: isNaN(baseline) ? 0 : baseline });
+  };
+
+  return (
+    <div className="mb-4">
+      <div className="flex justify-between items-center mb-1">
+        <span className="block text-sm font-medium text-gray-700 dark:text-gray-300">
+          Walk-behinds (cover the characters above the baseline)
+        </span>
+        <button
+          onClick={handleAddWalkBehind}
+          className="px-2 py-1 bg-green-500 text-white rounded hover:bg-green-600 text-xs"
+        >
+          + Add Walk-behind
+        </button>
+      </div>
+      {walkBehinds.map((walkBehind, index) => (
+        <div key={index} className="flex items-end gap-2 p-2 mb-1 border rounded bg-gray-50 dark:bg-gray-900">
+          {walkBehind.mask ? (
+            <img src={walkBehind.mask} alt={`Walk-behind ${index + 1}`} className="w-16 h-12 object-contain border rounded bg-white" />
+          ) : (
+            <div className="w-16 h-12 flex items-center justify-center border rounded text-xs text-gray-500">No mask</div>
+          )}
+          <label className="text-xs text-gray-600 dark:text-gray-400">
+            Mask
+            <input
+              type="file"
+              accept="image/png"
+              onChange={(e) => handleMaskChange(index, e)}
+              className="block w-40 text-xs"
+            />
+          </label>
+          <label className="text-xs text-gray-600 dark:text-gray-400 w-24">
+            Baseline (Y)
+            <input
+              type="number"
+              step={1}
+              min={0}
+              value={walkBehind.baseline}
+              onChange={(e) => handleBaselineChange(index, e.target.value)}
+              className={inputClassName}
+            />
+          </label>
+          <button
+            onClick={() => handleRemoveWalkBehind(index)}
+            className="ml-auto p-1 text-red-500 hover:text-red-700 dark:text-red-400 dark:hover:text-red-300 rounded-full hover:bg-red-100 dark:hover:bg-red-700/50"
+            aria-label={`Remove walk-behind ${index + 1}`}
+            title={`Remove walk-behind ${index + 1}`}
+          >
+            ×
+          </button>
+        </div>
+      ))}
+    </div>
+  );
+};
@@ -0,0 +1,292 @@
+'use client';
+
+import React, { useState, useEffect, useRef, useCallback, Dispatch, SetStateAction } from 'react';
+import { Entity, LocationEntity, NamedWalkableArea, Point } from '../flow-diagram/types/index';
+
+interface WalkableAreaEditorProps {
+  locationId: string;
+  locationImageUrl: string | null;
+  walkableAreas: NamedWalkableArea[]; // Aree con nome della location, salvate in details.walkableAreas
+  setEntities: Dispatch<SetStateAction<Entity[]>>;
+}
+
+// Editor delle aree calpestabili con nome: gli script le attivano e disattivano con
+// game:EnableWalkableArea / game:DisableWalkableArea, disabled è lo stato a inizio partita.
+// Ogni area può avere più poligoni; il click sull'immagine aggiunge un punto al poligono selezionato.
+export const WalkableAreaEditor: React.FC<WalkableAreaEditorProps> = ({
+  locationId,
+  locationImageUrl,
+  walkableAreas,
+  setEntities,
+}) => {
+  const [selectedIndex, setSelectedIndex] = useState<number | null>(null);
+  const [selectedPolygon, setSelectedPolygon] = useState(0);
+  const [imageSize, setImageSize] = useState<{ width: number; height: number } | null>(null);
+
+  const canvasRef = useRef<HTMLCanvasElement>(null);
+  const imageRef = useRef<HTMLImageElement>(null);
+
+  const selectedArea = selectedIndex !== null ? walkableAreas[selectedIndex] || null : null;
+  const selectedPoints = selectedArea?.polygons[selectedPolygon] || [];
+
+  useEffect(() => {
+    setSelectedIndex(null);
+    setSelectedPolygon(0);
+  }, [locationId]);
+
+  // Aggiorna le aree della location nel contesto
+  const updateWalkableAreas = useCallback((update: (areas: NamedWalkableArea[]) => NamedWalkableArea[]) => {
+    setEntities(prevEntities => prevEntities.map(entity => {
+      if (entity.id !== locationId || entity.type !== 'Location') return entity;
+      const locEntity = entity as LocationEntity;
+      return {
+        ...locEntity,
+        details: {
+          ...locEntity.details,
+          walkableAreas: update(locEntity.details?.walkableAreas || []),
+        },
+      } as LocationEntity;
+    }));
+  }, [locationId, setEntities]);
+
+  const updateSelectedArea = (changes: Partial<NamedWalkableArea>) => {
+    if (selectedIndex === null) return;
+    updateWalkableAreas(prev => prev.map((area, i) => i === selectedIndex ? { ...area, ...changes } : area));
+  };
+
+  const updateSelectedPoints = (points: Point[]) => {
+    if (!selectedArea) return;
+    updateSelectedArea({ polygons: selectedArea.polygons.map((polygon, i) => i === selectedPolygon ? points : polygon) });
+  };
+
+  const handleSelectArea = (index: number) => {
+    setSelectedIndex(index);
+    setSelectedPolygon(0);
+  };
+
+  const handleCreateArea = () => {
+    const baseName = 'Area';
+    let name = baseName;
+    let counter = 1;
+    while (walkableAreas.some(a => a.name === name)) {
+      name = `${baseName}_${counter}`;
+      counter++;
+    }
+    updateWalkableAreas(prev => [...prev, { name, polygons: [[]] }]);
+    setSelectedIndex(walkableAreas.length);
+    setSelectedPolygon(0);
+  };
+
+  const handleDeleteArea = (index: number) => {
+    if (!window.confirm('Sei sicuro di voler cancellare l\'area? Gli script che la usano non la troveranno più.')) return;
+    updateWalkableAreas(prev => prev.filter((_, i) => i !== index));
+    setSelectedIndex(null);
+    setSelectedPolygon(0);
+  };
+
+  // Il nome è la chiave usata dagli script: due aree non possono averlo uguale
+  const handleRename = (name: string) => {
+    if (walkableAreas.some((a, i) => a.name === name && i !== selectedIndex)) {
+      alert(`Il nome "${name}" è già usato da un'altra area.`);
+      return;
+    }
+    updateSelectedArea({ name });
+  };
+
+  const handleAddPolygon = () => {
+    if (!selectedArea) return;
+    updateSelectedArea({ polygons: [...selectedArea.polygons, []] });
+    setSelectedPolygon(selectedArea.polygons.length);
+  };
+
+  const handleRemovePolygon = () => {
+    if (!selectedArea) return;
+    updateSelectedArea({ polygons: selectedArea.polygons.filter((_, i) => i !== selectedPolygon) });
+    setSelectedPolygon(0);
+  };
+
+  const handleImageLoad = () => {
+    const img = imageRef.current;
+    if (img && img.naturalWidth > 0 && img.naturalHeight > 0) {
+      setImageSize({ width: img.naturalWidth, height: img.naturalHeight });
+    } else {
+      setImageSize(null);
+    }
+  };
+
+  // Disegna tutte le aree, quella selezionata in evidenza; le aree disattivate sono tratteggiate
+  const draw = useCallback(() => {
+    const canvas = canvasRef.current;
+    if (!canvas || !imageSize) return;
+    canvas.width = imageSize.width;
+    canvas.height = imageSize.height;
+    const ctx = canvas.getContext('2d');
+    if (!ctx) return;
+    ctx.clearRect(0, 0, canvas.width, canvas.height);
+
+    walkableAreas.forEach((area, areaIndex) => {
+      const isSelected = areaIndex === selectedIndex;
+      const color = isSelected ? 'rgba(255, 165, 0, 0.9)' : 'rgba(0, 200, 0, 0.7)';
+      ctx.setLineDash(area.disabled ? [6, 4] : []);
+      area.polygons.forEach((polygon, polygonIndex) => {
+        if (polygon.length === 0) return;
+        const isCurrent = isSelected && polygonIndex === selectedPolygon;
+        ctx.beginPath();
+        ctx.moveTo(polygon[0].x, polygon[0].y);
+        polygon.slice(1).forEach(p => ctx.lineTo(p.x, p.y));
+        ctx.closePath();
+        ctx.fillStyle = isSelected ? 'rgba(255, 165, 0, 0.2)' : 'rgba(0, 200, 0, 0.1)';
+        ctx.fill();
+        ctx.strokeStyle = color;
+        ctx.lineWidth = isCurrent ? 3 : 2;
+        ctx.stroke();
+        if (isCurrent) {
+          polygon.forEach(p => {
+            ctx.beginPath();
+            ctx.arc(p.x, p.y, 4, 0, 2 * Math.PI);
+            ctx.fillStyle = color;
+            ctx.fill();
+          });
+        }
+        if (polygonIndex === 0) {
+          ctx.fillStyle = color;
+          ctx.font = '12px sans-serif';
+          ctx.fillText(area.name, polygon[0].x + 6, polygon[0].y - 6);
+        }
+      });
+    });
+    ctx.setLineDash([]);
+  }, [walkableAreas, selectedIndex, selectedPolygon, imageSize]);
+
+  useEffect(() => {
+    draw();
+  }, [draw]);
+
+  // Il canvas ha le dimensioni naturali dell'immagine: le coordinate del click sono già quelle della location
+  const getNaturalCoordinates = (e: React.MouseEvent<HTMLCanvasElement>): Point | null => {
+    if (!canvasRef.current || !imageSize) return null;
+    const rect = canvasRef.current.getBoundingClientRect();
+    const x = Math.max(0, Math.min(imageSize.width, e.clientX - rect.left));
+    const y = Math.max(0, Math.min(imageSize.height, e.clientY - rect.top));
+    return { x: Math.round(x), y: Math.round(y) };
+  };
+
+  const handleCanvasClick = (e: React.MouseEvent<HTMLCanvasElement>) => {
+    if (!selectedArea || selectedPolygon >= selectedArea.polygons.length) return;
+    const point = getNaturalCoordinates(e);
+    if (!point) return;
+    updateSelectedPoints([...selectedPoints, point]);
+  };
+
+  return (
+    <div className="flex flex-col h-full">
+      {/* Lista delle aree e proprietà di quella selezionata */}
+      <div className="mb-4 p-2 border-b space-y-2">
+        <div className="flex flex-wrap items-center gap-2">
+          <button
+            onClick={handleCreateArea}
+            className="px-3 py-1 text-sm bg-green-500 hover:bg-green-600 text-white rounded"
+          >
+            + New Area
+          </button>
+          {walkableAreas.map((area, index) => (
+            <span
+              key={index}
+              className={`flex items-center px-2 py-1 rounded text-sm cursor-pointer ${index === selectedIndex ? 'bg-orange-200 dark:bg-orange-800 font-semibold' : 'bg-gray-200 dark:bg-gray-600'}`}
+            >
+              <span onClick={() => handleSelectArea(index)}>{area.name}{area.disabled ? ' (disabled)' : ''}</span>
+              <button
+                onClick={() => handleDeleteArea(index)}
+                className="ml-2 text-red-500 hover:text-red-700"
+                aria-label={`Delete ${area.name}`}
+                title={`Delete ${area.name}`}
+              >
+                ×
+              </button>
+            </span>
+          ))}
+        </div>
+
+        {selectedArea && (
+          <div className="flex flex-wrap items-center gap-2">
+            <input
+              type="text"
+              value={selectedArea.name}
+              onChange={(e) => handleRename(e.target.value)}
+              className="px-2 py-1 text-sm border border-gray-300 rounded-md dark:bg-gray-700 dark:border-gray-600 dark:text-white"
+            />
+            <label className="flex items-center gap-1 text-sm">
+              <input
+                type="checkbox"
+                checked={selectedArea.disabled || false}
+                onChange={(e) => updateSelectedArea({ disabled: e.target.checked || undefined })}
+              />
+              Disabled at start
+            </label>
+            <select
+              value={selectedPolygon}
+              onChange={(e) => setSelectedPolygon(parseInt(e.target.value, 10))}
+              className="px-2 py-1 text-sm border border-gray-300 rounded-md dark:bg-gray-700 dark:border-gray-600 dark:text-white"
+            >
+              {selectedArea.polygons.map((polygon, i) => (
+                <option key={i} value={i}>Polygon {i + 1} ({polygon.length} pts)</option>
+              ))}
+            </select>
+            <button
+              onClick={handleAddPolygon}
+              className="px-3 py-1 text-sm bg-indigo-600 hover:bg-indigo-700 text-white rounded"
+            >
+              + Polygon
+            </button>
+            <button
+              onClick={() => updateSelectedPoints(selectedPoints.slice(0, -1))}
+              disabled={selectedPoints.length === 0}
+              className="px-3 py-1 text-sm bg-red-500 hover:bg-red-600 text-white rounded disabled:bg-gray-400 disabled:cursor-not-allowed"
+            >
+              Remove Last Point
+            </button>
+            <button
+              onClick={handleRemovePolygon}
+              disabled={selectedArea.polygons.length === 0}
+              className="px-3 py-1 text-sm bg-red-500 hover:bg-red-600 text-white rounded disabled:bg-gray-400 disabled:cursor-not-allowed"
+            >
+              Remove Polygon
+            </button>
+          </div>
+        )}
+      </div>
+
+      {/* Sfondo con le aree disegnate sopra */}
+      <div className="flex-grow relative border rounded overflow-auto bg-gray-100 dark:bg-gray-900">
+        {locationImageUrl ? (
+          <div
+            className="relative"
+            style={imageSize ? { width: `${imageSize.width}px`, height: `${imageSize.height}px`, margin: 'auto' } : undefined}
+          >
+            <img
+              ref={imageRef}
+              src={locationImageUrl}
+              alt="Location background"
+              onLoad={handleImageLoad}
+              style={{ display: 'block', position: 'absolute', top: 0, left: 0 }}
+            />
+            <canvas
+              ref={canvasRef}
+              onClick={handleCanvasClick}
+              style={{
+                position: 'absolute',
+                top: 0,
+                left: 0,
+                cursor: selectedArea ? 'crosshair' : 'default',
+              }}
+            />
+          </div>
+        ) : (
+          <div className="text-center text-gray-500 dark:text-gray-400 mt-10">
+            Load a background image to draw walkable areas.
+          </div>
+        )}
+      </div>
+    </div>
+  );
+};
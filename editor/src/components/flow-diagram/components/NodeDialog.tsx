@@ -1,5 +1,5 @@
 import React, { useState, useEffect, Dispatch, SetStateAction } from 'react';
-import { Node, Entity, EntityType, VERBS, AnyEntity, PREDEFINED_ENTITIES, ActionNode, StateNode, NodeFlag } from '../types/index';
+import { Node, Entity, EntityType, VERBS, AnyEntity, PREDEFINED_ENTITIES, ActionNode, StateNode, NodeFlag, Hotspot, LocationEntity } from '../types/index';
 import { v4 as uuidv4 } from 'uuid';
 import {
   Dialog,
@@ -19,6 +19,17 @@ import {
      default: return ['Character'];
    }
  };
+
+ // Gli hotspot sono bersagli come item e personaggi, non come location
+ const acceptsHotspots = (entityTypes: EntityType[]): boolean =>
+   entityTypes.includes('Item') || entityTypes.includes('Character');
+
+ // Hotspot della location (ID o nome) in cui avviene l'azione: non sono entità, vivono nei dettagli della location
+ const locationHotspots = (where: string | undefined, allEntities: Entity[]): Hotspot[] => {
+   if (!where) return [];
+   const location = allEntities.find(e => e.type === 'Location' && !e.internal && (e.id === where || e.name === where)) as LocationEntity | undefined;
+   return location?.details?.hotspots || [];
+ };
  
  interface EntityDropdownProps {
    value: string;
@@ -28,6 +39,7 @@ import {
    onAddEntity: (fieldName: string, type: EntityType) => void;
    fieldName: string;
    placeholder?: string;
+   hotspots?: Hotspot[]; // Hotspot della location dell'azione, elencati dopo le entità
  }
  
  const EntityDropdown: React.FC<EntityDropdownProps> = ({
@@ -37,7 +49,8 @@ import {
    entities,
    onAddEntity,
    fieldName,
-   placeholder
+   placeholder,
+   hotspots = []
  }) => {
    const groupedEntities = entityTypes.reduce((acc, type) => {
      const typeEntities = entities.filter(e => e.type === type);
@@ -86,6 +99,17 @@ import {
              ))}
            </React.Fragment>
          ))}
+         {hotspots.length > 0 && (
+           <>
+             <option disabled>──────────</option>
+             <option disabled>Hotspots</option>
+             {hotspots.map(hotspot => (
+               <option key={hotspot.id} value={hotspot.id}>
+                 {hotspot.name}
+               </option>
+             ))}
+           </>
+         )}
        </select>
        <button
          type="button"
@@ -100,7 +124,8 @@ import {
  
  // Funzione helper per risolvere un nome di entità (potenzialmente predefinito) al suo ID GUID
  // o restituire il valore se è già un GUID valido o non trovato.
- const resolveEntityId = (value: string | undefined, allEntities: Entity[]): string => {
+ // Gli hotspot della location dell'azione mantengono il loro ID.
+ const resolveEntityId = (value: string | undefined, allEntities: Entity[], hotspots: Hotspot[] = []): string => {
    if (!value) return '';
 
    // Cerca prima tra le PREDEFINED_ENTITIES per nome
@@ -111,12 +136,18 @@ import {
    // (allEntities dovrebbe includere PREDEFINED_ENTITIES per coerenza, ma PREDEFINED_ENTITIES ha la priorità per i nomi speciali)
    const existingEntityById = allEntities.find(e => e.id === value);
    if (existingEntityById) return existingEntityById.id; // È già un GUID valido
+
+   const hotspotById = hotspots.find(h => h.id === value);
+   if (hotspotById) return hotspotById.id;
    
    // Fallback: se value è un nome non predefinito e non un GUID esistente, 
    // potrebbe essere un nome di un'entità utente. Cerchiamo per nome.
    const userEntityByName = allEntities.find(e => e.name === value && !e.internal);
    if (userEntityByName) return userEntityByName.id;
 
+   const hotspotByName = hotspots.find(h => h.name === value);
+   if (hotspotByName) return hotspotByName.id;
+
    // Se non trovato, potrebbe essere un vecchio valore o un errore, restituisce vuoto o il valore originale?
    // Per le dropdown, è meglio restituire '' per evitare di passare un valore non valido.
    // console.warn(`resolveEntityId: Could not resolve '${value}' to a valid entity ID.`);
@@ -144,11 +175,12 @@ import {
      if (open && node) {
        if (node.type === 'action') {
          const actionNode = node as ActionNode;
+         const hotspots = locationHotspots(actionNode.where, entities);
          setTempValues({
            ...actionNode,
            from: resolveEntityId(actionNode.from || 'SOMEONE', entities),
-           to: resolveEntityId(actionNode.to, entities),
-           with: resolveEntityId(actionNode.with, entities),
+           to: resolveEntityId(actionNode.to, entities, hotspots),
+           with: resolveEntityId(actionNode.with, entities, hotspots),
            where: resolveEntityId(actionNode.where, entities),
          });
        } else {
@@ -227,6 +259,11 @@ import {
        if (currentToEntity && newToTypes.includes(currentToEntity.type)) {
          resetTo = false; // L'entità attuale è ancora valida
        }
+       const currentToHotspot = locationHotspots((tempValues as Partial<ActionNode>).where, entities)
+         .some(h => h.id === (tempValues as Partial<ActionNode>).to);
+       if (currentToHotspot && acceptsHotspots(newToTypes)) {
+         resetTo = false; // Anche l'hotspot resta un bersaglio valido
+       }
 
        setTempValues(prev => ({
          ...prev,
@@ -293,6 +330,7 @@ import {
    // Determina i tipi di entità per il campo 'to' basati sul verbo corrente
    const toEntityTypes = node && node.type === 'action' ? getToEntityTypes((tempValues as Partial<ActionNode>).verb || '') : [];
    const defaultFromValue = resolveEntityId('SOMEONE', entities); 
+   const whereHotspots = node && node.type === 'action' ? locationHotspots((tempValues as Partial<ActionNode>).where, entities) : [];
  
    return (
      <Dialog open={open} onOpenChange={(isOpen) => {
@@ -355,6 +393,7 @@ import {
                    onAddEntity={handleAddEntity}
                    fieldName="to"
                    placeholder={`Select ${toEntityTypes.join('/')}`}
+                   hotspots={acceptsHotspots(toEntityTypes) ? whereHotspots : []}
                  />
                </div>
                {(tempValues as Partial<ActionNode>).verb === 'Interact with' && (
@@ -368,6 +407,7 @@ import {
                      onAddEntity={handleAddEntity}
                      fieldName="with"
                      placeholder="Select Item"
+                     hotspots={whereHotspots}
                    />
                  </div>
                )}
@@ -42,6 +42,7 @@ export interface Position {
     with: string;    // Con quale strumento/mezzo
     where: string;   // Dove viene eseguita l'azione
     script?: string;  // Script da eseguire
+    dialogue?: string; // Dialogo da avviare (ID dell'entità Dialogue)
   }
   
   // Interfaccia per un singolo flag di nodo
@@ -80,7 +81,7 @@ export interface Position {
     } | null;
   }
 
-export type EntityType = 'Character' | 'Item' | 'Location' | 'Action' | 'Font' | 'Script' | 'Cursor';
+export type EntityType = 'Character' | 'Item' | 'Location' | 'Action' | 'Font' | 'Script' | 'Cursor' | 'Dialogue';
 
 export interface Entity {
   id: string;
@@ -110,7 +111,7 @@ export interface CharacterDetails {
   inventoryImageData?: string;
   animations?: Animation[];
   interactionSpot?: Point; // Aggiunto per il punto di interazione
-  talkColor?: string; // Colore del testo dei dialoghi (es: #RRGGBB)
+  talkColor?: string; // Colore del testo dei dialoghi (es: #RRGGBB), bianco se assente
 }
 
 export interface ItemDetails {
@@ -123,10 +124,67 @@ export interface ItemDetails {
   interactionSpot?: Point; // Aggiunto per il punto di interazione
 }
 
+// Area calpestabile con nome: gli script la attivano e disattivano, disabled è lo stato iniziale
+export interface NamedWalkableArea {
+  name: string;
+  polygons: Polygon[];
+  disabled?: boolean;
+}
+
+// Scala dei personaggi: farScale sulla linea farY, nearScale sulla linea nearY, interpolata tra le due
+export interface ScaleArea {
+  farY: number;
+  farScale: number;
+  nearY: number;
+  nearScale: number;
+}
+
+// Layer della location: zOrder > 0 davanti ai personaggi, parallax 1 (default) scorre con la stanza, 0 resta fermo
+export interface LocationLayer {
+  image: string;
+  zOrder?: number;
+  parallax?: number;
+  transparentColor?: string; // #RRGGBB
+}
+
+// Maschera (grande quanto lo sfondo) delle parti che coprono i personaggi sopra la baseline
+export interface WalkBehind {
+  mask: string;
+  baseline: number;
+}
+
+// Uscita verso un'altra location: chi entra nell'area arriva in entryPoint di targetLocation (ID).
+// onExit e onEnter sono i nomi degli script eseguiti all'uscita e all'arrivo
+export interface Exit {
+  name?: string;
+  area: Polygon;
+  targetLocation: string;
+  entryPoint: Point;
+  entryDirection?: 'LEFT' | 'RIGHT' | 'UP' | 'DOWN';
+  onExit?: string;
+  onEnter?: string;
+}
+
+// Regione interattiva dipinta nello sfondo, senza sprite: le azioni la usano come to/with tramite id.
+// Il personaggio va in interactionSpot e si gira verso direction
+export interface Hotspot {
+  id: string;
+  name: string;
+  area: Polygon;
+  interactionSpot: Point;
+  direction?: 'LEFT' | 'RIGHT' | 'UP' | 'DOWN';
+}
+
 export interface LocationDetails {
   description: string;
   backgroundImage?: string;
   walkableArea?: Polygon[]; // Array di poligoni - saved to JSON as walkableArea
+  walkableAreas?: NamedWalkableArea[]; // Aree attivabili dagli script (game:EnableWalkableArea)
+  scaleArea?: ScaleArea; // Scala dei personaggi in base alla profondità
+  layers?: LocationLayer[]; // Immagini sopra lo sfondo, in ordine di zOrder
+  walkBehinds?: WalkBehind[]; // Parti dello sfondo che coprono i personaggi
+  exits?: Exit[]; // Uscite verso le altre location
+  hotspots?: Hotspot[]; // Regioni interattive dello sfondo
   placedItems?: PlacedEntity[];
   placedCharacters?: PlacedEntity[];
   backgroundColor?: string;
@@ -145,7 +203,42 @@ export interface CursorDetails {
   animations: Animation[];
 }
 
-export type EntityDetails = CharacterDetails | ItemDetails | LocationDetails | ActionDetails | FontDetails | ScriptDetails | CursorDetails;
+// Condizione per mostrare un'opzione di dialogo (flag oppure contatore)
+export interface DialogueCondition {
+  flag?: string;
+  value?: boolean;
+  counter?: string;
+  operator?: '==' | '!=' | '<' | '<=' | '>' | '>=';
+  amount?: number;
+}
+
+export interface DialogueLine {
+  speaker?: string; // ID del personaggio che parla (vuoto = personaggio corrente)
+  text: string;
+  script?: string;  // Hook Lua eseguito prima di mostrare la battuta
+}
+
+export interface DialogueOption {
+  id: string;
+  text: string;
+  conditions?: DialogueCondition[];
+  hideAfterChosen?: boolean;
+  script?: string;
+  next?: string; // ID del nodo successivo (vuoto = fine dialogo)
+}
+
+export interface DialogueNode {
+  id: string;
+  lines?: DialogueLine[];
+  options?: DialogueOption[];
+}
+
+export interface DialogueDetails {
+  startNode: string;
+  nodes: DialogueNode[];
+}
+
+export type EntityDetails = CharacterDetails | ItemDetails | LocationDetails | ActionDetails | FontDetails | ScriptDetails | CursorDetails | DialogueDetails;
 
 export interface Point {
   x: number;
@@ -195,8 +288,13 @@ export interface CursorEntity extends Entity {
   details?: CursorDetails;
 }
 
+export interface DialogueEntity extends Entity {
+  type: 'Dialogue';
+  details?: DialogueDetails;
+}
+
 // Tipo Entity come unione discriminata basata sulle interfacce specifiche
-export type AnyEntity = CharacterEntity | ItemEntity | LocationEntity | ActionEntity | FontEntity | ScriptEntity | CursorEntity;
+export type AnyEntity = CharacterEntity | ItemEntity | LocationEntity | ActionEntity | FontEntity | ScriptEntity | CursorEntity | DialogueEntity;
 
 // Placeholder for ActionDetails - define its fields as needed
 export interface ActionDetails {
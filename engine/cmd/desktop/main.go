@@ -2,20 +2,24 @@ package main
 
 import (
 	"chemistry/engine/logic"
+	"chemistry/engine/render"
+	"flag"
 	"fmt"
 	_ "image/jpeg"
 	_ "image/png"
 	"log"
 	"os"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
-var game logic.Game
-
-func init() {
+func main() {
+	record := flag.String("record", "", "record the input of the session to this file")
+	replay := flag.String("replay", "", "replay the input recorded in this file")
+	flag.Parse()
 
-	game = logic.NewGame()
+	game := logic.NewGame()
 
 	err := game.LoadGameData("demo3_packaged.dat")
 	if err != nil {
@@ -26,15 +30,43 @@ func init() {
 	//data.InitGenericData(&game)
 	//data.InitCustomData(&game)
 
-	game.ExecuteScript("Intro")
-}
+	renderer := render.NewRenderer(&game)
 
-func main() {
+	// Un replay riparte dal seed con cui è stato registrato
+	if *replay != "" {
+		file, err := os.Open(*replay)
+		if err != nil {
+			log.Fatalf("Error opening replay: %v", err)
+		}
+		inputReplay, err := logic.LoadInputReplay(file, &game)
+		file.Close()
+		if err != nil {
+			log.Fatalf("Error loading replay %s: %v", *replay, err)
+		}
+		renderer.ReplayFrom(inputReplay)
+	} else {
+		game.SetRandomSeed(time.Now().UnixNano())
+	}
+
+	if *record != "" {
+		file, err := os.Create(*record)
+		if err != nil {
+			log.Fatalf("Error creating recording: %v", err)
+		}
+		defer file.Close()
+		recorder, err := logic.NewInputRecorder(file, &game)
+		if err != nil {
+			log.Fatalf("Error starting recording %s: %v", *record, err)
+		}
+		renderer.RecordTo(recorder)
+	}
+
+	game.ExecuteScript("Intro")
 
 	ebiten.SetFullscreen(true)
 	ebiten.SetCursorMode(ebiten.CursorModeHidden)
 	//ebiten.SetWindowSize(1920, 1080)
-	if err := ebiten.RunGame(&game); err != nil {
+	if err := ebiten.RunGame(renderer); err != nil {
 		log.Fatal(err)
 	}
 
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"chemistry/engine/model"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -8,6 +10,9 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
 	"log"
 	"os"
@@ -22,6 +27,55 @@ type Point struct {
 
 type Polygon []Point
 
+// NamedWalkableArea è un'area calpestabile che gli script possono attivare e disattivare
+type NamedWalkableArea struct {
+	Name     string    `json:"name"`
+	Polygons []Polygon `json:"polygons"`
+	Disabled bool      `json:"disabled,omitempty"`
+}
+
+// LocationLayer è un'immagine disegnata sopra lo sfondo, davanti ai personaggi se ZOrder è maggiore di 0
+type LocationLayer struct {
+	ImageRef         *BinaryRef `json:"imageRef,omitempty"`
+	ZOrder           int        `json:"zOrder,omitempty"`
+	Parallax         *float64   `json:"parallax,omitempty"`
+	TransparentColor string     `json:"transparentColor,omitempty"`
+}
+
+// WalkBehind è una maschera dello sfondo che copre i personaggi sopra la Baseline
+type WalkBehind struct {
+	MaskRef  *BinaryRef `json:"maskRef,omitempty"`
+	Baseline int        `json:"baseline"`
+}
+
+// Exit è una regione della location che porta a TargetLocation (ID), dove si entra da EntryPoint
+type Exit struct {
+	Name           string  `json:"name,omitempty"`
+	Area           Polygon `json:"area"`
+	TargetLocation string  `json:"targetLocation"`
+	EntryPoint     Point   `json:"entryPoint"`
+	EntryDirection string  `json:"entryDirection,omitempty"`
+	OnExit         string  `json:"onExit,omitempty"`
+	OnEnter        string  `json:"onEnter,omitempty"`
+}
+
+// Hotspot è una regione interattiva dello sfondo, le azioni la usano tramite ID
+type Hotspot struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Area            Polygon `json:"area"`
+	InteractionSpot Point   `json:"interactionSpot"`
+	Direction       string  `json:"direction,omitempty"`
+}
+
+// ScaleArea è la scala dei personaggi su due linee orizzontali della location
+type ScaleArea struct {
+	FarY      int     `json:"farY"`
+	FarScale  float64 `json:"farScale"`
+	NearY     int     `json:"nearY"`
+	NearScale float64 `json:"nearScale"`
+}
+
 type PlacedEntity struct {
 	EntityID        string `json:"entityId"`
 	Position        Point  `json:"position"`
@@ -35,8 +89,9 @@ type BinaryRef struct {
 }
 
 type AnimationFrame struct {
-	ImageRef *BinaryRef `json:"imageRef,omitempty"`
-	Duration int        `json:"duration,omitempty"`
+	ImageRef   *BinaryRef `json:"imageRef,omitempty"`
+	HitMaskRef *BinaryRef `json:"hitMaskRef,omitempty"` // Solo per gli item
+	Duration   int        `json:"duration,omitempty"`
 }
 
 type Animation struct {
@@ -47,12 +102,18 @@ type Animation struct {
 
 // --- Dettagli Entità ---
 type LocationDetails struct {
-	Description      string         `json:"description,omitempty"`
-	BackgroundRef    *BinaryRef     `json:"backgroundRef,omitempty"`
-	WalkableArea     []Polygon      `json:"walkableArea,omitempty"`
-	PlacedItems      []PlacedEntity `json:"placedItems,omitempty"`
-	PlacedCharacters []PlacedEntity `json:"placedCharacters,omitempty"`
-	BackgroundColor  string         `json:"backgroundColor,omitempty"`
+	Description      string              `json:"description,omitempty"`
+	BackgroundRef    *BinaryRef          `json:"backgroundRef,omitempty"`
+	WalkableArea     []Polygon           `json:"walkableArea,omitempty"`
+	WalkableAreas    []NamedWalkableArea `json:"walkableAreas,omitempty"`
+	ScaleArea        *ScaleArea          `json:"scaleArea,omitempty"`
+	Layers           []LocationLayer     `json:"layers,omitempty"`
+	WalkBehinds      []WalkBehind        `json:"walkBehinds,omitempty"`
+	Exits            []Exit              `json:"exits,omitempty"`
+	Hotspots         []Hotspot           `json:"hotspots,omitempty"`
+	PlacedItems      []PlacedEntity      `json:"placedItems,omitempty"`
+	PlacedCharacters []PlacedEntity      `json:"placedCharacters,omitempty"`
+	BackgroundColor  string              `json:"backgroundColor,omitempty"`
 }
 
 type CharacterDetails struct {
@@ -61,11 +122,13 @@ type CharacterDetails struct {
 	InventoryImageRef *BinaryRef  `json:"inventoryImageRef,omitempty"`
 	Animations        []Animation `json:"animations,omitempty"`
 	InteractionSpot   *Point      `json:"interactionSpot,omitempty"`
+	TalkColor         string      `json:"talkColor,omitempty"` // #RRGGBB
 }
 
 type ItemDetails struct {
 	Description       string      `json:"description,omitempty"`
 	ImageRef          *BinaryRef  `json:"imageRef,omitempty"`
+	HitMaskRef        *BinaryRef  `json:"hitMaskRef,omitempty"`
 	CanBePickedUp     bool        `json:"canBePickedUp,omitempty"`
 	InventoryImageRef *BinaryRef  `json:"inventoryImageRef,omitempty"`
 	Animations        []Animation `json:"animations,omitempty"`
@@ -90,6 +153,40 @@ type CursorDetails struct {
 	Animations []Animation `json:"animations,omitempty"`
 }
 
+type DialogueCondition struct {
+	Flag     string `json:"flag,omitempty"`
+	Value    bool   `json:"value,omitempty"`
+	Counter  string `json:"counter,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Amount   int    `json:"amount,omitempty"`
+}
+
+type DialogueLine struct {
+	Speaker string `json:"speaker,omitempty"`
+	Text    string `json:"text"`
+	Script  string `json:"script,omitempty"`
+}
+
+type DialogueOption struct {
+	ID              string              `json:"id"`
+	Text            string              `json:"text"`
+	Conditions      []DialogueCondition `json:"conditions,omitempty"`
+	HideAfterChosen bool                `json:"hideAfterChosen,omitempty"`
+	Script          string              `json:"script,omitempty"`
+	Next            string              `json:"next,omitempty"`
+}
+
+type DialogueNode struct {
+	ID      string           `json:"id"`
+	Lines   []DialogueLine   `json:"lines,omitempty"`
+	Options []DialogueOption `json:"options,omitempty"`
+}
+
+type DialogueDetails struct {
+	StartNode string         `json:"startNode"`
+	Nodes     []DialogueNode `json:"nodes,omitempty"`
+}
+
 // --- Nodi del Diagramma ---
 type NodeFlag struct {
 	Name  string `json:"name"`
@@ -117,10 +214,11 @@ type GenericEntity struct {
 
 // --- ProjectData (Struttura principale del JSON) ---
 type ProjectData struct {
-	Version     string            `json:"version"`
-	ProjectName string            `json:"projectName"`
-	Nodes       []json.RawMessage `json:"nodes"`
-	Entities    []GenericEntity   `json:"entities"`
+	Version     string             `json:"version"`
+	ProjectName string             `json:"projectName"`
+	Nodes       []json.RawMessage  `json:"nodes"`
+	Entities    []GenericEntity    `json:"entities"`
+	Interface   *InterfaceSettings `json:"interface,omitempty"`
 }
 
 // Strutture complete per le Entità
@@ -180,6 +278,14 @@ type Cursor struct {
 	Details  *CursorDetails `json:"details,omitempty"`
 }
 
+type Dialogue struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Name     string           `json:"name"`
+	Internal bool             `json:"internal,omitempty"`
+	Details  *DialogueDetails `json:"details,omitempty"`
+}
+
 type TypedNode struct {
 	ID          string          `json:"id"`
 	Type        string          `json:"type"`
@@ -192,30 +298,78 @@ type TypedNode struct {
 	With        string          `json:"with,omitempty"`
 	Where       string          `json:"where,omitempty"`
 	Script      string          `json:"script,omitempty"`
+	Dialogue    string          `json:"dialogue,omitempty"`
 	Description string          `json:"description,omitempty"`
 	Flags       []NodeFlag      `json:"flags,omitempty"`
 }
 
 type PackagedGameData struct {
-	ProjectName  string      `json:"projectName"`
-	Version      string      `json:"version"`
-	DiagramNodes []TypedNode `json:"diagramNodes"`
-	Locations    []Location  `json:"locations"`
-	Characters   []Character `json:"characters"`
-	Items        []Item      `json:"items"`
-	Fonts        []Font      `json:"fonts"`
-	Scripts      []Script    `json:"scripts"`
-	Cursors      []Cursor    `json:"cursors"`
+	ProjectName  string             `json:"projectName"`
+	Version      string             `json:"version"`
+	DiagramNodes []TypedNode        `json:"diagramNodes"`
+	Locations    []Location         `json:"locations"`
+	Characters   []Character        `json:"characters"`
+	Items        []Item             `json:"items"`
+	Fonts        []Font             `json:"fonts"`
+	Scripts      []Script           `json:"scripts"`
+	Cursors      []Cursor           `json:"cursors"`
+	Dialogues    []Dialogue         `json:"dialogues,omitempty"`
+	Interface    *InterfaceSettings `json:"interface,omitempty"`
+}
+
+// InterfaceSettings is the project-level layout of the verb interface, in screen coordinates.
+// Missing values keep the engine defaults.
+type InterfaceSettings struct {
+	Verbs        []string          `json:"verbs,omitempty"`
+	VerbLabels   map[string]string `json:"verbLabels,omitempty"`
+	Prepositions map[string]string `json:"prepositions,omitempty"`
+	VerbColumns  int               `json:"verbColumns,omitempty"`
+	SentenceLine *ScreenRect       `json:"sentenceLine,omitempty"`
+	VerbBar      *ScreenRect       `json:"verbBar,omitempty"`
+	Inventory    *ScreenRect       `json:"inventory,omitempty"`
+	Cursors      *CursorSettings   `json:"cursors,omitempty"`
+}
+
+// CursorSettings sceglie il set di cursori (il nome di un cursore) di ogni verbo.
+// Le animazioni del set prendono il nome dello stato: DEFAULT, ITEM, EXIT, CHARACTER, BUSY
+type CursorSettings struct {
+	Default string            `json:"default,omitempty"`
+	Verbs   map[string]string `json:"verbs,omitempty"`
+}
+
+type ScreenRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
 }
 
 // Strutture originali per il parsing
 type LocationDetailsOrig struct {
-	Description      string         `json:"description,omitempty"`
-	BackgroundImage  string         `json:"backgroundImage,omitempty"`
-	WalkableArea     []Polygon      `json:"walkableArea,omitempty"`
-	PlacedItems      []PlacedEntity `json:"placedItems,omitempty"`
-	PlacedCharacters []PlacedEntity `json:"placedCharacters,omitempty"`
-	BackgroundColor  string         `json:"backgroundColor,omitempty"`
+	Description      string              `json:"description,omitempty"`
+	BackgroundImage  string              `json:"backgroundImage,omitempty"`
+	WalkableArea     []Polygon           `json:"walkableArea,omitempty"`
+	WalkableAreas    []NamedWalkableArea `json:"walkableAreas,omitempty"`
+	ScaleArea        *ScaleArea          `json:"scaleArea,omitempty"`
+	Layers           []LocationLayerOrig `json:"layers,omitempty"`
+	WalkBehinds      []WalkBehindOrig    `json:"walkBehinds,omitempty"`
+	Exits            []Exit              `json:"exits,omitempty"`
+	Hotspots         []Hotspot           `json:"hotspots,omitempty"`
+	PlacedItems      []PlacedEntity      `json:"placedItems,omitempty"`
+	PlacedCharacters []PlacedEntity      `json:"placedCharacters,omitempty"`
+	BackgroundColor  string              `json:"backgroundColor,omitempty"`
+}
+
+type LocationLayerOrig struct {
+	Image            string   `json:"image,omitempty"`
+	ZOrder           int      `json:"zOrder,omitempty"`
+	Parallax         *float64 `json:"parallax,omitempty"`
+	TransparentColor string   `json:"transparentColor,omitempty"`
+}
+
+type WalkBehindOrig struct {
+	Mask     string `json:"mask,omitempty"`
+	Baseline int    `json:"baseline"`
 }
 
 type CharacterDetailsOrig struct {
@@ -224,6 +378,7 @@ type CharacterDetailsOrig struct {
 	InventoryImageData string          `json:"inventoryImageData,omitempty"`
 	Animations         []AnimationOrig `json:"animations,omitempty"`
 	InteractionSpot    *Point          `json:"interactionSpot,omitempty"`
+	TalkColor          string          `json:"talkColor,omitempty"` // Colore del testo dei dialoghi (#RRGGBB)
 }
 
 type ItemDetailsOrig struct {
@@ -264,6 +419,31 @@ func writeBinaryData(binFile *os.File, data string) (*BinaryRef, error) {
 		return nil, nil
 	}
 
+	decoded, err := decodeDataURL(data)
+	if err != nil {
+		return nil, err
+	}
+	return writeBytes(binFile, decoded)
+}
+
+// writeHitMask scrive la hit mask di un'immagine: i pixel opachi, un bit per pixel (vedi model.HitMask)
+func writeHitMask(binFile *os.File, data string) (*BinaryRef, error) {
+	decoded, err := decodeDataURL(data)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(decoded))
+	if err != nil {
+		return nil, err
+	}
+	mask, err := model.NewHitMask(img).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return writeBytes(binFile, mask)
+}
+
+func decodeDataURL(data string) ([]byte, error) {
 	// Handle data URL format (data:mime/type;base64,actualdata)
 	var base64Data string
 	if strings.HasPrefix(data, "data:") {
@@ -276,11 +456,10 @@ func writeBinaryData(binFile *os.File, data string) (*BinaryRef, error) {
 		base64Data = data
 	}
 
-	decoded, err := base64.StdEncoding.DecodeString(base64Data)
-	if err != nil {
-		return nil, err
-	}
+	return base64.StdEncoding.DecodeString(base64Data)
+}
 
+func writeBytes(binFile *os.File, decoded []byte) (*BinaryRef, error) {
 	offset, err := binFile.Seek(0, os.SEEK_CUR)
 	if err != nil {
 		return nil, err
@@ -363,6 +542,7 @@ func main() {
 	var fonts []Font
 	var scripts []Script
 	var cursors []Cursor
+	var dialogues []Dialogue
 
 	for _, genericEntity := range projectData.Entities {
 		switch genericEntity.Type {
@@ -378,6 +558,10 @@ func main() {
 			details := LocationDetails{
 				Description:      detailsOrig.Description,
 				WalkableArea:     detailsOrig.WalkableArea,
+				WalkableAreas:    detailsOrig.WalkableAreas,
+				ScaleArea:        detailsOrig.ScaleArea,
+				Exits:            detailsOrig.Exits,
+				Hotspots:         detailsOrig.Hotspots,
 				PlacedItems:      detailsOrig.PlacedItems,
 				PlacedCharacters: detailsOrig.PlacedCharacters,
 				BackgroundColor:  detailsOrig.BackgroundColor,
@@ -392,6 +576,29 @@ func main() {
 				}
 			}
 
+			for i, layerOrig := range detailsOrig.Layers {
+				ref, err := writeBinaryData(binFile, layerOrig.Image)
+				if err != nil {
+					log.Printf("Error writing layer %d for %s: %v\n", i, genericEntity.ID, err)
+					continue
+				}
+				details.Layers = append(details.Layers, LocationLayer{
+					ImageRef:         ref,
+					ZOrder:           layerOrig.ZOrder,
+					Parallax:         layerOrig.Parallax,
+					TransparentColor: layerOrig.TransparentColor,
+				})
+			}
+
+			for i, walkBehindOrig := range detailsOrig.WalkBehinds {
+				ref, err := writeBinaryData(binFile, walkBehindOrig.Mask)
+				if err != nil {
+					log.Printf("Error writing walk-behind mask %d for %s: %v\n", i, genericEntity.ID, err)
+					continue
+				}
+				details.WalkBehinds = append(details.WalkBehinds, WalkBehind{MaskRef: ref, Baseline: walkBehindOrig.Baseline})
+			}
+
 			locations = append(locations, Location{ID: genericEntity.ID, Type: genericEntity.Type, Name: genericEntity.Name, Internal: genericEntity.Internal, Details: &details})
 
 		case "Character":
@@ -406,6 +613,7 @@ func main() {
 			details := CharacterDetails{
 				Description:     detailsOrig.Description,
 				InteractionSpot: detailsOrig.InteractionSpot,
+				TalkColor:       detailsOrig.TalkColor,
 			}
 
 			if detailsOrig.ImageData != "" {
@@ -468,6 +676,14 @@ func main() {
 				} else {
 					details.ImageRef = ref
 				}
+
+				// Il motore usa la hit mask per capire su quale item si trova il cursore
+				maskRef, err := writeHitMask(binFile, detailsOrig.ImageData)
+				if err != nil {
+					log.Printf("Error writing hit mask for %s: %v\n", genericEntity.ID, err)
+				} else {
+					details.HitMaskRef = maskRef
+				}
 			}
 
 			if detailsOrig.InventoryImageData != "" {
@@ -490,6 +706,12 @@ func main() {
 						} else {
 							frame.ImageRef = ref
 						}
+						maskRef, err := writeHitMask(binFile, frameOrig.ImageData)
+						if err != nil {
+							log.Printf("Error writing animation frame hit mask: %v\n", err)
+						} else {
+							frame.HitMaskRef = maskRef
+						}
 					}
 					anim.Frames = append(anim.Frames, frame)
 				}
@@ -575,11 +797,23 @@ func main() {
 			}
 
 			cursors = append(cursors, Cursor{ID: genericEntity.ID, Type: genericEntity.Type, Name: genericEntity.Name, Internal: genericEntity.Internal, Details: &details})
+
+		case "Dialogue":
+			// Dialogues are plain text, no binary data to extract
+			var details DialogueDetails
+			if len(genericEntity.DetailsRaw) > 0 && string(genericEntity.DetailsRaw) != "null" {
+				if err := json.Unmarshal(genericEntity.DetailsRaw, &details); err != nil {
+					log.Printf("Error unmarshalling DialogueDetails for entity %s: %v\n", genericEntity.ID, err)
+					continue
+				}
+			}
+
+			dialogues = append(dialogues, Dialogue{ID: genericEntity.ID, Type: genericEntity.Type, Name: genericEntity.Name, Internal: genericEntity.Internal, Details: &details})
 		}
 	}
 
-	log.Printf("Parsed Locations: %d, Characters: %d, Items: %d, Fonts: %d, Scripts: %d, Cursors: %d\n",
-		len(locations), len(characters), len(items), len(fonts), len(scripts), len(cursors))
+	log.Printf("Parsed Locations: %d, Characters: %d, Items: %d, Fonts: %d, Scripts: %d, Cursors: %d, Dialogues: %d\n",
+		len(locations), len(characters), len(items), len(fonts), len(scripts), len(cursors), len(dialogues))
 
 	gameDataToPackage := PackagedGameData{
 		ProjectName:  projectData.ProjectName,
@@ -591,6 +825,8 @@ func main() {
 		Fonts:        fonts,
 		Scripts:      scripts,
 		Cursors:      cursors,
+		Dialogues:    dialogues,
+		Interface:    projectData.Interface,
 	}
 
 	// Serializza JSON in memoria
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"chemistry/engine/logic"
+	"flag"
+	"fmt"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+)
+
+// walkthrough replays a text file of commands on a packaged game and checks the
+// state of the game after each of them, to verify the game can still be completed.
+//
+// Usage: walkthrough [-script Intro] [-v] <game.dat> <walkthrough.txt>
+func main() {
+	script := flag.String("script", "Intro", "script executed after loading the game, empty for none")
+	verbose := flag.Bool("v", false, "show the engine log")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <game.dat> <walkthrough.txt>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	walkthroughPath := flag.Arg(1)
+	file, err := os.Open(walkthroughPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening walkthrough: %v\n", err)
+		os.Exit(2)
+	}
+	steps, err := logic.ParseWalkthrough(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", walkthroughPath, err)
+		os.Exit(2)
+	}
+
+	game := logic.NewGame()
+	if err := game.LoadPackagedGameData(flag.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error LoadPackagedGameData: %v\n", err)
+		os.Exit(2)
+	}
+	if *script != "" {
+		game.ExecuteScript(*script)
+	}
+
+	for i, step := range steps {
+		if step.Command != "" {
+			if err := game.RunWalkthroughCommand(step.Command); err != nil {
+				fmt.Fprintf(os.Stderr, "%s:%d: %s: %v\n", walkthroughPath, step.Line, step.Command, err)
+				os.Exit(1)
+			}
+		}
+
+		mismatches := game.CheckWalkthroughExpectations(step.Expectations)
+		if len(mismatches) == 0 {
+			continue
+		}
+
+		description := "initial state"
+		if step.Command != "" {
+			description = fmt.Sprintf("after %q", step.Command)
+		}
+		fmt.Fprintf(os.Stderr, "%s:%d: step %d, %s, the game diverges:\n", walkthroughPath, step.Line, i+1, description)
+		for _, mismatch := range mismatches {
+			expectation := mismatch.Expectation
+			fmt.Fprintf(os.Stderr, "  line %d: %s %s\n", expectation.Line, expectation.Kind, expectation.Name)
+			fmt.Fprintf(os.Stderr, "    - expected: %s\n", mismatch.Expected)
+			fmt.Fprintf(os.Stderr, "    + actual:   %s\n", mismatch.Actual)
+		}
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s: %d steps completed\n", walkthroughPath, len(steps))
+}
@@ -0,0 +1,190 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// AnimationPlayer plays an animation with the frame durations of the game data. A looping
+// animation starts over after its last frame, a one-shot one stops there and is finished.
+type AnimationPlayer struct {
+	Animation      string
+	AnimationFrame int
+	once           bool
+	finished       bool
+	frameStarted   time.Duration
+	onFinished     []func()
+}
+
+// Play starts an animation from its first frame; it loops if the animation does
+func (p *AnimationPlayer) Play(name string, now time.Duration) {
+	p.start(name, false, now)
+}
+
+// PlayOnce starts an animation from its first frame and stops on the last one, even if the animation loops
+func (p *AnimationPlayer) PlayOnce(name string, now time.Duration) {
+	p.start(name, true, now)
+}
+
+func (p *AnimationPlayer) start(name string, once bool, now time.Duration) {
+	callbacks := p.onFinished
+	*p = AnimationPlayer{Animation: name, once: once, frameStarted: now}
+
+	// Chi aspettava l'animazione precedente non resta in attesa per sempre
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// OnFinished calls callback when the animation being played is over (or replaced by another one),
+// right away if it already is. A looping animation is never over.
+func (p *AnimationPlayer) OnFinished(callback func()) {
+	if p.finished {
+		callback()
+		return
+	}
+	p.onFinished = append(p.onFinished, callback)
+}
+
+// Finished reports whether a one-shot animation has shown its last frame
+func (p *AnimationPlayer) Finished() bool {
+	return p.finished
+}
+
+// Update shows the next frame once the current one has been shown for its duration.
+// frames is the number of frames of the animation.
+func (p *AnimationPlayer) Update(now time.Duration, frames int, timing model.AnimationTiming) {
+	if p.finished || frames == 0 {
+		return
+	}
+	if p.AnimationFrame < 0 || p.AnimationFrame >= frames {
+		p.AnimationFrame = 0
+	}
+	if now-p.frameStarted < timing.FrameDuration(p.AnimationFrame) {
+		return
+	}
+	p.frameStarted = now
+
+	switch {
+	case p.AnimationFrame+1 < frames:
+		p.AnimationFrame++
+	case timing.Loop && !p.once:
+		p.AnimationFrame = 0
+	default:
+		p.finished = true
+		callbacks := p.onFinished
+		p.onFinished = nil
+		for _, callback := range callbacks {
+			callback()
+		}
+	}
+}
+
+// itemAnimation returns the player of the animation of an item, or nil if the item has no animations.
+// Items start with their IDLE animation, or the first one in alphabetical order.
+func (g *Game) itemAnimation(item model.Item) *AnimationPlayer {
+	if len(item.Animations) == 0 {
+		return nil
+	}
+	if player, exists := g.state.itemAnimations[item.ID]; exists {
+		return player
+	}
+
+	names := make([]string, 0, len(item.Animations))
+	for name := range item.Animations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	name := names[0]
+	if _, exists := item.Animations["IDLE"]; exists {
+		name = "IDLE"
+	}
+
+	player := &AnimationPlayer{}
+	player.Play(name, g.now())
+	g.state.itemAnimations[item.ID] = player
+	return player
+}
+
+// SetItemAnimation plays an animation of an item, from its first frame
+func (g *Game) SetItemAnimation(itemID string, name string) error {
+	if _, exists := g.data.Items[itemID]; !exists {
+		return fmt.Errorf("item '%s' not found", itemID)
+	}
+	item := g.loadedItem(itemID)
+	if len(item.Animations[name]) == 0 {
+		return fmt.Errorf("animation '%s' not found for item '%s'", name, item.Name)
+	}
+	g.itemAnimation(item).Play(name, g.now())
+	return nil
+}
+
+// ItemAnimation returns the animation an item is playing and the frame it is showing, "" if it has none
+func (g *Game) ItemAnimation(itemID string) (string, int) {
+	player := g.itemAnimation(g.loadedItem(itemID))
+	if player == nil {
+		return "", 0
+	}
+	return player.Animation, player.AnimationFrame
+}
+
+// animatedCharacters returns the IDs of the characters in the room and of the current one
+func (g *Game) animatedCharacters() []string {
+	characters := g.state.charactersInRoom()
+	if g.state.currentCharacter.ID != "" {
+		characters = append(characters, g.state.currentCharacter.ID)
+	}
+	return characters
+}
+
+// updateTalkAnimations makes the character saying the current line play the talk animation of its direction.
+// Walking and scripted animations are not interrupted; who has stopped talking goes back to idle.
+func (g *Game) updateTalkAnimations() {
+	line, talking := g.Speech()
+	for _, id := range g.animatedCharacters() {
+		character, exists := g.CharacterByID(id)
+		if !exists {
+			continue
+		}
+		state := g.state.characterState(id)
+		if talking && character.ID == line.Speaker {
+			talk := talkAnimation(state.Direction)
+			if len(character.Animations[talk]) > 0 && state.Animation != talk &&
+				(state.Animation == "" || state.Animation == idleAnimation(state.Direction) || isTalkAnimation(state.Animation)) {
+				state.Play(talk, g.now())
+			}
+			continue
+		}
+		if isTalkAnimation(state.Animation) {
+			if idle := idleAnimation(state.Direction); idle != "" {
+				state.Play(idle, g.now())
+			}
+		}
+	}
+}
+
+// updateAnimations plays the animations of the characters and of the items in the room
+func (g *Game) updateAnimations() {
+	for _, id := range g.animatedCharacters() {
+		character, exists := g.CharacterByID(id)
+		if !exists {
+			continue
+		}
+		state := g.state.characterState(id)
+		state.Update(g.now(), len(character.Animations[state.Animation]), character.AnimationTiming(state.Animation))
+	}
+
+	ids := make([]string, 0, len(g.state.currentLocation.Items))
+	for id := range g.state.currentLocation.Items {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		item := g.loadedItem(id)
+		if player := g.itemAnimation(item); player != nil {
+			player.Update(g.now(), len(item.Animations[player.Animation]), item.AnimationTiming(player.Animation))
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"testing"
+	"time"
+)
+
+func TestAnimationsFollowPackagedTimings(t *testing.T) {
+	game := NewGame()
+	guybrush := model.NewCharacter("guybrush-id", "Guybrush", model.Color{R: 255, G: 255, B: 255})
+	guybrush.Animations[string(model.IDLE_FACE_DOWN)] = [][]byte{{0}}
+	guybrush.Animations["BOW"] = [][]byte{{1}, {2}, {3}}
+	guybrush.AnimationTimings["BOW"] = model.AnimationTiming{Durations: []time.Duration{50 * time.Millisecond, 500 * time.Millisecond, 0}}
+	game.AddCharacter(guybrush)
+
+	// A flag waving forever, the slow frame in the middle
+	flag := model.NewItem("flag-id", "Flag", false, false, nil)
+	flag.Animations["IDLE"] = [][]byte{{10}, {11}, {12}}
+	flag.AnimationTimings["IDLE"] = model.AnimationTiming{Durations: []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 100 * time.Millisecond}, Loop: true}
+	game.AddItem(flag)
+
+	location := model.NewLocation("porto-id", "Porto", nil)
+	location.AddItem("flag-id", model.ItemLocation{LocationPoint: image.Pt(10, 10)})
+	game.AddLocation(location)
+	game.SetCurrentCharacter("Guybrush")
+	game.SetCurrentLocation("Porto")
+	game.SetCurrentCharacterDirection(model.DOWN)
+
+	game.StartScript("Bow", `
+		game:PlayAnimation("BOW")
+		game:SetFlag("BOWED", true)
+	`, nil)
+
+	frameAt := func(elapsed time.Duration) (int, byte) {
+		for game.now() < elapsed {
+			game.Tick(nil)
+		}
+		_, frame := game.GetCurrentCharacterAnimation()
+		return frame, game.ItemFrame("flag-id")[0]
+	}
+
+	if frame, flagFrame := frameAt(150 * time.Millisecond); frame != 1 || flagFrame != 11 {
+		t.Errorf("expected frames 1 and 11 after 150ms, got %d and %d", frame, flagFrame)
+	}
+	if frame, _ := frameAt(500 * time.Millisecond); frame != 1 || game.GetFlag("BOWED") {
+		t.Errorf("expected the slow frame still shown after 500ms, got frame %d", frame)
+	}
+	if frame, flagFrame := frameAt(600 * time.Millisecond); frame != 2 || flagFrame != 10 {
+		t.Errorf("expected frames 2 and 10 after 600ms, got %d and %d", frame, flagFrame)
+	}
+
+	// The one-shot animation is over after its last frame: the script goes on and the character is idle again
+	frameAt(800 * time.Millisecond)
+	if !game.GetFlag("BOWED") || game.IsScriptRunning() {
+		t.Fatalf("expected the script resumed once the animation was over")
+	}
+	if animation, _ := game.GetCurrentCharacterAnimation(); animation != string(model.IDLE_FACE_DOWN) {
+		t.Errorf("expected the character idle after the animation, got %s", animation)
+	}
+}
+
+func TestItemsKeepTheirOwnAnimation(t *testing.T) {
+	game := NewGame()
+	game.SetSaveDirectory(t.TempDir())
+	location := model.NewLocation("porto-id", "Porto", nil)
+	for _, id := range []string{"left-torch-id", "right-torch-id"} {
+		torch := model.NewItem(id, "Torch", false, false, nil)
+		torch.Animations["IDLE"] = [][]byte{{0}}
+		torch.Animations["BURNING"] = [][]byte{{1}, {2}}
+		torch.AnimationTimings["BURNING"] = model.AnimationTiming{Durations: []time.Duration{time.Second, time.Second}, Loop: true}
+		game.AddItem(torch)
+		location.AddItem(id, model.ItemLocation{})
+	}
+	game.AddLocation(location)
+	game.SetCurrentLocation("Porto")
+
+	if err := RunScript(game.luaState(), `game:SetItemAnimation("left-torch-id", "BURNING")`); err != nil {
+		t.Fatalf("SetItemAnimation failed: %v", err)
+	}
+	for game.now() < 1500*time.Millisecond {
+		game.Tick(nil)
+	}
+	if animation, frame := game.ItemAnimation("left-torch-id"); animation != "BURNING" || frame != 1 {
+		t.Errorf("expected the left torch burning at frame 1, got %s at frame %d", animation, frame)
+	}
+	if animation, _ := game.ItemAnimation("right-torch-id"); animation != "IDLE" {
+		t.Errorf("expected the right torch still idle, got %s", animation)
+	}
+
+	if err := game.SaveGame(1); err != nil {
+		t.Fatalf("SaveGame failed: %v", err)
+	}
+	game.SetItemAnimation("left-torch-id", "IDLE")
+	if err := game.LoadGame(1); err != nil {
+		t.Fatalf("LoadGame failed: %v", err)
+	}
+	if animation, frame := game.ItemAnimation("left-torch-id"); animation != "BURNING" || frame != 1 {
+		t.Errorf("expected the left torch burning at frame 1 after loading, got %s at frame %d", animation, frame)
+	}
+	// The restored frame is shown for its whole duration from the moment of loading
+	loaded := game.now()
+	for game.now() < loaded+900*time.Millisecond {
+		game.Tick(nil)
+	}
+	if _, frame := game.ItemAnimation("left-torch-id"); frame != 1 {
+		t.Errorf("expected the left torch still at frame 1 shortly after loading, got frame %d", frame)
+	}
+	for game.now() < loaded+1100*time.Millisecond {
+		game.Tick(nil)
+	}
+	if _, frame := game.ItemAnimation("left-torch-id"); frame != 0 {
+		t.Errorf("expected the left torch back at frame 0 a second after loading, got frame %d", frame)
+	}
+
+	if err := game.SetItemAnimation("right-torch-id", "EXPLODING"); err == nil {
+		t.Errorf("expected an error for an unknown animation")
+	}
+}
+
+func TestEditorAnimationsKeepTheTimingOfTheFramesDecoded(t *testing.T) {
+	game := NewGame()
+	err := game.MapPackagedData(model.PackagedGameData{
+		Characters: []model.EditorCharacter{{
+			ID:   "guybrush-id",
+			Name: "Guybrush",
+			Details: &model.EditorCharacterDetails{Animations: []model.EditorAnimation{{
+				Name: "BOW",
+				Loop: true,
+				// The broken frame is dropped with its duration
+				Frames: []model.EditorAnimationFrame{{ImageData: "AQ==", Duration: 80}, {ImageData: "!!", Duration: 10}, {ImageData: "Ag=="}},
+			}}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("MapPackagedData failed: %v", err)
+	}
+
+	guybrush := game.GetCharacter("Guybrush")
+	if frames := guybrush.Animations["BOW"]; len(frames) != 2 || frames[0][0] != 1 || frames[1][0] != 2 {
+		t.Errorf("expected the two valid frames, got %v", frames)
+	}
+	timing := guybrush.AnimationTimings["BOW"]
+	if !timing.Loop || len(timing.Durations) != 2 || timing.Durations[0] != 80*time.Millisecond || timing.Durations[1] != 0 {
+		t.Errorf("expected a looping animation of 80ms and default frames, got %+v", timing)
+	}
+}
@@ -0,0 +1,203 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"fmt"
+	"image"
+	"log"
+	"sort"
+)
+
+// characterScale is the scale characters are drawn at in locations without a scale area
+const characterScale = 2.5
+
+// CharacterState is the runtime state of a character: where it is and how it is drawn
+type CharacterState struct {
+	Location  string // ID of the location the character is in
+	Position  image.Point
+	Direction model.CharacterDirection
+	AnimationPlayer
+}
+
+// characterState returns the runtime state of a character, creating it on first use
+func (gs *GameState) characterState(id string) *CharacterState {
+	state, exists := gs.characters[id]
+	if !exists {
+		state = &CharacterState{}
+		gs.characters[id] = state
+	}
+	return state
+}
+
+// charactersInRoom returns the IDs of the characters in the current location, the current one excluded
+func (gs *GameState) charactersInRoom() []string {
+	ids := make([]string, 0)
+	for id, state := range gs.characters {
+		if id == "" || id == gs.currentCharacter.ID {
+			continue
+		}
+		if state.Location != "" && state.Location == gs.currentLocation.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (g *Game) currentCharacterState() *CharacterState {
+	return g.state.characterState(g.state.currentCharacter.ID)
+}
+
+// CharacterByID returns the character with the given ID or name
+func (g *Game) CharacterByID(id string) (model.Character, bool) {
+	key, exists := g.characterKey(id)
+	if !exists {
+		return model.Character{}, false
+	}
+	return g.data.Character[key], true
+}
+
+// GetCharacterState returns the runtime state of the character with the given ID or name
+func (g *Game) GetCharacterState(id string) (*CharacterState, error) {
+	character, exists := g.CharacterByID(id)
+	if !exists {
+		return nil, fmt.Errorf("character '%s' not found", id)
+	}
+	return g.state.characterState(character.ID), nil
+}
+
+// placeCharacters gives a runtime state to the characters placed in a location the first time it is entered.
+// Characters that already have one (e.g. moved by a script) are left where they are.
+func (g *Game) placeCharacters(location model.Location) {
+	for id, placement := range location.Characters {
+		key, exists := g.characterKey(id)
+		if !exists {
+			log.Printf("Warning: character '%s' placed in location '%s' not found", id, location.Name)
+			continue
+		}
+		character := g.data.Character[key]
+
+		if _, placed := g.state.characters[character.ID]; !placed {
+			g.state.characters[character.ID] = &CharacterState{
+				Location:        location.ID,
+				Position:        placement.LocationPoint,
+				Direction:       model.DOWN,
+				AnimationPlayer: AnimationPlayer{Animation: string(model.IDLE_FACE_DOWN)},
+			}
+		}
+
+		// Load character animations on-demand
+		if g.packagedData != nil && len(character.Animations) == 0 {
+			g.LoadCharacterAnimations(key)
+		}
+	}
+
+	if g.state.currentCharacter.ID != "" {
+		g.currentCharacterState().Location = location.ID
+	}
+}
+
+// CharacterFrame returns the image of the frame a character is showing, or nil
+func CharacterFrame(character model.Character, state *CharacterState) []byte {
+	frames := character.Animations[state.Animation]
+	if state.AnimationFrame < 0 || state.AnimationFrame >= len(frames) {
+		return nil
+	}
+	return frames[state.AnimationFrame]
+}
+
+// CharacterScale returns the scale a character standing at position is drawn at:
+// characters shrink as they walk away, following the scale area of the current location
+func (g *Game) CharacterScale(position image.Point) float64 {
+	area := g.state.currentLocation.GetScaleArea()
+	if area == nil {
+		return characterScale
+	}
+	if scale := area.ScaleAt(position.Y); scale > 0 {
+		return scale
+	}
+	return characterScale
+}
+
+// CharacterBounds returns the world area covered by a sprite of the given size drawn at position with scale.
+// Characters are anchored at the middle of their feet.
+func CharacterBounds(position image.Point, spriteWidth int, spriteHeight int, scale float64) image.Rectangle {
+	width := int(float64(spriteWidth) * scale)
+	height := int(float64(spriteHeight) * scale)
+	return image.Rect(position.X-width/2, position.Y-height, position.X-width/2+width, position.Y)
+}
+
+// CharacterAt returns the ID of the character, other than the current one, under the given world position, or ""
+func (g *Game) CharacterAt(x int, y int) string {
+	// The character drawn last is on top
+	for i := len(g.state.yOrderedEntities) - 1; i >= 0; i-- {
+		id := g.state.yOrderedEntities[i]
+		if index, isWalkBehind := WalkBehindIndex(id); isWalkBehind {
+			// The characters behind a walk-behind can't be clicked through it
+			if isWalkBehindAt(g.WalkBehinds()[index], x, y) {
+				return ""
+			}
+			continue
+		}
+		state, isCharacter := g.state.characters[id]
+		if !isCharacter || id == g.state.currentCharacter.ID {
+			continue
+		}
+
+		character, exists := g.CharacterByID(id)
+		if !exists {
+			continue
+		}
+		hit, ok := g.characterHit(character, state)
+		if !ok {
+			continue
+		}
+		scale := g.CharacterScale(state.Position)
+		bounds := CharacterBounds(state.Position, hit.bounds.Dx(), hit.bounds.Dy(), scale)
+		if !image.Pt(x, y).In(bounds) {
+			continue
+		}
+
+		spriteX := hit.bounds.Min.X + int(float64(x-bounds.Min.X)/scale)
+		spriteY := hit.bounds.Min.Y + int(float64(y-bounds.Min.Y)/scale)
+		if hit.mask.Contains(image.Pt(spriteX, spriteY)) {
+			return id
+		}
+	}
+	return ""
+}
+
+// interactionPoint returns where the current character walks to interact with an item, a character or a hotspot of the room
+func (g *Game) interactionPoint(id string) (image.Point, bool) {
+	location := g.GetCurrentLocation()
+	if itemLocation, exists := location.Items[id]; exists {
+		return itemLocation.InteractionPoint, true
+	}
+	if hotspot, exists := g.hotspot(id); exists {
+		return hotspot.InteractionPoint, true
+	}
+
+	state, exists := g.state.characters[id]
+	if !exists || id == g.state.currentCharacter.ID || state.Location != location.ID {
+		return image.Point{}, false
+	}
+
+	// The interaction point follows the character when it moves away from where it was placed
+	if placement, placed := location.Characters[id]; placed {
+		return placement.InteractionPoint.Add(state.Position.Sub(placement.LocationPoint)), true
+	}
+	return state.Position, true
+}
+
+// characterTalkColor returns the talk color (#RRGGBB) of a character, white if it has none
+func characterTalkColor(name string, hex string) model.Color {
+	if hex == "" {
+		return model.Color{R: 255, G: 255, B: 255}
+	}
+	c, err := parseHexColor(hex)
+	if err != nil {
+		log.Printf("Invalid talk color '%s' of character '%s': %v", hex, name, err)
+		return model.Color{R: 255, G: 255, B: 255}
+	}
+	return model.Color{R: c.R, G: c.G, B: c.B}
+}
@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"reflect"
+	"testing"
+)
+
+// newCharactersTestGame returns Porto with the Pirate, a barrel, and a coin nobody holds yet
+func newCharactersTestGame() *Game {
+	return newPortoTestGame(nil, func(game *Game, porto *model.Location) {
+		game.AddCharacter(model.NewCharacter("pirate-id", "Pirate", model.Color{R: 255}))
+		game.AddItem(model.NewItem("coin-id", "Coin", false, true, nil))
+		game.AddItem(model.NewItem("barrel-id", "Barrel", false, false, nil))
+
+		porto.AddItem("barrel-id", model.ItemLocation{LocationPoint: image.Pt(50, 250), InteractionPoint: image.Pt(60, 260)})
+		porto.AddCharacter("pirate-id", model.CharacterLocation{LocationPoint: image.Pt(300, 200), InteractionPoint: image.Pt(260, 210)})
+	})
+}
+
+func TestPlacedCharactersAreYSortedWithItems(t *testing.T) {
+	game := newCharactersTestGame()
+
+	if inRoom := game.state.charactersInRoom(); !reflect.DeepEqual(inRoom, []string{"pirate-id"}) {
+		t.Fatalf("expected the pirate in the room, got %v", inRoom)
+	}
+
+	game.state.CalculateYOrderedEntities()
+	expected := []string{"pirate-id", "barrel-id", "guybrush-id"}
+	if !reflect.DeepEqual(game.state.yOrderedEntities, expected) {
+		t.Errorf("expected draw order %v, got %v", expected, game.state.yOrderedEntities)
+	}
+
+	// The interaction point follows the character when it moves
+	state, err := game.GetCharacterState("Pirate")
+	if err != nil {
+		t.Fatalf("GetCharacterState failed: %v", err)
+	}
+	state.Position = state.Position.Add(image.Pt(10, 0))
+	if point, _ := game.interactionPoint("pirate-id"); point != image.Pt(270, 210) {
+		t.Errorf("expected interaction point (270,210), got %v", point)
+	}
+}
+
+func TestGiveToCharacterMovesItem(t *testing.T) {
+	game := newCharactersTestGame()
+	game.GetCharacter("Guybrush").Inventory["coin-id"] = model.InventorySlot{Count: 1, Item: game.GetItem("coin-id")}
+
+	game.ExecuteAction(composeTrigger("guybrush-id", model.GIVE_TO, "coin-id", "pirate-id", "porto-id"))
+
+	if _, exists := game.GetCharacter("Guybrush").Inventory["coin-id"]; exists {
+		t.Errorf("expected coin removed from the giver's inventory")
+	}
+	if slot := game.GetCharacter("Pirate").Inventory["coin-id"]; slot.Count != 1 {
+		t.Errorf("expected coin in the receiver's inventory, got %+v", slot)
+	}
+}
+
+func TestCharacterScaleFollowsDepth(t *testing.T) {
+	game := newCharactersTestGame()
+	if scale := game.CharacterScale(image.Pt(100, 300)); scale != characterScale {
+		t.Errorf("expected the default scale without a scale area, got %v", scale)
+	}
+
+	game.state.currentLocation.SetScaleArea(model.ScaleArea{FarY: 200, FarScale: 1, NearY: 400, NearScale: 3})
+	for y, expected := range map[int]float64{100: 1, 200: 1, 300: 2, 400: 3, 500: 3} {
+		if scale := game.CharacterScale(image.Pt(100, y)); scale != expected {
+			t.Errorf("expected scale %v at y %d, got %v", expected, y, scale)
+		}
+	}
+
+	// Farther characters cover a smaller area, still anchored at their feet
+	near := CharacterBounds(image.Pt(100, 400), 10, 20, game.CharacterScale(image.Pt(100, 400)))
+	far := CharacterBounds(image.Pt(100, 200), 10, 20, game.CharacterScale(image.Pt(100, 200)))
+	if near != image.Rect(85, 340, 115, 400) || far != image.Rect(95, 180, 105, 200) {
+		t.Errorf("unexpected bounds near %v, far %v", near, far)
+	}
+}
@@ -0,0 +1,193 @@
+package logic
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// scriptTask is a Lua script running as a coroutine. Blocking game functions
+// (WalkTo, Say, Wait, PlayAnimation) suspend it and Update resumes it once they are over.
+type scriptTask struct {
+	name       string
+	thread     *lua.LState
+	fn         *lua.LFunction
+	resumeWhen func() bool
+	onDone     func(err error)
+	cancelled  bool
+}
+
+// StartScript runs a Lua script as a coroutine. The script runs right away up to its first
+// blocking call; onDone, if any, is called once the script has ended, with the error it failed with
+// (e.g. aborted for running too long) or nil.
+func (g *Game) StartScript(name string, script string, onDone func(err error)) {
+	L := g.luaState()
+
+	fn, err := L.Load(strings.NewReader(script), name)
+	if err != nil {
+		log.Printf("Error executing Lua script %s: %v", name, err)
+		if onDone != nil {
+			onDone(err)
+		}
+		return
+	}
+
+	thread, _ := L.NewThread()
+	task := &scriptTask{
+		name:   name,
+		thread: thread,
+		fn:     fn,
+		onDone: onDone,
+	}
+	g.state.scripts = append(g.state.scripts, task)
+	g.resumeScript(task)
+}
+
+// IsScriptRunning reports whether a script is waiting for a blocking call, e.g. during a cutscene
+func (g *Game) IsScriptRunning() bool {
+	return len(g.state.scripts) > 0
+}
+
+func (g *Game) resumeScript(task *scriptTask) {
+	task.resumeWhen = nil
+
+	var state lua.ResumeState
+	previous := g.state.runningScript
+	g.state.runningScript = task
+	err := withScriptBudget(task.thread, func() error {
+		var err error
+		state, err, _ = g.luaState().Resume(task.thread, task.fn)
+		return err
+	})
+	g.state.runningScript = previous
+
+	if err != nil {
+		log.Printf("Error executing Lua script %s: %v", task.name, err)
+	} else if state == lua.ResumeYield && !task.cancelled {
+		return
+	}
+	g.finishScript(task, err)
+}
+
+func (g *Game) finishScript(task *scriptTask, err error) {
+	for i, t := range g.state.scripts {
+		if t == task {
+			g.state.scripts = append(g.state.scripts[:i], g.state.scripts[i+1:]...)
+			break
+		}
+	}
+
+	if task.onDone != nil && !task.cancelled {
+		task.onDone(err)
+	}
+}
+
+// cancelScripts stops every running script without calling their onDone.
+// The script currently executing Go code is dropped as soon as it gives control back.
+func (g *Game) cancelScripts() {
+	for _, task := range g.state.scripts {
+		task.cancelled = true
+	}
+	g.state.scripts = make([]*scriptTask, 0)
+}
+
+// updateScripts resumes the scripts whose blocking call is over
+func (g *Game) updateScripts() {
+	for _, task := range append([]*scriptTask{}, g.state.scripts...) {
+		if task.cancelled {
+			continue
+		}
+		if task.resumeWhen == nil || task.resumeWhen() {
+			g.resumeScript(task)
+		}
+	}
+}
+
+// scriptTaskOf returns the script task L is the coroutine of, or nil
+func (g *Game) scriptTaskOf(L *lua.LState) *scriptTask {
+	for _, task := range g.state.scripts {
+		if task.thread == L {
+			return task
+		}
+	}
+	return nil
+}
+
+// waitUntil suspends the script running in L until condition holds, polling it every tick.
+// Outside of a script task there is nothing to suspend and the call returns right away.
+func (g *Game) waitUntil(L *lua.LState, condition func() bool) int {
+	task := g.scriptTaskOf(L)
+	if task == nil || condition() {
+		return 0
+	}
+	task.resumeWhen = condition
+	return L.Yield()
+}
+
+// --- Blocking Lua functions ---
+
+func luaWalkTo(L *lua.LState, game *Game) int {
+	x := L.CheckInt(2)
+	y := L.CheckInt(3)
+	game.MoveTo(x, y)
+	return game.waitUntil(L, func() bool {
+		return len(game.state.watingActions) == 0
+	})
+}
+
+// luaSay makes a character say a sentence: game:Say(characterId, text), or game:Say(text) for the current one.
+// The script waits for the line to be read.
+func luaSay(L *lua.LState, game *Game) int {
+	if L.GetTop() >= 3 {
+		if err := game.Say(L.CheckString(2), L.CheckString(3)); err != nil {
+			L.ArgError(1, err.Error())
+			return 0
+		}
+	} else {
+		game.SaySomething(L.CheckString(2))
+	}
+	return game.waitUntil(L, func() bool {
+		return len(game.state.textToDraw) == 0
+	})
+}
+
+func luaWait(L *lua.LState, game *Game) int {
+	deadline := game.now() + time.Duration(L.CheckInt(2))*time.Millisecond
+	return game.waitUntil(L, func() bool {
+		return game.now() >= deadline
+	})
+}
+
+// luaPlayAnimation plays an animation of a character (the current one by default) once, then goes back to idle.
+// The script waits for the last frame, or for someone else to take over the character.
+func luaPlayAnimation(L *lua.LState, game *Game) int {
+	name := L.CheckString(2)
+	character := game.GetCurrentCharacter()
+	if L.GetTop() >= 3 {
+		var exists bool
+		if character, exists = game.CharacterByID(L.CheckString(3)); !exists {
+			L.ArgError(2, fmt.Sprintf("character '%s' not found", L.CheckString(3)))
+			return 0
+		}
+	}
+	if len(character.Animations[name]) == 0 {
+		log.Printf("Warning: Animation '%s' not found for character '%s'", name, character.Name)
+		return 0
+	}
+
+	state := game.state.characterState(character.ID)
+	over := false
+	state.PlayOnce(name, game.now())
+	state.OnFinished(func() {
+		if state.Finished() && state.Animation == name {
+			state.Play(idleAnimation(state.Direction), game.now())
+		}
+		over = true
+	})
+	return game.waitUntil(L, func() bool {
+		return over
+	})
+}
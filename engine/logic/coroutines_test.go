@@ -0,0 +1,64 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"testing"
+)
+
+func TestActionScriptBlocksUntilSayIsOver(t *testing.T) {
+	game := newCharactersTestGame()
+	location := game.GetLocation("Porto")
+	location.AddItem("coin-id", model.ItemLocation{LocationPoint: image.Pt(120, 300), InteractionPoint: image.Pt(120, 300)})
+	game.AddAction(model.NewAction("guybrush-id", model.PICK_UP, "coin-id", model.NOTHING, "porto-id", `
+		game:SetFlag("before", true)
+		game:Say("A coin!")
+		game:SetFlag("after", true)
+	`, nil, nil, nil))
+
+	game.ExecuteAction(composeTrigger("guybrush-id", model.PICK_UP, "coin-id", model.NOTHING, "porto-id"))
+
+	if !game.GetFlag("before") || game.GetFlag("after") {
+		t.Fatalf("expected the script suspended on Say")
+	}
+	if !game.IsScriptRunning() {
+		t.Fatalf("expected a running script")
+	}
+	if _, picked := game.GetCharacter("Guybrush").Inventory["coin-id"]; picked {
+		t.Errorf("expected the verb applied only once the script has ended")
+	}
+
+	// Still talking: nothing changes
+	game.updateScripts()
+	if game.GetFlag("after") {
+		t.Fatalf("expected the script to wait for the line to be read")
+	}
+
+	game.state.textToDraw = make([]SpeechLine, 0)
+	game.updateScripts()
+	if !game.GetFlag("after") || game.IsScriptRunning() {
+		t.Fatalf("expected the script resumed and ended")
+	}
+	if _, picked := game.GetCharacter("Guybrush").Inventory["coin-id"]; !picked {
+		t.Errorf("expected the coin picked up after the script")
+	}
+}
+
+func TestLoadingCancelsRunningScripts(t *testing.T) {
+	game := newCharactersTestGame()
+	game.StartScript("cutscene", `
+		game:Wait(100000)
+		game:SetFlag("done", true)
+	`, nil)
+	if !game.IsScriptRunning() {
+		t.Fatalf("expected the script suspended on Wait")
+	}
+
+	if err := game.restoreSaveGame(game.snapshotSaveGame()); err != nil {
+		t.Fatalf("restoreSaveGame failed: %v", err)
+	}
+	game.updateScripts()
+	if game.IsScriptRunning() || game.GetFlag("done") {
+		t.Errorf("expected the script cancelled by loading")
+	}
+}
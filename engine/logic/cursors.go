@@ -0,0 +1,101 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"log"
+)
+
+// CursorState is what the cursor is over. A cursor set shows its animation named after the state,
+// or its default animation when it has none.
+type CursorState string
+
+const (
+	CURSOR_DEFAULT   CursorState = "DEFAULT"
+	CURSOR_ITEM      CursorState = "ITEM"      // An item or a hotspot of the room, or an inventory item
+	CURSOR_EXIT      CursorState = "EXIT"      // An exit to another location
+	CURSOR_CHARACTER CursorState = "CHARACTER" // A character of the room
+	CURSOR_BUSY      CursorState = "BUSY"      // A cutscene is running, the player can not interact
+)
+
+// SetCurrentCursor makes a script choose the cursor set shown, whatever the current verb.
+// An empty name goes back to the cursor sets of the verbs.
+func (g *Game) SetCurrentCursor(name string) {
+	if name != "" {
+		if _, exists := g.cursorSet(name); !exists {
+			log.Printf("Error: cursor '%s' not found.", name)
+			return
+		}
+	}
+	g.state.currentCursor = name
+}
+
+// cursorSet returns a cursor set, loading its animations on-demand
+func (g *Game) cursorSet(name string) (model.Cursor, bool) {
+	cursor, exists := g.data.Cursors[name]
+	if exists && len(cursor.Animations) == 0 && g.packagedData != nil {
+		if err := g.LoadCursor(name); err != nil {
+			log.Printf("Error loading cursor '%s': %v", name, err)
+		}
+		cursor = g.data.Cursors[name]
+	}
+	return cursor, exists
+}
+
+// CursorState returns what the cursor is over
+func (g *Game) CursorState() CursorState {
+	switch {
+	case g.IsScriptRunning():
+		return CURSOR_BUSY
+	case g.state.cursorOnInventory != "":
+		return CURSOR_ITEM
+	case g.state.cursorOnItem != "":
+		if _, isCharacter := g.characterKey(g.state.cursorOnItem); isCharacter {
+			return CURSOR_CHARACTER
+		}
+		return CURSOR_ITEM
+	case g.state.cursorOnExit:
+		return CURSOR_EXIT
+	}
+	return CURSOR_DEFAULT
+}
+
+// currentCursorSet returns the name of the cursor set shown: the one chosen by a script, otherwise the one of the current verb
+func (g *Game) currentCursorSet() string {
+	if g.state.currentCursor != "" {
+		return g.state.currentCursor
+	}
+	if name, exists := g.data.Interface.Cursors[g.GetCurrentVerb()]; exists {
+		return name
+	}
+	return g.data.Interface.DefaultCursor
+}
+
+// updateCursor plays the animation of the cursor set for what the cursor is over
+func (g *Game) updateCursor() {
+	set, _ := g.cursorSet(g.currentCursorSet())
+	animation := string(g.CursorState())
+	if _, exists := set.Animations[animation]; !exists {
+		animation = set.DefaultAnimation
+	}
+
+	if set.Name != g.state.cursorSet || animation != g.state.cursor.Animation {
+		g.state.cursorSet = set.Name
+		g.state.cursor.Play(animation, g.now())
+	}
+	g.state.cursor.Update(g.now(), len(set.Animations[animation]), set.AnimationTiming(animation))
+}
+
+// CurrentCursorFrame returns the cursor set shown, its animation and the frame being shown
+func (g *Game) CurrentCursorFrame() (string, string, int) {
+	return g.state.cursorSet, g.state.cursor.Animation, g.state.cursor.AnimationFrame
+}
+
+// CurrentCursor returns the image of the frame of the cursor shown, or nil
+func (g *Game) CurrentCursor() []byte {
+	frames := g.data.Cursors[g.state.cursorSet].Animations[g.state.cursor.Animation]
+	frame := g.state.cursor.AnimationFrame
+	if frame < 0 || frame >= len(frames) {
+		return nil
+	}
+	return frames[frame]
+}
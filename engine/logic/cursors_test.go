@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"testing"
+	"time"
+)
+
+func TestCursorFollowsVerbAndHover(t *testing.T) {
+	game := NewGame()
+	game.AddCharacter(model.NewCharacter("guybrush-id", "Guybrush", model.Color{R: 255, G: 255, B: 255}))
+	game.AddItem(model.NewItem("door-id", "Door", false, false, nil))
+
+	location := model.NewLocation("porto-id", "Porto", nil)
+	location.AddWalkableArea(model.WalkableArea{Polygons: [][]image.Point{{{0, 250}, {600, 250}, {600, 380}, {0, 380}}}})
+	location.AddHotspot(model.Hotspot{ID: "door-id", Name: "Door", Area: []image.Point{{100, 100}, {150, 100}, {150, 200}, {100, 200}}})
+	location.AddExit(model.Exit{Area: []image.Point{{550, 250}, {600, 250}, {600, 380}, {550, 380}}, TargetLocation: "porto-id"})
+	game.AddLocation(location)
+
+	arrow := model.NewCursor("Arrow")
+	arrow.AddAnimation("DEFAULT", [][]byte{{1}, {2}}, model.AnimationTiming{Durations: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond}, Loop: true})
+	arrow.AddAnimation("ITEM", [][]byte{{3}}, model.AnimationTiming{})
+	arrow.AddAnimation("EXIT", [][]byte{{4}}, model.AnimationTiming{})
+	arrow.AddAnimation("BUSY", [][]byte{{5}}, model.AnimationTiming{})
+	game.AddCursorSet(arrow)
+	game.AddCursor("Eye", []byte{6})
+	game.AddCursor("Hand", []byte{7})
+	game.SetInterfaceSettings(&InterfaceSettings{Cursors: &CursorSettings{Default: "Arrow", Verbs: map[string]string{"LOOK_AT": "Eye"}}})
+
+	game.SetCurrentCharacter("Guybrush")
+	game.SetCurrentLocation("Porto")
+	game.SetCurrentCharacterPosition(image.Pt(300, 300))
+	game.Tick(nil)
+	camera := game.GetCamera()
+	screen := func(x, y int) (int, int) {
+		return x - int(camera.Position[0]), y - int(camera.Position[1])
+	}
+	cursorAt := func(x, y int) byte {
+		x, y = screen(x, y)
+		game.Tick([]InputEvent{{Type: CURSOR_MOVED, X: x, Y: y}})
+		return game.CurrentCursor()[0]
+	}
+
+	if cursor := cursorAt(300, 120); cursor != 1 {
+		t.Errorf("expected the first frame of the default cursor, got %d", cursor)
+	}
+	for i := 0; i < 7; i++ {
+		game.Tick(nil)
+	}
+	if cursor := game.CurrentCursor()[0]; cursor != 2 {
+		t.Errorf("expected the default cursor animated, got %d", cursor)
+	}
+	if set, animation, frame := game.CurrentCursorFrame(); set != "Arrow" || animation != "DEFAULT" || frame != 1 {
+		t.Errorf("expected the second frame of Arrow DEFAULT, got %s %s %d", set, animation, frame)
+	}
+	if cursor := cursorAt(120, 150); cursor != 3 {
+		t.Errorf("expected the item cursor over the door, got %d", cursor)
+	}
+	if cursor := cursorAt(580, 300); cursor != 4 {
+		t.Errorf("expected the exit cursor over the exit, got %d", cursor)
+	}
+
+	// Verbs have their own cursor set, scripts override it
+	game.SelectVerb(model.LOOK_AT)
+	if cursor := cursorAt(120, 150); cursor != 6 {
+		t.Errorf("expected the cursor of LOOK_AT, got %d", cursor)
+	}
+	game.SetCurrentCursor("Hand")
+	if cursor := cursorAt(120, 150); cursor != 7 {
+		t.Errorf("expected the cursor chosen by the script, got %d", cursor)
+	}
+	game.SetCurrentCursor("")
+	game.SelectVerb(model.MOVE_TO)
+
+	game.StartScript("Cutscene", `game:Wait(1000)`, nil)
+	if cursor := cursorAt(120, 150); cursor != 5 {
+		t.Errorf("expected the busy cursor during a cutscene, got %d", cursor)
+	}
+}
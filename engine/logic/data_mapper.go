@@ -30,18 +30,41 @@ func (g *Game) MapPackagedData(pkgData model.PackagedGameData) error {
 			}
 
 			// Walkable Areas (Polygon arrays)
-			// EditorLocationDetails has WalkableArea [][]EditorPoint
-			// Convert to model.WalkableArea
-			if len(l.Details.WalkableArea) > 0 {
-				var polyPoints [][]image.Point
-				for _, poly := range l.Details.WalkableArea {
-					var points []image.Point
-					for _, p := range poly {
-						points = append(points, image.Point{X: p.X, Y: p.Y})
-					}
-					polyPoints = append(polyPoints, points)
+			mapWalkableAreas(&location, l.Details.WalkableArea, l.Details.WalkableAreas)
+
+			// Scale Area (character scale at two Y lines)
+			if area := l.Details.ScaleArea; area != nil {
+				location.SetScaleArea(mapScaleArea(*area))
+			}
+
+			// Layers
+			for i, layer := range l.Details.Layers {
+				layerBytes, err := decodeBase64(layer.Image)
+				if err != nil {
+					log.Printf("Error decoding layer %d for location %s: %v", i, l.Name, err)
+					continue
+				}
+				location.AddLayer(mapLayer(layerBytes, layer.EditorLayerSettings))
+			}
+
+			// Walk-behinds
+			for i, walkBehind := range l.Details.WalkBehinds {
+				maskBytes, err := decodeBase64(walkBehind.Mask)
+				if err != nil {
+					log.Printf("Error decoding walk-behind %d for location %s: %v", i, l.Name, err)
+					continue
 				}
-				location.AddWalkableArea(model.WalkableArea{Polygons: polyPoints})
+				location.AddWalkBehind(mapWalkBehind(maskBytes, walkBehind.Baseline))
+			}
+
+			// Exits
+			for _, exit := range l.Details.Exits {
+				location.AddExit(mapExit(exit))
+			}
+
+			// Hotspots
+			for _, hotspot := range l.Details.Hotspots {
+				location.AddHotspot(mapHotspot(hotspot))
 			}
 
 			// Placed Items
@@ -51,6 +74,14 @@ func (g *Game) MapPackagedData(pkgData model.PackagedGameData) error {
 					InteractionPoint: image.Point{X: placedItem.InteractionSpot.X, Y: placedItem.InteractionSpot.Y},
 				})
 			}
+
+			// Placed Characters
+			for _, placedCharacter := range l.Details.PlacedCharacters {
+				location.AddCharacter(placedCharacter.EntityID, model.CharacterLocation{
+					LocationPoint:    image.Point{X: placedCharacter.Position.X, Y: placedCharacter.Position.Y},
+					InteractionPoint: image.Point{X: placedCharacter.InteractionSpot.X, Y: placedCharacter.InteractionSpot.Y},
+				})
+			}
 		}
 		g.AddLocation(location)
 	}
@@ -58,23 +89,18 @@ func (g *Game) MapPackagedData(pkgData model.PackagedGameData) error {
 	// 2. Characters
 	for _, c := range pkgData.Characters {
 		// NewCharacter(id string, name string, talkColor Color)
-		// Default talk color white if not specified (not in PackagedData explicitly? check struct)
-		// Check EditorCharacterDetails for specific color? No, it's not there. defaulting.
-		char := model.NewCharacter(c.ID, c.Name, model.Color{R: 255, G: 255, B: 255})
+		talkColor := ""
+		if c.Details != nil {
+			talkColor = c.Details.TalkColor
+		}
+		char := model.NewCharacter(c.ID, c.Name, characterTalkColor(c.Name, talkColor))
 
 		if c.Details != nil {
 			// Animations
 			for _, anim := range c.Details.Animations {
-				var frames [][]byte
-				for _, frame := range anim.Frames {
-					b, err := decodeBase64(frame.ImageData)
-					if err != nil {
-						log.Printf("Error decoding animation frame for char %s: %v", c.Name, err)
-						continue
-					}
-					frames = append(frames, b)
-				}
+				frames, timing := decodeAnimation(anim, "char "+c.Name)
 				char.Animations[anim.Name] = frames
+				char.AnimationTimings[anim.Name] = timing
 			}
 		}
 		g.AddCharacter(char)
@@ -105,16 +131,9 @@ func (g *Game) MapPackagedData(pkgData model.PackagedGameData) error {
 		// Add Animations if any
 		if i.Details != nil {
 			for _, anim := range i.Details.Animations {
-				var frames [][]byte
-				for _, frame := range anim.Frames {
-					b, err := decodeBase64(frame.ImageData)
-					if err != nil {
-						log.Printf("Error decoding animation frame for item %s: %v", i.Name, err)
-						continue
-					}
-					frames = append(frames, b)
-				}
+				frames, timing := decodeAnimation(anim, "item "+i.Name)
 				item.Animations[anim.Name] = frames
+				item.AnimationTimings[anim.Name] = timing
 			}
 
 			// Inventory Image
@@ -184,6 +203,8 @@ func (g *Game) MapPackagedData(pkgData model.PackagedGameData) error {
 				model.DoNothing, // ExecAction (runtime logic handles this if no script?)
 				model.DoNothing, // ExecAfter
 			)
+			action.Dialogue = node.Dialogue
+			action.NodeID = node.ID
 			g.AddAction(action)
 		case "state":
 			if node.Label == "Initial state" {
@@ -209,22 +230,60 @@ func (g *Game) MapPackagedData(pkgData model.PackagedGameData) error {
 	// 7. Cursors
 	for _, c := range pkgData.Cursors {
 		if c.Details != nil {
-			var animations [][]byte
+			cursor := model.NewCursor(c.Name)
 			for _, anim := range c.Details.Animations {
-				b, err := decodeBase64(anim.Frames[0].ImageData)
-				if err != nil {
-					log.Printf("Error decoding animation frame for cursor %s: %v", c.Name, err)
-					continue
+				frames, timing := decodeAnimation(anim, "cursor "+c.Name)
+				if len(frames) > 0 {
+					cursor.AddAnimation(anim.Name, frames, timing)
 				}
-				animations = append(animations, b)
 			}
-			g.AddCursor(c.Name, animations[0])
+			g.AddCursorSet(cursor)
 		}
 	}
 
+	// 8. Dialogues
+	for _, d := range pkgData.Dialogues {
+		g.AddDialogue(mapDialogue(d.ID, d.Name, d.Details))
+	}
+
+	// 9. Interface layout
+	if ui := pkgData.Interface; ui != nil {
+		settings := &InterfaceSettings{
+			Verbs:        ui.Verbs,
+			VerbLabels:   ui.VerbLabels,
+			Prepositions: ui.Prepositions,
+			VerbColumns:  ui.VerbColumns,
+		}
+		if ui.SentenceLine != nil {
+			settings.SentenceLine = &ScreenRect{X: ui.SentenceLine.X, Y: ui.SentenceLine.Y, Width: ui.SentenceLine.Width, Height: ui.SentenceLine.Height}
+		}
+		if ui.VerbBar != nil {
+			settings.VerbBar = &ScreenRect{X: ui.VerbBar.X, Y: ui.VerbBar.Y, Width: ui.VerbBar.Width, Height: ui.VerbBar.Height}
+		}
+		if ui.Inventory != nil {
+			settings.Inventory = &ScreenRect{X: ui.Inventory.X, Y: ui.Inventory.Y, Width: ui.Inventory.Width, Height: ui.Inventory.Height}
+		}
+		if ui.Cursors != nil {
+			settings.Cursors = &CursorSettings{Default: ui.Cursors.Default, Verbs: ui.Cursors.Verbs}
+		}
+		g.SetInterfaceSettings(settings)
+	}
+
 	return nil
 }
 
+// decodeAnimation decodes the base64 frames of an editor animation and how they are played
+func decodeAnimation(anim model.EditorAnimation, owner string) ([][]byte, model.AnimationTiming) {
+	return mapAnimation(anim.Frames, anim.Loop, func(frame model.EditorAnimationFrame) ([]byte, int, bool) {
+		data, err := decodeBase64(frame.ImageData)
+		if err != nil {
+			log.Printf("Error decoding animation frame for %s: %v", owner, err)
+			return nil, 0, false
+		}
+		return data, frame.Duration, true
+	})
+}
+
 func decodeBase64(data string) ([]byte, error) {
 	// Handle data:image/png;base64, prefix if present
 	if strings.Contains(data, ",") {
@@ -0,0 +1,257 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"log"
+)
+
+const (
+	dialogueOptionHeight = 28
+	dialogueOptionMargin = 20
+)
+
+// dialogueState tracks the conversation currently running
+type dialogueState struct {
+	dialogueID   string
+	nodeID       string
+	pendingLines []model.DialogueLine
+	options      []model.DialogueOption
+	choosing     bool
+	choiceMade   bool
+	nextNode     string
+}
+
+// GetDialogue returns the dialogue with the given ID or name
+func (g *Game) GetDialogue(id string) (model.Dialogue, bool) {
+	if dialogue, exists := g.data.Dialogues[id]; exists {
+		return dialogue, true
+	}
+	for _, dialogue := range g.data.Dialogues {
+		if dialogue.Name == id {
+			return dialogue, true
+		}
+	}
+	return model.Dialogue{}, false
+}
+
+// StartDialogue starts the dialogue with the given ID or name from its start node
+func (g *Game) StartDialogue(id string) {
+	dialogue, exists := g.GetDialogue(id)
+	if !exists {
+		log.Printf("Error: dialogue '%s' not found.", id)
+		return
+	}
+
+	g.state.watingActions = make([]string, 0)
+	g.StopCharacterMovementAnimation()
+
+	g.state.dialogue = &dialogueState{dialogueID: dialogue.ID}
+	g.SetCurrentState(model.IN_DIALOGUE)
+	g.enterDialogueNode(dialogue.StartNode)
+}
+
+// EndDialogue stops the running dialogue, if any
+func (g *Game) EndDialogue() {
+	if g.state.dialogue == nil {
+		return
+	}
+	g.state.dialogue = nil
+	if g.GetCurrentState() == model.IN_DIALOGUE {
+		g.SetCurrentState(model.IDLE)
+	}
+}
+
+// IsInDialogue reports whether a dialogue is running
+func (g *Game) IsInDialogue() bool {
+	return g.state.dialogue != nil
+}
+
+func (g *Game) enterDialogueNode(nodeID string) {
+	d := g.state.dialogue
+	dialogue, _ := g.GetDialogue(d.dialogueID)
+	node, exists := dialogue.Nodes[nodeID]
+	if !exists {
+		log.Printf("Error: node '%s' not found in dialogue '%s'.", nodeID, dialogue.Name)
+		g.EndDialogue()
+		return
+	}
+
+	d.nodeID = node.ID
+	d.pendingLines = append([]model.DialogueLine{}, node.Lines...)
+	d.options = nil
+	d.choosing = false
+	d.choiceMade = false
+	d.nextNode = ""
+}
+
+// visibleDialogueOptions returns the options of a node whose conditions hold and that were not hidden
+func (g *Game) visibleDialogueOptions(dialogueID string, node model.DialogueNode) []model.DialogueOption {
+	options := make([]model.DialogueOption, 0)
+	for _, option := range node.Options {
+		if option.HideAfterChosen && g.state.chosenDialogueOptions[dialogueOptionKey(dialogueID, option.ID)] {
+			continue
+		}
+		if !g.dialogueConditionsHold(option.Conditions) {
+			continue
+		}
+		options = append(options, option)
+	}
+	return options
+}
+
+func (g *Game) dialogueConditionsHold(conditions []model.DialogueCondition) bool {
+	for _, condition := range conditions {
+		if condition.Flag != "" && g.GetFlag(condition.Flag) != condition.Value {
+			return false
+		}
+		if condition.Counter != "" && !compareCounter(g.GetCounter(condition.Counter), condition.Operator, condition.Amount) {
+			return false
+		}
+	}
+	return true
+}
+
+func compareCounter(value int, operator string, amount int) bool {
+	switch operator {
+	case "!=":
+		return value != amount
+	case "<":
+		return value < amount
+	case "<=":
+		return value <= amount
+	case ">":
+		return value > amount
+	case ">=":
+		return value >= amount
+	default:
+		return value == amount
+	}
+}
+
+func dialogueOptionKey(dialogueID string, optionID string) string {
+	return dialogueID + "/" + optionID
+}
+
+// ChooseDialogueOption picks one of the options currently shown to the player
+func (g *Game) ChooseDialogueOption(index int) {
+	d := g.state.dialogue
+	if d == nil || !d.choosing || index < 0 || index >= len(d.options) {
+		return
+	}
+
+	option := d.options[index]
+	if option.HideAfterChosen {
+		g.state.chosenDialogueOptions[dialogueOptionKey(d.dialogueID, option.ID)] = true
+	}
+
+	d.pendingLines = []model.DialogueLine{{Text: option.Text, Script: option.Script}}
+	d.options = nil
+	d.choosing = false
+	d.choiceMade = true
+	d.nextNode = option.Next
+}
+
+// Nuova funzione per aggiornare lo stato IN_DIALOGUE
+func (g *Game) updateDialogueState() {
+	d := g.state.dialogue
+	if d == nil {
+		g.SetCurrentState(model.IDLE)
+		return
+	}
+
+	// Wait for the current line to be read and for its hook to end
+	if len(g.state.textToDraw) > 0 || g.IsScriptRunning() {
+		return
+	}
+
+	if len(d.pendingLines) > 0 {
+		line := d.pendingLines[0]
+		d.pendingLines = d.pendingLines[1:]
+		dialogueID, nodeID := d.dialogueID, d.nodeID
+		say := func() {
+			if line.Text == "" {
+				return
+			}
+			if err := g.Say(line.Speaker, line.Text); err != nil {
+				log.Printf("Error speaking dialogue line %s/%s, said by the current character: %v", dialogueID, nodeID, err)
+				g.SaySomething(line.Text)
+			}
+		}
+		// The line is said once its hook has ended
+		if line.Script != "" {
+			g.runDialogueHook(line.Script, say)
+		} else {
+			say()
+		}
+		return
+	}
+
+	if d.choosing {
+		return
+	}
+
+	if d.choiceMade {
+		if d.nextNode == "" {
+			g.EndDialogue()
+		} else {
+			g.enterDialogueNode(d.nextNode)
+		}
+		return
+	}
+
+	dialogue, _ := g.GetDialogue(d.dialogueID)
+	options := g.visibleDialogueOptions(d.dialogueID, dialogue.Nodes[d.nodeID])
+	if len(options) == 0 {
+		g.EndDialogue()
+		return
+	}
+	d.options = options
+	d.choosing = true
+}
+
+func (g *Game) runDialogueHook(script string, onDone func()) {
+	// The dialogue goes on even if the hook failed
+	g.StartScript("dialogue "+g.state.dialogue.dialogueID, script, func(error) { onDone() })
+}
+
+// DialogueOptionRects returns the screen area of every option shown to the player
+func (g *Game) DialogueOptionRects() []image.Rectangle {
+	d := g.state.dialogue
+	if d == nil || !d.choosing {
+		return nil
+	}
+
+	rects := make([]image.Rectangle, len(d.options))
+	top := ScreenHeight - dialogueOptionMargin - len(d.options)*dialogueOptionHeight
+	for i := range d.options {
+		y := top + i*dialogueOptionHeight
+		rects[i] = image.Rect(dialogueOptionMargin, y, ScreenWidth-dialogueOptionMargin, y+dialogueOptionHeight)
+	}
+	return rects
+}
+
+// DialogueOptions returns the options the player is choosing from, if any
+func (g *Game) DialogueOptions() []model.DialogueOption {
+	if g.state.dialogue == nil || !g.state.dialogue.choosing {
+		return nil
+	}
+	return g.state.dialogue.options
+}
+
+// DialogueOptionAt returns the index of the option under the given screen position, or -1
+func (g *Game) DialogueOptionAt(x int, y int) int {
+	for i, rect := range g.DialogueOptionRects() {
+		if image.Pt(x, y).In(rect) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (g *Game) handleDialogueClick() {
+	index := g.DialogueOptionAt(g.state.cursorPosition.X, g.state.cursorPosition.Y)
+	if index >= 0 {
+		g.ChooseDialogueOption(index)
+	}
+}
@@ -0,0 +1,91 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"testing"
+)
+
+func newDialogueTestGame() *Game {
+	return newPortoTestGame(nil, func(game *Game, porto *model.Location) {
+		dialogue := model.NewDialogue("pirate-talk", "Pirate", "start")
+		dialogue.AddNode(model.DialogueNode{
+			ID:    "start",
+			Lines: []model.DialogueLine{{Text: "Ahoy!", Script: `game:SetFlag("GREETED", true)`}},
+			Options: []model.DialogueOption{
+				{ID: "name", Text: "What's your name?", HideAfterChosen: true, Next: "start"},
+				{ID: "gold", Text: "Give me your gold.", Conditions: []model.DialogueCondition{{Counter: "swords", Operator: ">=", Amount: 1}}, Next: "start"},
+				{ID: "bye", Text: "Bye.", Script: `game:SetCounter("talks", 1)`},
+			},
+		})
+		game.AddDialogue(dialogue)
+	})
+}
+
+// runDialogueUntilChoice advances the dialogue, skipping every spoken line, until options are shown or it ends
+func runDialogueUntilChoice(game *Game) {
+	for i := 0; i < 100 && game.IsInDialogue() && !game.state.dialogue.choosing; i++ {
+		game.state.textToDraw = make([]SpeechLine, 0)
+		game.updateDialogueState()
+	}
+}
+
+func TestDialogueOptionsHideAndConditions(t *testing.T) {
+	game := newDialogueTestGame()
+
+	game.StartDialogue("Pirate")
+	if game.GetCurrentState() != model.IN_DIALOGUE {
+		t.Fatalf("expected state IN_DIALOGUE, got %s", game.GetCurrentState())
+	}
+
+	runDialogueUntilChoice(game)
+	if !game.GetFlag("GREETED") {
+		t.Errorf("line hook did not run")
+	}
+	if len(game.state.dialogue.options) != 2 {
+		t.Fatalf("expected 2 visible options (gold hidden by condition), got %d", len(game.state.dialogue.options))
+	}
+
+	game.ChooseDialogueOption(0)
+	runDialogueUntilChoice(game)
+	if len(game.state.dialogue.options) != 1 || game.state.dialogue.options[0].ID != "bye" {
+		t.Fatalf("expected only 'bye' after choosing a hide-after-chosen option, got %+v", game.state.dialogue.options)
+	}
+
+	game.ChooseDialogueOption(0)
+	runDialogueUntilChoice(game)
+	if game.IsInDialogue() {
+		t.Errorf("expected dialogue to end after an option without next node")
+	}
+	if game.GetCounter("talks") != 1 {
+		t.Errorf("option hook did not run")
+	}
+	if game.GetCurrentState() != model.IDLE {
+		t.Errorf("expected state IDLE after dialogue, got %s", game.GetCurrentState())
+	}
+}
+
+func TestTalkToAfterWalkingStartsTheDialogue(t *testing.T) {
+	game := newDialogueTestGame()
+	action := model.NewAction("guybrush-id", model.TALK_TO, "pirate-id", model.NOTHING, "porto-id", "", nil, nil, nil)
+	action.Dialogue = "pirate-talk"
+	game.AddAction(action)
+
+	// The pirate is far away: walk to him first, then talk
+	game.SetCurrentCharacterPosition(image.Pt(100, 300))
+	game.state.watingActions = []string{
+		composeTrigger("guybrush-id", model.MOVE_TO, "100:300", "140:300", "porto-id"),
+		composeTrigger("guybrush-id", model.TALK_TO, "pirate-id", model.NOTHING, "porto-id"),
+	}
+	game.SetCurrentState(model.EXECUTING_ACTION)
+	for i := 0; i < 100 && game.GetCurrentState() == model.EXECUTING_ACTION; i++ {
+		game.updateExecutingActionState()
+	}
+
+	if position := game.GetCurrentCharacterPosition(); position != image.Pt(140, 300) {
+		t.Errorf("expected the character at the pirate, got %v", position)
+	}
+	if game.GetCurrentState() != model.IN_DIALOGUE || !game.IsInDialogue() {
+		t.Errorf("expected the dialogue started after the walk, got state %s", game.GetCurrentState())
+	}
+}
@@ -7,17 +7,18 @@ import (
 	"image"
 	"log"
 	"math"
+	"math/rand"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/hajimehoshi/ebiten/v2"
+	lua "github.com/yuin/gopher-lua"
 	"golang.org/x/image/math/f64"
 )
 
 const (
-	screenWidth  = 960
-	screenHeight = 540
+	ScreenWidth  = 960
+	ScreenHeight = 540
 )
 
 type Camera struct {
@@ -41,35 +42,33 @@ func (c *Camera) viewportCenter() f64.Vec2 {
 	}
 }
 
-func (c *Camera) worldMatrix() ebiten.GeoM {
-	m := ebiten.GeoM{}
-	m.Translate(-c.Position[0], -c.Position[1])
+// WorldMatrix returns the affine transformation from world to screen coordinates
+func (c *Camera) WorldMatrix() f64.Aff3 {
 	// We want to scale and rotate around center of image / screen
-	m.Translate(-c.viewportCenter()[0], -c.viewportCenter()[1])
-	m.Scale(
-		math.Pow(1.01, float64(c.ZoomFactor)),
-		math.Pow(1.01, float64(c.ZoomFactor)),
-	)
-	m.Rotate(float64(c.Rotation) * 2 * math.Pi / 360)
-	m.Translate(c.viewportCenter()[0], c.viewportCenter()[1])
-	return m
-}
-
-func (c *Camera) Render(world, screen *ebiten.Image) {
-	screen.DrawImage(world, &ebiten.DrawImageOptions{
-		GeoM: c.worldMatrix(),
-	})
+	center := c.viewportCenter()
+	scale := math.Pow(1.01, float64(c.ZoomFactor))
+	sin, cos := math.Sincos(float64(c.Rotation) * 2 * math.Pi / 360)
+
+	a, b := scale*cos, -scale*sin
+	d, e := scale*sin, scale*cos
+	tx, ty := -c.Position[0]-center[0], -c.Position[1]-center[1]
+	return f64.Aff3{
+		a, b, a*tx + b*ty + center[0],
+		d, e, d*tx + e*ty + center[1],
+	}
 }
 
 func (c *Camera) ScreenToWorld(posX, posY int) (float64, float64) {
-	inverseMatrix := c.worldMatrix()
-	if inverseMatrix.IsInvertible() {
-		inverseMatrix.Invert()
-		return inverseMatrix.Apply(float64(posX), float64(posY))
-	} else {
+	m := c.WorldMatrix()
+	det := m[0]*m[4] - m[1]*m[3]
+	if det == 0 {
 		// When scaling it can happened that matrix is not invertable
 		return math.NaN(), math.NaN()
 	}
+
+	x := float64(posX) - m[2]
+	y := float64(posY) - m[5]
+	return (m[4]*x - m[1]*y) / det, (m[0]*y - m[3]*x) / det
 }
 
 type GameData struct {
@@ -80,40 +79,65 @@ type GameData struct {
 	Triggers  map[string]model.Action    `json:"triggers"`
 	Scripts   map[string]string
 	Fonts     map[string][]byte
-	Cursors   map[string][]byte
+	Cursors   map[string]model.Cursor
+	Dialogues map[string]model.Dialogue
+	Interface InterfaceLayout
 }
 
 type GameState struct {
-	flags                          map[string]bool
-	counters                       map[string]int
-	currentLocation                model.Location
-	currentCharacter               model.Character
-	pathFinder                     *model.Pathfinder
-	pathPointIndex                 int
-	currentCharacterPosition       image.Point
-	currentCharacterDirection      model.CharacterDirection
-	currentCharacterAnimation      string
-	currentCharacterAnimationFrame int
-	currentVerb                    model.Verb
-	mainItemID                     string
-	secondItemID                   string
-	currentState                   model.StateType
-	watingActions                  []string
-	lastUpdated                    time.Time
-	yOrderedEntities               []string
-	cursorOnItem                   string
-	textToDraw                     []string
-	textDrawn                      time.Time
-	world                          *ebiten.Image
-	currentBackGround              *ebiten.Image
-	currentCursor                  *ebiten.Image
-	camera                         Camera
+	flags                 map[string]bool
+	counters              map[string]int
+	currentLocation       model.Location
+	currentCharacter      model.Character
+	pathFinder            *model.Pathfinder
+	pathPointIndex        int
+	characters            map[string]*CharacterState
+	currentVerb           model.Verb
+	mainItemID            string
+	secondItemID          string
+	committedSentence     string
+	currentState          model.StateType
+	watingActions         []string
+	yOrderedEntities      []string
+	cursorOnItem          string
+	cursorOnInventory     string
+	inventoryScroll       int
+	textToDraw            []SpeechLine
+	textDrawn             time.Duration
+	textSpeed             int // Characters per second the player reads
+	dialogue              *dialogueState
+	scripts               []*scriptTask
+	runningScript         *scriptTask
+	lua                   *lua.LState
+	luaBuiltins           map[string]bool
+	chosenDialogueOptions map[string]bool
+	clock                 time.Duration
+	cursorPosition        image.Point
+	currentCursor         string // Cursor set chosen by a script, "" for the one of the current verb
+	cursorSet             string
+	cursor                AnimationPlayer
+	cursorOnExit          bool
+	backgroundSize        image.Point
+	random                *rand.Rand
+	randomSeed            int64
+	walkableAreas         map[string]map[string]bool  // Location ID -> walkable area -> enabled, when changed by a script
+	itemAnimations        map[string]*AnimationPlayer // Item ID -> animation being played
+	insideExit            int                         // Index of the exit the current character stands in, -1 if none
+	pendingExit           int                         // Index of the exit clicked by the player, taken once the walk is over
+	camera                Camera
+}
+
+// YOrderedEntities returns the IDs of the items, characters and walk-behinds (see WalkBehindIndex)
+// of the current location, in drawing order
+func (g *Game) YOrderedEntities() []string {
+	return g.state.yOrderedEntities
 }
 
 func (gs *GameState) CalculateYOrderedEntities() {
 	type oderedItem struct {
-		Id string
-		Y  int
+		Id         string
+		Y          int
+		WalkBehind bool
 	}
 
 	orderedItems := make([]oderedItem, 0)
@@ -124,13 +148,36 @@ func (gs *GameState) CalculateYOrderedEntities() {
 		})
 	}
 
+	for _, id := range gs.charactersInRoom() {
+		orderedItems = append(orderedItems, oderedItem{
+			Id: id,
+			Y:  gs.characters[id].Position.Y,
+		})
+	}
+
 	orderedItems = append(orderedItems, oderedItem{
 		Id: gs.currentCharacter.ID,
-		Y:  gs.currentCharacterPosition.Y,
+		Y:  gs.characterState(gs.currentCharacter.ID).Position.Y,
 	})
 
+	// Un walk-behind copre chi sta sopra la sua baseline
+	for i, walkBehind := range gs.currentLocation.GetWalkBehinds() {
+		orderedItems = append(orderedItems, oderedItem{
+			Id:         walkBehindID(i),
+			Y:          walkBehind.Baseline,
+			WalkBehind: true,
+		})
+	}
+
+	// A parità di Y l'ordine deve restare stabile tra un frame e l'altro
 	sort.Slice(orderedItems, func(i, j int) bool {
-		return orderedItems[i].Y < orderedItems[j].Y
+		if orderedItems[i].Y != orderedItems[j].Y {
+			return orderedItems[i].Y < orderedItems[j].Y
+		}
+		if orderedItems[i].WalkBehind != orderedItems[j].WalkBehind {
+			return orderedItems[i].WalkBehind // Chi sta sulla baseline resta davanti
+		}
+		return orderedItems[i].Id < orderedItems[j].Id
 	})
 
 	result := make([]string, 0)
@@ -157,41 +204,56 @@ func (g *Game) SetCurrentVerb(verb model.Verb) {
 	g.state.currentVerb = verb
 }
 
-func (g *Game) SetCurrentCursor(name string) {
-	// Load cursor on-demand if using packaged data
-	if g.packagedData != nil {
-		g.LoadCursor(name)
-	}
-
-	cursorImage, _, err := image.Decode(bytes.NewReader(g.data.Cursors[name]))
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	g.state.currentCursor = ebiten.NewImageFromImage(cursorImage)
-}
-
 func (g *Game) GetCurrentLocation() model.Location {
 	return g.state.currentLocation
 }
 
 func (g *Game) SetCurrentLocation(location string) {
 	locationData := g.GetLocation(location)
+	if previous := g.state.currentLocation.ID; previous != "" && previous != locationData.ID {
+		for _, handler := range g.onLeaveLocation {
+			handler(previous)
+		}
+	}
 	g.state.currentLocation = locationData
-	g.state.pathFinder = model.NewPathfinder(locationData.GetWalkableArea(0).Polygons)
+	g.updatePathFinder()
 
 	// Load background on-demand
 	if g.packagedData != nil {
 		g.LoadLocationBackground(location)
 	}
 
-	backgroundImage, _, err := image.Decode(bytes.NewReader(locationData.GetLayers()[0].Image))
-	if err != nil {
-		log.Fatal(err)
+	// The camera scrolls within the background
+	g.state.backgroundSize = image.Point{}
+	if background := g.BackgroundImage(); len(background) > 0 {
+		config, _, err := image.DecodeConfig(bytes.NewReader(background))
+		if err != nil {
+			log.Printf("Error decoding background of location '%s': %v", locationData.Name, err)
+		} else {
+			g.state.backgroundSize = image.Pt(config.Width, config.Height)
+		}
+	}
+
+	g.placeCharacters(locationData)
+}
+
+// OnLeaveLocation calls handler with the ID of the location the game leaves, e.g. to free its resources
+func (g *Game) OnLeaveLocation(handler func(locationID string)) {
+	g.onLeaveLocation = append(g.onLeaveLocation, handler)
+}
+
+// BackgroundImage returns the background image of the current location, or nil
+func (g *Game) BackgroundImage() []byte {
+	layers := g.state.currentLocation.GetLayers()
+	if len(layers) == 0 {
+		return nil
 	}
+	return layers[0].Image
+}
 
-	g.state.currentBackGround = ebiten.NewImageFromImage(backgroundImage)
-	g.state.world = ebiten.NewImage(g.state.currentBackGround.Bounds().Dx(), g.state.currentBackGround.Bounds().Dx())
+// GetCamera returns the camera looking at the current location
+func (g *Game) GetCamera() Camera {
+	return g.state.camera
 }
 
 func (g *Game) GetCurrentCharacter() model.Character {
@@ -202,6 +264,11 @@ func (g *Game) SetCurrentCharacter(character string) {
 	characterData := g.GetCharacter(character)
 	g.state.currentCharacter = characterData
 
+	// The current character is always in the current location
+	if g.state.currentLocation.ID != "" {
+		g.currentCharacterState().Location = g.state.currentLocation.ID
+	}
+
 	// Load character animations on-demand
 	if g.packagedData != nil {
 		g.LoadCharacterAnimations(character)
@@ -240,6 +307,32 @@ func (g *Game) GetItem(id string) model.Item {
 	return g.data.Items[id]
 }
 
+// locationKey returns the GameData.Locations key of the location with the given key or ID
+func (g *Game) locationKey(id string) (string, bool) {
+	if _, exists := g.data.Locations[id]; exists {
+		return id, true
+	}
+	for key, location := range g.data.Locations {
+		if location.ID == id {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// characterKey returns the GameData.Character key of the character with the given key or ID
+func (g *Game) characterKey(id string) (string, bool) {
+	if _, exists := g.data.Character[id]; exists {
+		return id, true
+	}
+	for key, character := range g.data.Character {
+		if character.ID == id {
+			return key, true
+		}
+	}
+	return "", false
+}
+
 func (g *Game) AddScript(name string, scriptText string) {
 	if g.data.Scripts == nil {
 		g.data.Scripts = make(map[string]string)
@@ -248,68 +341,60 @@ func (g *Game) AddScript(name string, scriptText string) {
 }
 
 func (g *Game) ExecuteScript(name string) {
-	// Load script on-demand if not loaded
-	if g.data.Scripts[name] == "" && g.packagedData != nil {
-		g.LoadScript(name)
-	}
-
-	scriptText, exists := g.data.Scripts[name]
+	scriptText, exists := g.scriptSource(name)
 	if !exists {
 		log.Printf("Error: Lua script named '%s' not found.", name)
 		return
 	}
 
-	L := NewLuaState(g)
-	defer L.Close()
-
-	err := RunScript(L, scriptText)
-	if err != nil {
-		log.Printf("Error executing named Lua script '%s': %v", name, err)
-	}
+	g.StartScript(name, scriptText, nil)
 }
 
 func (g *Game) GetCurrentCharacterPosition() image.Point {
-	return g.state.currentCharacterPosition
+	return g.currentCharacterState().Position
 }
 
 func (g *Game) SetCurrentCharacterPosition(position image.Point) {
-	g.state.currentCharacterPosition = position
+	g.currentCharacterState().Position = position
 }
 
 func (g *Game) GetCurrentCharacterDirection() model.CharacterDirection {
-	return g.state.currentCharacterDirection
+	return g.currentCharacterState().Direction
 }
 
 func (g *Game) SetCurrentCharacterDirection(direction model.CharacterDirection) {
-	g.state.currentCharacterDirection = direction
+	g.currentCharacterState().Direction = direction
 }
 
 func (g *Game) SetCurrentCharacterAnimationFrame(value int) {
-	g.state.currentCharacterAnimationFrame = value
+	g.currentCharacterState().AnimationFrame = value
 }
 
+// SetCurrentCharacterAnimation plays an animation of the current character, unless it is already playing
 func (g *Game) SetCurrentCharacterAnimation(animation string) {
-	g.state.currentCharacterAnimation = animation
+	state := g.currentCharacterState()
+	if state.Animation != animation {
+		state.Play(animation, g.now())
+	}
 }
 
 func (g *Game) SetCurrentCharacterAnimationAtFrame(animation string, frame int) {
-	g.state.currentCharacterAnimation = animation
-	g.state.currentCharacterAnimationFrame = frame
+	state := g.currentCharacterState()
+	state.Play(animation, g.now())
+	state.AnimationFrame = frame
 }
 
 func (g *Game) AdvanceCurrentAnimationFrame() {
-	g.state.currentCharacterAnimationFrame += 1
-	if g.state.currentCharacterAnimationFrame >= len(g.state.currentCharacter.Animations[g.state.currentCharacterAnimation]) {
-		g.state.currentCharacterAnimationFrame = 0
+	state := g.currentCharacterState()
+	state.AnimationFrame += 1
+	if state.AnimationFrame >= len(g.state.currentCharacter.Animations[state.Animation]) {
+		state.AnimationFrame = 0
 	}
 }
 
 func (g *Game) GetCurrentCharacterAnimation() (string, int) {
-	return g.state.currentCharacterAnimation, g.state.currentCharacterAnimationFrame
-}
-
-func (g *Game) SaySomething(sentence string) {
-	g.state.textToDraw = append(g.state.textToDraw, sentence)
+	state := g.currentCharacterState()
+	return state.Animation, state.AnimationFrame
 }
 
 func initGameData() GameData {
@@ -328,35 +413,47 @@ func initGameData() GameData {
 		Triggers:  make(map[string]model.Action),
 		Scripts:   make(map[string]string),
 		Fonts:     make(map[string][]byte),
-		Cursors:   make(map[string][]byte),
+		Cursors:   make(map[string]model.Cursor),
+		Dialogues: make(map[string]model.Dialogue),
+		Interface: defaultInterfaceLayout(),
 	}
 }
 
 func initGameState() GameState {
 	return GameState{
-		flags:                          make(map[string]bool),
-		counters:                       make(map[string]int),
-		currentLocation:                model.Location{},
-		currentCharacter:               model.Character{},
-		pathFinder:                     nil,
-		currentCharacterPosition:       image.Point{},
-		currentCharacterDirection:      "",
-		currentCharacterAnimation:      "",
-		currentCharacterAnimationFrame: 0,
-		currentVerb:                    model.MOVE_TO,
-		mainItemID:                     "",
-		secondItemID:                   "",
-		currentState:                   model.IDLE,
-		watingActions:                  make([]string, 0),
-		lastUpdated:                    time.Time{},
-		yOrderedEntities:               make([]string, 0),
-		cursorOnItem:                   "",
-		textToDraw:                     make([]string, 0),
-		textDrawn:                      time.Time{},
-		world:                          nil,
-		currentBackGround:              nil,
-		currentCursor:                  nil,
-		camera:                         Camera{ViewPort: f64.Vec2{screenWidth, screenHeight}},
+		flags:                 make(map[string]bool),
+		counters:              make(map[string]int),
+		currentLocation:       model.Location{},
+		currentCharacter:      model.Character{},
+		pathFinder:            nil,
+		characters:            make(map[string]*CharacterState),
+		currentVerb:           model.MOVE_TO,
+		mainItemID:            "",
+		secondItemID:          "",
+		currentState:          model.IDLE,
+		watingActions:         make([]string, 0),
+		yOrderedEntities:      make([]string, 0),
+		cursorOnItem:          "",
+		cursorOnInventory:     "",
+		inventoryScroll:       0,
+		textToDraw:            make([]SpeechLine, 0),
+		textSpeed:             DefaultTextSpeed,
+		textDrawn:             0,
+		dialogue:              nil,
+		scripts:               make([]*scriptTask, 0),
+		runningScript:         nil,
+		chosenDialogueOptions: make(map[string]bool),
+		clock:                 0,
+		cursorPosition:        image.Point{},
+		currentCursor:         "",
+		backgroundSize:        image.Point{},
+		random:                rand.New(rand.NewSource(0)),
+		randomSeed:            0,
+		walkableAreas:         make(map[string]map[string]bool),
+		itemAnimations:        make(map[string]*AnimationPlayer),
+		insideExit:            -1,
+		pendingExit:           -1,
+		camera:                Camera{ViewPort: f64.Vec2{ScreenWidth, ScreenHeight}},
 	}
 }
 
@@ -374,8 +471,15 @@ func (g *Game) AddFont(name string, font []byte) {
 	g.data.Fonts[name] = font
 }
 
+// AddCursor adds a cursor set made of a single image
 func (g *Game) AddCursor(name string, cursor []byte) {
-	g.data.Cursors[name] = cursor
+	set := model.NewCursor(name)
+	set.AddAnimation(string(CURSOR_DEFAULT), [][]byte{cursor}, model.AnimationTiming{Loop: true})
+	g.AddCursorSet(set)
+}
+
+func (g *Game) AddCursorSet(cursor model.Cursor) {
+	g.data.Cursors[cursor.Name] = cursor
 }
 
 func (g *Game) AddCharacter(character model.Character) {
@@ -390,6 +494,10 @@ func (g *Game) AddLocation(location model.Location) {
 	g.data.Locations[location.Name] = location
 }
 
+func (g *Game) AddDialogue(dialogue model.Dialogue) {
+	g.data.Dialogues[dialogue.ID] = dialogue
+}
+
 func (g *Game) AddAction(action model.Action) {
 	trigger := calculateActionTrigger(action)
 	g.data.Triggers[trigger] = action
@@ -413,18 +521,35 @@ func (g *Game) ExecuteAction(inputTrigger string) {
 
 	log.Printf("Executing Lua script for action %s: %v", inputTrigger, actionToExecute.Script)
 
-	L := NewLuaState(g)
-	defer L.Close()
-
-	err := RunScript(L, actionToExecute.Script)
-	if err != nil {
-		log.Printf("Error executing Lua script for action %s: %v", inputTrigger, err)
+	// Errors, e.g. a script aborted for running too long, point to the diagram node
+	scriptName := inputTrigger
+	if actionToExecute.NodeID != "" {
+		scriptName = fmt.Sprintf("%s (node %s)", inputTrigger, actionToExecute.NodeID)
 	}
 
-	switch actionToExecute.Verb {
+	// The built-in effects of the verb follow the script, which may block (e.g. a cutscene).
+	// A script that failed, e.g. aborted for running too long, has none.
+	g.StartScript(scriptName, actionToExecute.Script, func(err error) {
+		if err != nil {
+			return
+		}
+		if actionToExecute.Dialogue != "" {
+			g.StartDialogue(actionToExecute.Dialogue)
+		}
+		g.executeVerb(actionToExecute.Verb, subject, mainObject, secondObject, location)
+	})
+}
+
+// executeVerb applies the built-in effects of a verb once the action script has ended
+func (g *Game) executeVerb(verb model.Verb, subject string, mainObject string, secondObject string, location string) {
+	switch verb {
 	case model.GIVE_TO:
 		item := g.data.Items[mainObject]
-		character := g.data.Character[subject]
+		characterKey, _ := g.characterKey(subject)
+		character := g.data.Character[characterKey]
+		if _, exists := character.Inventory[item.ID]; !exists {
+			break
+		}
 		if character.Inventory[item.ID].Count == 1 {
 			delete(character.Inventory, item.ID)
 		} else {
@@ -433,12 +558,29 @@ func (g *Game) ExecuteAction(inputTrigger string) {
 			character.Inventory[item.ID] = slot
 		}
 
+		// The item ends up in the inventory of the character it was given to
+		if receiverKey, exists := g.characterKey(secondObject); exists {
+			receiver := g.data.Character[receiverKey]
+			if receiver.Inventory == nil {
+				break
+			}
+			slot := receiver.Inventory[item.ID]
+			slot.Count++
+			slot.Item = item
+			receiver.Inventory[item.ID] = slot
+		}
+
 	case model.TALK_TO:
-		// TODO: Implement talk to
+		// Conversations are started through the action's Dialogue (see above)
 	case model.PICK_UP:
 		item := g.data.Items[mainObject]
 		if item.Pickable {
-			characterData := g.data.Character[subject]
+			characterKey, _ := g.characterKey(subject)
+			characterData := g.data.Character[characterKey]
+			if characterData.Inventory == nil {
+				log.Printf("Character '%s' not found, can't pick up '%s'", subject, item.ID)
+				break
+			}
 			if slot, exists := characterData.Inventory[item.ID]; exists {
 				slot.Count++
 				characterData.Inventory[item.ID] = slot
@@ -448,7 +590,8 @@ func (g *Game) ExecuteAction(inputTrigger string) {
 					Item:  item,
 				}
 			}
-			locationData := g.data.Locations[location]
+			locationKey, _ := g.locationKey(location)
+			locationData := g.data.Locations[locationKey]
 			delete(locationData.Items, item.ID)
 
 		} else {
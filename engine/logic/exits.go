@@ -0,0 +1,96 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"log"
+)
+
+// ExitAt returns the exit of the current location at a point of the location, if any
+func (g *Game) ExitAt(x, y int) (model.Exit, bool) {
+	index := g.exitIndexAt(x, y)
+	if index < 0 {
+		return model.Exit{}, false
+	}
+	return g.state.currentLocation.GetExits()[index], true
+}
+
+func (g *Game) exitIndexAt(x, y int) int {
+	for i, exit := range g.state.currentLocation.GetExits() {
+		if exit.Contains(image.Pt(x, y)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkExits takes the exit the current character has walked into, or the exit clicked by
+// the player once the walk towards it is over. Characters walked by scripts never take exits.
+func (g *Game) checkExits() {
+	if g.state.currentCharacter.ID == "" {
+		return
+	}
+	position := g.GetCurrentCharacterPosition()
+	inside := g.exitIndexAt(position.X, position.Y)
+
+	if g.IsScriptRunning() || g.IsInDialogue() {
+		g.state.insideExit = inside
+		g.state.pendingExit = -1
+		return
+	}
+
+	exits := g.state.currentLocation.GetExits()
+	switch {
+	case inside >= 0 && inside != g.state.insideExit:
+		g.takeExit(exits[inside])
+	case g.state.pendingExit >= 0 && g.GetCurrentState() == model.IDLE:
+		// The exit may be outside the walkable area: the walk ends as close as possible to it
+		g.takeExit(exits[g.state.pendingExit])
+	default:
+		g.state.insideExit = inside
+	}
+}
+
+// takeExit runs the on-exit script of an exit, then moves the current character to the entry point
+// of the target location and runs the on-enter script
+func (g *Game) takeExit(exit model.Exit) {
+	g.state.pendingExit = -1
+	g.state.insideExit = -1
+	g.actionsDone()
+	g.StopCharacterMovementAnimation()
+
+	key, exists := g.locationKey(exit.TargetLocation)
+	if !exists {
+		log.Printf("Error: target location '%s' of exit '%s' not found", exit.TargetLocation, exit.Name)
+		return
+	}
+
+	enter := func() {
+		g.SetCurrentLocation(key)
+		g.SetCurrentCharacterPosition(exit.EntryPoint)
+		if exit.EntryDirection != "" {
+			g.SetCurrentCharacterDirection(exit.EntryDirection)
+		}
+		g.StopCharacterMovementAnimation()
+
+		// The entry point may lie in an exit back: it is taken only once the character walks into it again
+		g.state.insideExit = g.exitIndexAt(exit.EntryPoint.X, exit.EntryPoint.Y)
+
+		if exit.OnEnter != "" {
+			g.ExecuteScript(exit.OnEnter)
+		}
+	}
+
+	if exit.OnExit == "" {
+		enter()
+		return
+	}
+	script, exists := g.scriptSource(exit.OnExit)
+	if !exists {
+		log.Printf("Error: Lua script named '%s' not found.", exit.OnExit)
+		enter()
+		return
+	}
+	// The character is not left stuck in the exit if the script failed
+	g.StartScript(exit.OnExit, script, func(error) { enter() })
+}
@@ -0,0 +1,137 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"testing"
+	"time"
+)
+
+// newExitsTestGame returns a game in Porto, whose pier leads to Isola; Isola's boat leads back
+func newExitsTestGame() *Game {
+	return newPortoTestGame(nil, func(game *Game, porto *model.Location) {
+		porto.AddExit(model.Exit{
+			Name:           "pier",
+			Area:           []image.Point{{850, 200}, {900, 200}, {900, 380}, {850, 380}},
+			TargetLocation: "isola-id",
+			EntryPoint:     image.Pt(40, 300),
+			EntryDirection: model.RIGHT,
+			OnExit:         "LeavePorto",
+			OnEnter:        "ArriveIsola",
+		})
+
+		// The entry point lies in the exit back to the docks
+		isola := model.NewLocation("isola-id", "Isola", nil)
+		isola.AddWalkableArea(model.WalkableArea{Polygons: [][]image.Point{{{0, 200}, {600, 200}, {600, 380}, {0, 380}}}})
+		isola.AddExit(model.Exit{
+			Name:           "boat",
+			Area:           []image.Point{{0, 200}, {50, 200}, {50, 380}, {0, 380}},
+			TargetLocation: "porto-id",
+			EntryPoint:     image.Pt(800, 300),
+			EntryDirection: model.LEFT,
+		})
+		game.AddLocation(isola)
+
+		game.AddScript("LeavePorto", `game:SetFlag("LEFT_PORTO", true)`)
+		game.AddScript("ArriveIsola", `game:SetCounter("arrivals", game:GetCounter("arrivals") + 1)`)
+	})
+}
+
+func TestWalkingIntoExitChangesLocation(t *testing.T) {
+	game := newExitsTestGame()
+
+	game.MoveTo(880, 300)
+	for elapsed := time.Duration(0); elapsed < time.Minute && game.GetCurrentLocation().ID == "porto-id"; elapsed += TickDuration {
+		game.Tick(nil)
+	}
+
+	if game.GetCurrentLocation().ID != "isola-id" {
+		t.Fatalf("expected the exit to lead to Isola, got %s", game.GetCurrentLocation().Name)
+	}
+	if position := game.GetCurrentCharacterPosition(); position != image.Pt(40, 300) {
+		t.Errorf("expected the character at the entry point, got %v", position)
+	}
+	if direction := game.GetCurrentCharacterDirection(); direction != model.RIGHT {
+		t.Errorf("expected the character facing right, got %s", direction)
+	}
+	if animation, _ := game.GetCurrentCharacterAnimation(); animation != string(model.IDLE_FACE_RIGHT) {
+		t.Errorf("expected the idle animation facing right, got %s", animation)
+	}
+	if !game.GetFlag("LEFT_PORTO") {
+		t.Errorf("expected the on-exit script run")
+	}
+
+	// Standing at the entry point does not take the exit back
+	for i := 0; i < 10; i++ {
+		game.Tick(nil)
+	}
+	if game.GetCurrentLocation().ID != "isola-id" || game.GetCounter("arrivals") != 1 {
+		t.Errorf("expected one arrival in Isola, got %s after %d arrivals", game.GetCurrentLocation().Name, game.GetCounter("arrivals"))
+	}
+}
+
+func TestLoadingInsideAnExitDoesNotTakeIt(t *testing.T) {
+	game := newExitsTestGame()
+	game.MoveTo(880, 300)
+	for elapsed := time.Duration(0); elapsed < time.Minute && game.GetCurrentLocation().ID == "porto-id"; elapsed += TickDuration {
+		game.Tick(nil)
+	}
+	// Saved at the entry point, inside the boat exit
+	saved := game.snapshotSaveGame()
+
+	// Away from the boat, then back to the save standing in it
+	game.SetCurrentCharacterPosition(image.Pt(300, 300))
+	game.Tick(nil)
+	if err := game.restoreSaveGame(saved); err != nil {
+		t.Fatalf("restoreSaveGame failed: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		game.Tick(nil)
+	}
+
+	if game.GetCurrentLocation().ID != "isola-id" {
+		t.Errorf("expected to stay in Isola after loading, got %s", game.GetCurrentLocation().Name)
+	}
+}
+
+func TestClickingAnExitWithoutWalkableAreaDoesNotTakeIt(t *testing.T) {
+	game := newExitsTestGame()
+	// A dock reached by script only: the player cannot walk there
+	molo := model.NewLocation("molo-id", "Molo", nil)
+	molo.AddExit(model.Exit{
+		Name:           "gangway",
+		Area:           []image.Point{{850, 200}, {900, 200}, {900, 380}, {850, 380}},
+		TargetLocation: "isola-id",
+		EntryPoint:     image.Pt(40, 300),
+	})
+	game.AddLocation(molo)
+	game.SetCurrentLocation("Molo")
+	game.SetCurrentCharacterPosition(image.Pt(100, 300))
+
+	game.state.cursorPosition = image.Pt(880, 300)
+	game.handleLeftClick()
+	for i := 0; i < 10; i++ {
+		game.Tick(nil)
+	}
+
+	if game.GetCurrentLocation().ID != "molo-id" {
+		t.Errorf("expected to stay in Molo, got %s", game.GetCurrentLocation().Name)
+	}
+	if position := game.GetCurrentCharacterPosition(); position != image.Pt(100, 300) {
+		t.Errorf("expected the character not to move, got %v", position)
+	}
+}
+
+func TestClickingAnExitTakesItAfterTheWalk(t *testing.T) {
+	game := newExitsTestGame()
+
+	game.state.cursorPosition = image.Pt(880, 300)
+	game.handleLeftClick()
+	for elapsed := time.Duration(0); elapsed < time.Minute && game.GetCurrentLocation().ID == "porto-id"; elapsed += TickDuration {
+		game.Tick(nil)
+	}
+
+	if game.GetCurrentLocation().ID != "isola-id" {
+		t.Errorf("expected the clicked exit to lead to Isola, got %s", game.GetCurrentLocation().Name)
+	}
+}
@@ -0,0 +1,26 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+)
+
+// newPortoTestGame returns a game with Guybrush, the current character, standing at (100,300) in Porto,
+// whose walkable area spans (0,200)-(900,380). setup, if any, adds what a test needs to the game and
+// to Porto before Guybrush enters it.
+func newPortoTestGame(background []byte, setup func(game *Game, porto *model.Location)) *Game {
+	game := NewGame()
+	game.AddCharacter(model.NewCharacter("guybrush-id", "Guybrush", model.Color{R: 255, G: 255, B: 255}))
+
+	porto := model.NewLocation("porto-id", "Porto", background)
+	porto.AddWalkableArea(model.WalkableArea{Polygons: [][]image.Point{{{0, 200}, {900, 200}, {900, 380}, {0, 380}}}})
+	if setup != nil {
+		setup(&game, &porto)
+	}
+	game.AddLocation(porto)
+
+	game.SetCurrentCharacter("Guybrush")
+	game.SetCurrentLocation("Porto")
+	game.SetCurrentCharacterPosition(image.Pt(100, 300))
+	return &game
+}
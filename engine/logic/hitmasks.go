@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"bytes"
+	"chemistry/engine/model"
+	"fmt"
+	"image"
+	"log"
+)
+
+// itemHitMask returns the hit mask of the image an item is showing, or nil if it has no image.
+// Masks missing from the game data are built once and kept with the item.
+func (g *Game) itemHitMask(itemID string) *model.HitMask {
+	item := g.loadedItem(itemID)
+	player := g.itemAnimation(item)
+	if player == nil || player.AnimationFrame < 0 || player.AnimationFrame >= len(item.Animations[player.Animation]) {
+		if item.HitMask == nil && len(item.Image) > 0 {
+			item.HitMask = decodeHitMask(item.Image)
+			g.data.Items[itemID] = item
+		}
+		return item.HitMask
+	}
+
+	frames := item.Animations[player.Animation]
+	masks := item.FrameHitMasks[player.Animation]
+	if len(masks) != len(frames) {
+		if item.FrameHitMasks == nil {
+			item.FrameHitMasks = make(map[string][]*model.HitMask)
+			g.data.Items[itemID] = item
+		}
+		masks = make([]*model.HitMask, len(frames))
+		item.FrameHitMasks[player.Animation] = masks
+	}
+	if masks[player.AnimationFrame] == nil {
+		masks[player.AnimationFrame] = decodeHitMask(frames[player.AnimationFrame])
+	}
+	return masks[player.AnimationFrame]
+}
+
+// decodeHitMask builds the hit mask of an image, an empty one if it can't be decoded
+func decodeHitMask(data []byte) *model.HitMask {
+	mask := model.HitMask{}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("Error decoding sprite for its hit mask: %v", err)
+	} else {
+		mask = model.NewHitMask(img)
+	}
+	return &mask
+}
+
+// characterHit is what hit testing a frame of a character needs: the bounds of the sprite and its opaque pixels
+type characterHit struct {
+	bounds image.Rectangle
+	mask   model.HitMask
+}
+
+// characterHit returns the hit test data of the frame a character is showing, decoding it once
+// per location. ok is false if the character has no frame to show or it can't be decoded.
+func (g *Game) characterHit(character model.Character, state *CharacterState) (characterHit, bool) {
+	if g.characterHits == nil {
+		g.characterHits = NewResourceCache[characterHit](g, nil)
+	}
+
+	key := FrameKey(character.ID, state.Animation, state.AnimationFrame)
+	hit, err := g.characterHits.Get(key, g.state.currentLocation.ID, func() (characterHit, error) {
+		frameImage := CharacterFrame(character, state)
+		if len(frameImage) == 0 {
+			return characterHit{}, fmt.Errorf("character '%s' has no frame %s", character.ID, key)
+		}
+		img, _, err := image.Decode(bytes.NewReader(frameImage))
+		if err != nil {
+			log.Printf("Error decoding frame %s for its hit mask: %v", key, err)
+			return characterHit{}, err
+		}
+		return characterHit{bounds: img.Bounds(), mask: model.NewHitMask(img)}, nil
+	})
+	return hit, err == nil
+}
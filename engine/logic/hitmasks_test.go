@@ -0,0 +1,112 @@
+package logic
+
+import (
+	"bytes"
+	"chemistry/engine/model"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// ringSprite is a 20x20 sprite with a transparent hole in the middle
+func ringSprite(t *testing.T) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			if x < 5 || x >= 15 || y < 5 || y >= 15 {
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestHitMaskRoundTripsItsBits(t *testing.T) {
+	img, _, err := image.Decode(bytes.NewReader(ringSprite(t)))
+	if err != nil {
+		t.Fatalf("image.Decode failed: %v", err)
+	}
+	data, err := model.NewHitMask(img).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	if len(data) != 16+3*20 {
+		t.Errorf("expected 3 bytes for each of the 20 rows, got %d bytes", len(data))
+	}
+
+	var mask model.HitMask
+	if err := mask.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	for _, point := range []image.Point{{0, 0}, {19, 19}, {4, 10}, {15, 10}} {
+		if !mask.Contains(point) {
+			t.Errorf("expected %v opaque", point)
+		}
+	}
+	for _, point := range []image.Point{{10, 10}, {20, 0}, {-1, 5}} {
+		if mask.Contains(point) {
+			t.Errorf("expected %v transparent", point)
+		}
+	}
+	if err := mask.UnmarshalBinary(data[:20]); err == nil {
+		t.Errorf("expected a truncated mask rejected")
+	}
+}
+
+func TestItemAtPicksTheTopMostOpaqueItem(t *testing.T) {
+	game := newCharactersTestGame()
+	game.AddItem(model.NewItem("back-id", "Back", false, false, ringSprite(t)))
+	game.AddItem(model.NewItem("front-id", "Front", false, false, ringSprite(t)))
+	location := game.GetLocation("Porto")
+	location.AddItem("back-id", model.ItemLocation{LocationPoint: image.Pt(100, 100)})
+	location.AddItem("front-id", model.ItemLocation{LocationPoint: image.Pt(110, 110)})
+	game.SetCurrentLocation("Porto")
+	game.state.CalculateYOrderedEntities()
+
+	// Front is drawn after Back, being lower in the room
+	if id := game.ItemAt(112, 112); id != "front-id" {
+		t.Errorf("expected the front item where both are opaque, got %q", id)
+	}
+	// Through the hole of Front the player clicks Back
+	if id := game.ItemAt(117, 117); id != "back-id" {
+		t.Errorf("expected the back item through the hole of the front one, got %q", id)
+	}
+	if id := game.ItemAt(120, 120); id != "" {
+		t.Errorf("expected no item through both holes, got %q", id)
+	}
+	if id := game.ItemAt(200, 200); id != "" {
+		t.Errorf("expected no item outside the sprites, got %q", id)
+	}
+}
+
+func TestCharacterAtDecodesEachFrameOnce(t *testing.T) {
+	game := newCharactersTestGame()
+	pirate := game.GetCharacter("Pirate")
+	pirate.Animations[string(model.IDLE_FACE_DOWN)] = [][]byte{ringSprite(t)}
+	game.SetCurrentLocation("Porto")
+	game.state.CalculateYOrderedEntities()
+
+	bounds := CharacterBounds(image.Pt(300, 200), 20, 20, game.CharacterScale(image.Pt(300, 200)))
+	edge := bounds.Min.Add(image.Pt(1, 1))
+	hole := bounds.Min.Add(image.Pt(bounds.Dx()/2, bounds.Dy()/2))
+	if id := game.CharacterAt(edge.X, edge.Y); id != "pirate-id" {
+		t.Errorf("expected the pirate on its opaque edge, got %q", id)
+	}
+	if id := game.CharacterAt(hole.X, hole.Y); id != "" {
+		t.Errorf("expected nothing through the hole of the pirate, got %q", id)
+	}
+
+	// The frame is not decoded again while the game stays in the location
+	pirate.Animations[string(model.IDLE_FACE_DOWN)][0] = []byte("not a png")
+	if id := game.CharacterAt(edge.X, edge.Y); id != "pirate-id" {
+		t.Errorf("expected the cached hit mask used, got %q", id)
+	}
+	if game.characterHits.Len() != 1 {
+		t.Errorf("expected one cached frame, got %d", game.characterHits.Len())
+	}
+}
@@ -0,0 +1,46 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"strings"
+)
+
+// HotspotAt returns the ID of the hotspot of the current location at a point of the location, or ""
+func (g *Game) HotspotAt(x, y int) string {
+	for _, hotspot := range g.state.currentLocation.GetHotspots() {
+		if hotspot.Contains(image.Pt(x, y)) {
+			return hotspot.ID
+		}
+	}
+	return ""
+}
+
+// hotspot returns the hotspot of the current location with the given ID
+func (g *Game) hotspot(id string) (model.Hotspot, bool) {
+	for _, hotspot := range g.state.currentLocation.GetHotspots() {
+		if hotspot.ID == id {
+			return hotspot, true
+		}
+	}
+	return model.Hotspot{}, false
+}
+
+// faceHotspot turns the current character to the hotspot an action is about (the second object wins)
+func (g *Game) faceHotspot(trigger string) {
+	parts := strings.Split(trigger, ".")
+	if len(parts) < 4 {
+		return
+	}
+	for _, object := range []string{parts[3], parts[2]} {
+		hotspot, exists := g.hotspot(object)
+		if !exists {
+			continue
+		}
+		if hotspot.Direction != "" {
+			g.SetCurrentCharacterDirection(hotspot.Direction)
+			g.StopCharacterMovementAnimation()
+		}
+		return
+	}
+}
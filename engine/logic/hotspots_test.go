@@ -0,0 +1,58 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"testing"
+	"time"
+)
+
+func TestHotspotsAreActionTargets(t *testing.T) {
+	game := NewGame()
+	game.AddCharacter(model.NewCharacter("guybrush-id", "Guybrush", model.Color{R: 255, G: 255, B: 255}))
+
+	// A window painted in the background, above the floor
+	location := model.NewLocation("porto-id", "Porto", nil)
+	location.AddWalkableArea(model.WalkableArea{Polygons: [][]image.Point{{{0, 250}, {600, 250}, {600, 380}, {0, 380}}}})
+	location.AddHotspot(model.Hotspot{
+		ID:               "window-id",
+		Name:             "Window",
+		Area:             []image.Point{{200, 80}, {280, 80}, {280, 160}, {200, 160}},
+		InteractionPoint: image.Pt(240, 260),
+		Direction:        model.UP,
+	})
+	game.AddLocation(location)
+	game.AddAction(model.NewAction("guybrush-id", model.LOOK_AT, "window-id", model.NOTHING, "porto-id", `game:SetFlag("SAW_THE_SEA", true)`, nil, nil, nil))
+
+	game.SetCurrentCharacter("Guybrush")
+	game.SetCurrentLocation("Porto")
+	game.SetCurrentCharacterPosition(image.Pt(500, 350))
+	game.Tick(nil)
+
+	camera := game.GetCamera()
+	window := image.Pt(240-int(camera.Position[0]), 120-int(camera.Position[1]))
+	if id := game.HotspotAt(240, 120); id != "window-id" {
+		t.Fatalf("expected the window at its center, got '%s'", id)
+	}
+
+	game.SelectVerb(model.LOOK_AT)
+	game.Tick([]InputEvent{{Type: CURSOR_MOVED, X: window.X, Y: window.Y}})
+	if sentence := game.SentenceLine(); sentence != "Look at Window" {
+		t.Errorf("expected 'Look at Window', got '%s'", sentence)
+	}
+
+	game.Tick([]InputEvent{{Type: LEFT_CLICK, X: window.X, Y: window.Y}})
+	for elapsed := time.Duration(0); elapsed < time.Minute && !game.GetFlag("SAW_THE_SEA"); elapsed += TickDuration {
+		game.Tick(nil)
+	}
+
+	if !game.GetFlag("SAW_THE_SEA") {
+		t.Fatalf("expected the LOOK_AT action on the window executed")
+	}
+	if position := game.GetCurrentCharacterPosition(); position != image.Pt(240, 260) {
+		t.Errorf("expected the character at the interaction point, got %v", position)
+	}
+	if direction := game.GetCurrentCharacterDirection(); direction != model.UP {
+		t.Errorf("expected the character facing the window, got %s", direction)
+	}
+}
@@ -0,0 +1,157 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"math"
+	"time"
+)
+
+// TicksPerSecond is how many times per second the game is updated
+const TicksPerSecond = 60
+
+// TickDuration is the game time every Tick advances, whatever the real time elapsed
+const TickDuration = time.Second / TicksPerSecond
+
+type InputEventType string
+
+const (
+	CURSOR_MOVED   InputEventType = "CURSOR_MOVED"
+	LEFT_CLICK     InputEventType = "LEFT_CLICK"
+	RIGHT_CLICK    InputEventType = "RIGHT_CLICK"
+	WHEEL_SCROLLED InputEventType = "WHEEL_SCROLLED"
+	SKIP_TEXT      InputEventType = "SKIP_TEXT"
+)
+
+// InputEvent is an input of the player, independent of the device it comes from.
+// X and Y are the screen position of the cursor when the event happened.
+type InputEvent struct {
+	Type  InputEventType `json:"type"`
+	X     int            `json:"x"`
+	Y     int            `json:"y"`
+	Delta float64        `json:"delta,omitempty"` // Wheel rotation, positive when scrolling up
+}
+
+// Tick advances the game by TickDuration, handling the input received since the previous tick.
+// It has no dependency on a display, so the game can be simulated headless (e.g. in tests).
+func (g *Game) Tick(events []InputEvent) {
+	g.state.clock += TickDuration
+
+	g.updateTextToDrawTimer() // Estrai la logica del timer del testo
+
+	defer g.state.CalculateYOrderedEntities()
+
+	// Gestisci l'input prima di aggiornare lo stato
+	for _, event := range events {
+		g.handleInputEvent(event)
+	}
+	g.updateHover()
+
+	// Anima i personaggi e gli oggetti presenti nella stanza, chi parla muove la bocca
+	g.updateTalkAnimations()
+	g.updateAnimations()
+
+	// Riprendi gli script in attesa (cutscene)
+	g.updateScripts()
+
+	// Aggiorna lo stato in base allo stato corrente
+	switch g.GetCurrentState() {
+	case model.EXECUTING_ACTION:
+		g.updateExecutingActionState()
+	case model.IDLE:
+		g.updateIdleState()
+	case model.WAITING_ACTION:
+		g.updateWaitingActionState()
+	case model.IN_DIALOGUE:
+		g.updateDialogueState()
+		// Aggiungi altri stati se necessario
+	}
+
+	// Il personaggio che entra in un'uscita cambia location
+	g.checkExits()
+
+	// La sentence line torna a seguire il cursore quando l'azione è stata eseguita
+	if g.GetCurrentState() != model.EXECUTING_ACTION {
+		g.state.committedSentence = ""
+	}
+
+	// Il cursore cambia in base al verbo e a cosa c'è sotto
+	g.updateCursor()
+
+	// Aggiorna la posizione della camera (potrebbe essere estratta)
+	g.updateCameraPosition()
+}
+
+// now returns the game time, i.e. the number of ticks elapsed times TickDuration
+func (g *Game) now() time.Duration {
+	return g.state.clock
+}
+
+// TickCount returns the number of ticks elapsed since the game started
+func (g *Game) TickCount() int {
+	return int(g.state.clock / TickDuration)
+}
+
+// CursorPosition returns the screen position of the cursor as of the last input event
+func (g *Game) CursorPosition() image.Point {
+	return g.state.cursorPosition
+}
+
+func (g *Game) handleInputEvent(event InputEvent) {
+	g.state.cursorPosition = image.Pt(event.X, event.Y)
+	g.updateHover()
+
+	// Un click mentre qualcuno parla salta la battuta, anche durante una cutscene
+	if _, talking := g.Speech(); talking && (event.Type == LEFT_CLICK || event.Type == SKIP_TEXT) {
+		g.SkipSpeech()
+		return
+	}
+
+	// Durante una cutscene il giocatore non può interagire
+	if g.IsScriptRunning() {
+		return
+	}
+
+	overInterface := g.IsOverInterface(event.X, event.Y)
+	switch event.Type {
+	case LEFT_CLICK:
+		if overInterface {
+			g.handleInterfaceClick(event.X, event.Y)
+		} else {
+			g.handleLeftClick() // Estrai la logica del click sinistro
+		}
+	case RIGHT_CLICK:
+		g.handleRightClick() // Estrai la logica del click destro
+	case WHEEL_SCROLLED:
+		if event.Delta != 0 && overInterface && image.Pt(event.X, event.Y).In(g.data.Interface.Inventory) {
+			g.ScrollInventory(-int(math.Copysign(1, event.Delta)))
+		}
+	}
+}
+
+// updateHover finds what is under the cursor: an inventory item over the interface, otherwise a character, an item or a hotspot of the room
+func (g *Game) updateHover() {
+	screenX, screenY := g.state.cursorPosition.X, g.state.cursorPosition.Y
+
+	// L'interfaccia dei verbi copre la parte bassa dello schermo
+	if g.IsOverInterface(screenX, screenY) {
+		g.state.cursorOnItem = ""
+		g.state.cursorOnExit = false
+		g.state.cursorOnInventory = g.inventoryItemAt(screenX, screenY)
+		return
+	}
+
+	cursorX, cursorY := g.state.camera.ScreenToWorld(screenX, screenY)
+
+	// I personaggi nella stanza stanno davanti agli oggetti
+	g.state.cursorOnItem = g.CharacterAt(int(cursorX), int(cursorY))
+	if g.state.cursorOnItem == "" {
+		g.state.cursorOnItem = g.ItemAt(int(cursorX), int(cursorY))
+	}
+	// Gli hotspot sono dipinti nello sfondo, dietro a tutto
+	if g.state.cursorOnItem == "" {
+		g.state.cursorOnItem = g.HotspotAt(int(cursorX), int(cursorY))
+	}
+	g.state.cursorOnExit = g.state.cursorOnItem == "" && g.exitIndexAt(int(cursorX), int(cursorY)) >= 0
+	g.state.cursorOnInventory = ""
+}
@@ -0,0 +1,75 @@
+package logic
+
+import (
+	"bytes"
+	"chemistry/engine/model"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func testImage(t *testing.T, width int, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestHeadlessClickWalksAndPicksUp(t *testing.T) {
+	game := newPortoTestGame(testImage(t, ScreenWidth, ScreenHeight), func(game *Game, porto *model.Location) {
+		game.AddItem(model.NewItem("key-id", "Key", false, true, testImage(t, 10, 10)))
+		porto.AddItem("key-id", model.ItemLocation{LocationPoint: image.Pt(300, 250), InteractionPoint: image.Pt(305, 300)})
+	})
+	game.Tick(nil)
+
+	camera := game.GetCamera()
+	toScreen := func(x int, y int) (int, int) {
+		return x - int(camera.Position[0]), y - int(camera.Position[1])
+	}
+
+	game.SelectVerb(model.PICK_UP)
+	x, y := toScreen(305, 255)
+	game.Tick([]InputEvent{{Type: LEFT_CLICK, X: x, Y: y}})
+	if game.GetCurrentState() != model.EXECUTING_ACTION {
+		t.Fatalf("expected the character walking to the key, got state %s", game.GetCurrentState())
+	}
+
+	for i := 0; i < 10*TicksPerSecond && game.GetCurrentState() == model.EXECUTING_ACTION; i++ {
+		game.Tick(nil)
+	}
+
+	if position := game.GetCurrentCharacterPosition(); position != image.Pt(305, 300) {
+		t.Errorf("expected the character at the interaction point, got %v", position)
+	}
+	if _, picked := game.GetCharacter("Guybrush").Inventory["key-id"]; !picked {
+		t.Errorf("expected the key picked up")
+	}
+}
+
+func TestTextTimerFollowsGameTime(t *testing.T) {
+	game := NewGame()
+	game.SaySomething("Hello")
+	game.Tick(nil)
+
+	for i := 0; i < 2*TicksPerSecond; i++ {
+		game.Tick(nil)
+	}
+	if game.TextToDraw() != "Hello" {
+		t.Fatalf("expected the line still shown after 2 seconds of game time")
+	}
+
+	for i := 0; i < TicksPerSecond; i++ {
+		game.Tick(nil)
+	}
+	if game.TextToDraw() != "" {
+		t.Errorf("expected the line gone after 3 seconds of game time, got '%s'", game.TextToDraw())
+	}
+}
@@ -0,0 +1,182 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"sort"
+)
+
+const (
+	inventorySlotSize    = 48
+	inventorySlotSpacing = 8
+	inventoryArrowWidth  = 32
+)
+
+// InventoryItems returns the IDs of the items held by the current character, ordered by name
+func (g *Game) InventoryItems() []string {
+	inventory := g.state.currentCharacter.Inventory
+	ids := make([]string, 0, len(inventory))
+	for id := range inventory {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		nameI, nameJ := g.GetItem(ids[i]).Name, g.GetItem(ids[j]).Name
+		if nameI != nameJ {
+			return nameI < nameJ
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+func (g *Game) InventoryScrollBackRect() image.Rectangle {
+	panel := g.data.Interface.Inventory
+	return image.Rect(panel.Min.X, panel.Min.Y, panel.Min.X+inventoryArrowWidth, panel.Max.Y)
+}
+
+func (g *Game) InventoryScrollForwardRect() image.Rectangle {
+	panel := g.data.Interface.Inventory
+	return image.Rect(panel.Max.X-inventoryArrowWidth, panel.Min.Y, panel.Max.X, panel.Max.Y)
+}
+
+// inventoryGrid returns how many slot columns and rows fit in the inventory area
+func (g *Game) inventoryGrid() (int, int) {
+	panel := g.data.Interface.Inventory
+	columns := (panel.Dx() - 2*inventoryArrowWidth - inventorySlotSpacing) / (inventorySlotSize + inventorySlotSpacing)
+	rows := (panel.Dy() - inventorySlotSpacing) / (inventorySlotSize + inventorySlotSpacing)
+	return max(columns, 0), max(rows, 0)
+}
+
+func (g *Game) InventoryVisibleSlots() int {
+	columns, rows := g.inventoryGrid()
+	return columns * rows
+}
+
+// InventorySlotRects returns the screen area of every visible inventory slot, row by row
+func (g *Game) InventorySlotRects() []image.Rectangle {
+	panel := g.data.Interface.Inventory
+	columns, rows := g.inventoryGrid()
+	step := inventorySlotSize + inventorySlotSpacing
+	top := panel.Min.Y + (panel.Dy()-rows*step+inventorySlotSpacing)/2
+
+	rects := make([]image.Rectangle, 0, columns*rows)
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			x := panel.Min.X + inventoryArrowWidth + inventorySlotSpacing + column*step
+			y := top + row*step
+			rects = append(rects, image.Rect(x, y, x+inventorySlotSize, y+inventorySlotSize))
+		}
+	}
+	return rects
+}
+
+// InventoryScroll returns the index of the first inventory item shown
+func (g *Game) InventoryScroll() int {
+	return g.state.inventoryScroll
+}
+
+// ScrollInventory moves the visible inventory window by delta rows
+func (g *Game) ScrollInventory(delta int) {
+	columns, _ := g.inventoryGrid()
+	if columns == 0 {
+		return
+	}
+
+	items := len(g.InventoryItems())
+	maxScroll := (items+columns-1)/columns*columns - g.InventoryVisibleSlots()
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+
+	g.state.inventoryScroll += delta * columns
+	if g.state.inventoryScroll > maxScroll {
+		g.state.inventoryScroll = maxScroll
+	}
+	if g.state.inventoryScroll < 0 {
+		g.state.inventoryScroll = 0
+	}
+}
+
+// inventoryItemAt returns the inventory item under the given screen position, or ""
+func (g *Game) inventoryItemAt(x int, y int) string {
+	if !g.IsInterfaceVisible() {
+		return ""
+	}
+
+	items := g.InventoryItems()
+	for i, rect := range g.InventorySlotRects() {
+		index := g.state.inventoryScroll + i
+		if index >= len(items) {
+			break
+		}
+		if image.Pt(x, y).In(rect) {
+			return items[index]
+		}
+	}
+	return ""
+}
+
+// handleInventoryClick handles a left click on the inventory panel
+func (g *Game) handleInventoryClick(x int, y int) {
+	point := image.Pt(x, y)
+	switch {
+	case point.In(g.InventoryScrollBackRect()):
+		g.ScrollInventory(-1)
+	case point.In(g.InventoryScrollForwardRect()):
+		g.ScrollInventory(1)
+	default:
+		itemID := g.inventoryItemAt(x, y)
+		if itemID != "" {
+			g.selectInventoryItem(itemID)
+		}
+	}
+}
+
+// selectInventoryItem uses an inventory item as main or second object of the current verb.
+// Inventory items are always at hand, the character only walks when the other object is in the room.
+func (g *Game) selectInventoryItem(itemID string) {
+	verb := g.GetCurrentVerb()
+
+	switch g.GetCurrentState() {
+	case model.WAITING_ACTION:
+		if itemID == g.state.mainItemID {
+			return
+		}
+		g.state.secondItemID = itemID
+		trigger := composeTrigger(g.state.currentCharacter.ID, verb, g.state.mainItemID, itemID, g.state.currentLocation.ID)
+		g.carryOutSentence(trigger, g.state.mainItemID, itemID)
+
+	case model.IDLE, model.EXECUTING_ACTION:
+		item := g.GetItem(itemID)
+		switch {
+		case verb == model.MOVE_TO:
+			return
+		case (verb == model.USE && item.UseWith) || verb == model.GIVE_TO:
+			g.stopCurrentActions()
+			g.state.mainItemID = itemID
+			g.SetCurrentState(model.WAITING_ACTION)
+		default:
+			trigger := composeTrigger(g.state.currentCharacter.ID, verb, itemID, model.NOTHING, g.state.currentLocation.ID)
+			g.carryOutSentence(trigger, itemID)
+		}
+	}
+}
+
+// InventoryImage returns the image shown in the inventory for an item, loading it on-demand
+func (g *Game) InventoryImage(itemID string) []byte {
+	item := g.GetItem(itemID)
+	if len(item.InventoryImage) == 0 && g.packagedData != nil {
+		g.LoadItemInventoryImage(itemID)
+		item = g.GetItem(itemID)
+	}
+	if len(item.InventoryImage) > 0 {
+		return item.InventoryImage
+	}
+
+	// Fallback to the sprite used in the locations
+	if len(item.Image) == 0 && g.packagedData != nil {
+		g.LoadItemSprite(itemID)
+		item = g.GetItem(itemID)
+	}
+	return item.Image
+}
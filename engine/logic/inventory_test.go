@@ -0,0 +1,128 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"fmt"
+	"image"
+	"testing"
+)
+
+// newInventoryTestGame returns the characters test game with Guybrush holding count items,
+// named so that they are listed in the order they were given
+func newInventoryTestGame(count int) *Game {
+	game := newCharactersTestGame()
+	inventory := game.GetCharacter("Guybrush").Inventory
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("item-%02d", i)
+		game.AddItem(model.NewItem(id, fmt.Sprintf("Item %02d", i), i == 0, true, nil))
+		inventory[id] = model.InventorySlot{Count: 1, Item: game.GetItem(id)}
+	}
+	return game
+}
+
+func center(rect image.Rectangle) image.Point {
+	return rect.Min.Add(rect.Size().Div(2))
+}
+
+func TestInventorySlotsHitTheItemsShown(t *testing.T) {
+	game := newInventoryTestGame(3)
+
+	slots := game.InventorySlotRects()
+	if len(slots) != game.InventoryVisibleSlots() || len(slots) < 4 {
+		t.Fatalf("expected at least 4 visible slots, got %d", len(slots))
+	}
+	for i, slot := range slots {
+		if !slot.In(game.data.Interface.Inventory) {
+			t.Errorf("expected slot %d %v inside the inventory panel", i, slot)
+		}
+		if slot.Overlaps(game.InventoryScrollBackRect()) || slot.Overlaps(game.InventoryScrollForwardRect()) {
+			t.Errorf("expected slot %d %v clear of the scroll arrows", i, slot)
+		}
+	}
+
+	for i, want := range []string{"item-00", "item-01", "item-02", ""} {
+		point := center(slots[i])
+		if got := game.inventoryItemAt(point.X, point.Y); got != want {
+			t.Errorf("slot %d: expected %q, got %q", i, want, got)
+		}
+	}
+	// The spacing between two slots belongs to neither
+	if got := game.inventoryItemAt(slots[0].Max.X+1, center(slots[0]).Y); got != "" {
+		t.Errorf("expected no item between the slots, got %q", got)
+	}
+}
+
+func TestScrollInventoryIsClampedAtBothEnds(t *testing.T) {
+	empty := newInventoryTestGame(0)
+	columns, _ := empty.inventoryGrid()
+	// One item more than a full row past the visible slots: two rows to scroll
+	game := newInventoryTestGame(empty.InventoryVisibleSlots() + columns + 1)
+
+	game.ScrollInventory(-1)
+	if scroll := game.InventoryScroll(); scroll != 0 {
+		t.Errorf("expected no scrolling back from the first row, got %d", scroll)
+	}
+
+	game.ScrollInventory(1)
+	if scroll := game.InventoryScroll(); scroll != columns {
+		t.Errorf("expected the second row first, got %d", scroll)
+	}
+	game.ScrollInventory(5)
+	if scroll := game.InventoryScroll(); scroll != 2*columns {
+		t.Errorf("expected the scrolling to stop at the last row, got %d", scroll)
+	}
+	point := center(game.InventorySlotRects()[0])
+	if got, want := game.inventoryItemAt(point.X, point.Y), fmt.Sprintf("item-%02d", 2*columns); got != want {
+		t.Errorf("expected %q in the first slot after scrolling, got %q", want, got)
+	}
+
+	game.ScrollInventory(-5)
+	if scroll := game.InventoryScroll(); scroll != 0 {
+		t.Errorf("expected the scrolling to stop at the first row, got %d", scroll)
+	}
+}
+
+func TestClickingAnInventoryItemMakesItTheObjectOfTheVerb(t *testing.T) {
+	game := newInventoryTestGame(2)
+	game.AddAction(model.NewAction("guybrush-id", model.USE, "item-00", "item-01", "porto-id", `game:SetFlag("USED_ITEMS", true)`, nil, nil, nil))
+	slots := game.InventorySlotRects()
+
+	// item-00 is used with something else: the sentence waits for the second object
+	game.SelectVerb(model.USE)
+	first := center(slots[0])
+	game.handleInventoryClick(first.X, first.Y)
+	if game.GetCurrentState() != model.WAITING_ACTION || game.state.mainItemID != "item-00" {
+		t.Fatalf("expected item-00 as the object of Use, got state %s with %q", game.GetCurrentState(), game.state.mainItemID)
+	}
+
+	second := center(slots[1])
+	game.handleInventoryClick(second.X, second.Y)
+	for i := 0; i < TicksPerSecond && !game.GetFlag("USED_ITEMS"); i++ {
+		game.Tick(nil)
+	}
+	if !game.GetFlag("USED_ITEMS") {
+		t.Errorf("expected item-00 used with item-01")
+	}
+}
+
+func TestWheelScrollsTheInventoryOnlyOverIt(t *testing.T) {
+	empty := newInventoryTestGame(0)
+	columns, _ := empty.inventoryGrid()
+	game := newInventoryTestGame(empty.InventoryVisibleSlots() + 1)
+	inventory := center(game.data.Interface.Inventory)
+
+	// Scrolling down over the room does not move the inventory
+	game.Tick([]InputEvent{{Type: WHEEL_SCROLLED, X: 100, Y: 100, Delta: -1}})
+	if scroll := game.InventoryScroll(); scroll != 0 {
+		t.Errorf("expected no scrolling over the room, got %d", scroll)
+	}
+
+	game.Tick([]InputEvent{{Type: WHEEL_SCROLLED, X: inventory.X, Y: inventory.Y, Delta: -1}})
+	if scroll := game.InventoryScroll(); scroll != columns {
+		t.Errorf("expected scrolling down to show the next row, got %d", scroll)
+	}
+	game.Tick([]InputEvent{{Type: WHEEL_SCROLLED, X: inventory.X, Y: inventory.Y, Delta: 1}})
+	if scroll := game.InventoryScroll(); scroll != 0 {
+		t.Errorf("expected scrolling up to show the first row, got %d", scroll)
+	}
+}
@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log"
+	"sort"
+
+	"golang.org/x/image/math/f64"
+)
+
+// Layers returns the layers of the current location in drawing order, i.e. by ZOrder.
+// Layers with the same ZOrder keep the order they were added in.
+func (g *Game) Layers() []model.Layer {
+	layers := append([]model.Layer(nil), g.state.currentLocation.GetLayers()...)
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i].ZOrder < layers[j].ZOrder
+	})
+	return layers
+}
+
+// LayerOffset returns where a layer is drawn in the world so that, seen through the camera,
+// it scrolls Parallax times as fast as the room
+func (g *Game) LayerOffset(layer model.Layer) f64.Vec2 {
+	camera := g.state.camera
+	return f64.Vec2{
+		camera.Position[0] * (1 - layer.Parallax),
+		camera.Position[1] * (1 - layer.Parallax),
+	}
+}
+
+// KeyTransparentColor returns img with the pixels of the transparent color (#RRGGBB) made transparent.
+// img is returned as it is when there is no transparent color.
+func KeyTransparentColor(img image.Image, transparentColor string) image.Image {
+	if transparentColor == "" {
+		return img
+	}
+	key, err := parseHexColor(transparentColor)
+	if err != nil {
+		log.Printf("Warning: invalid transparent color '%s': %v", transparentColor, err)
+		return img
+	}
+
+	bounds := img.Bounds()
+	keyed := image.NewNRGBA(bounds)
+	draw.Draw(keyed, bounds, img, bounds.Min, draw.Src)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixel := keyed.NRGBAAt(x, y)
+			if pixel.R == key.R && pixel.G == key.G && pixel.B == key.B {
+				keyed.SetNRGBA(x, y, color.NRGBA{})
+			}
+		}
+	}
+	return keyed
+}
+
+// parseHexColor parses a color in the #RRGGBB format of the editor
+func parseHexColor(hex string) (color.NRGBA, error) {
+	var c color.NRGBA
+	if len(hex) != 7 || hex[0] != '#' {
+		return c, fmt.Errorf("expected #RRGGBB")
+	}
+	if _, err := fmt.Sscanf(hex[1:], "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, err
+	}
+	c.A = 255
+	return c, nil
+}
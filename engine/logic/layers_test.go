@@ -0,0 +1,52 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/math/f64"
+)
+
+func TestLayersAreOrderedAndScrollWithParallax(t *testing.T) {
+	game := NewGame()
+	location := model.NewLocation("porto-id", "Porto", testImage(t, 1200, 540))
+	location.AddLayer(model.Layer{ZOrder: 1, Parallax: 1.5, TransparentColor: "#ff00ff"})
+	location.AddLayer(model.Layer{ZOrder: -1, Parallax: 0})
+	game.AddLocation(location)
+	game.SetCurrentLocation("Porto")
+	game.state.camera.Position = f64.Vec2{100, 0}
+
+	layers := game.Layers()
+	if len(layers) != 3 || layers[0].ZOrder != -1 || layers[1].ZOrder != 0 || layers[2].ZOrder != 1 {
+		t.Fatalf("expected the layers ordered by z-order, got %+v", layers)
+	}
+	if layers[1].InFrontOfActors() || !layers[2].InFrontOfActors() {
+		t.Errorf("expected only the last layer in front of the actors")
+	}
+
+	// Fixed on screen, with the room, faster than the room
+	for i, expected := range []float64{100, 0, -50} {
+		if offset := game.LayerOffset(layers[i]); offset[0] != expected {
+			t.Errorf("layer %d: expected offset %v, got %v", i, expected, offset[0])
+		}
+	}
+}
+
+func TestKeyTransparentColor(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{R: 255, B: 255, A: 255})
+	img.Set(1, 0, color.NRGBA{R: 255, G: 10, B: 255, A: 255})
+
+	keyed := KeyTransparentColor(img, "#FF00FF")
+	if _, _, _, a := keyed.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("expected the key color transparent")
+	}
+	if _, _, _, a := keyed.At(1, 0).RGBA(); a == 0 {
+		t.Errorf("expected other colors opaque")
+	}
+	if KeyTransparentColor(img, "") != image.Image(img) {
+		t.Errorf("expected the image unchanged without a transparent color")
+	}
+}
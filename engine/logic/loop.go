@@ -3,22 +3,16 @@ package logic
 import (
 	"bytes"
 	"chemistry/engine/model"
+	"crypto/sha256"
 	"encoding/gob"
+	"encoding/hex"
 	"fmt"
 	"image"
-	"image/color"
 	"log"
 	"math"
 	"os"
 	"strconv"
 	"strings"
-	"time"
-
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
-	"github.com/hajimehoshi/ebiten/v2/text/v2"
-	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 type Game struct {
@@ -26,6 +20,10 @@ type Game struct {
 	state           GameState
 	resourceManager *ResourceManager
 	packagedData    *PackagedGameData
+	saveDirectory   string
+	dataHash        string
+	onLeaveLocation []func(locationID string)
+	characterHits   *ResourceCache[characterHit]
 }
 
 func NewGame() Game {
@@ -42,14 +40,14 @@ func (g *Game) LoadGameData(filePath string) error {
 	}
 
 	// Legacy format handling
-	file, err := os.Open(filePath)
+	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("error opening game data file %s: %w", filePath, err)
 	}
-	defer file.Close()
+	g.dataHash = hashGameData(content)
 
 	var gameData model.PackagedGameData
-	decoder := gob.NewDecoder(file)
+	decoder := gob.NewDecoder(bytes.NewReader(content))
 	err = decoder.Decode(&gameData)
 	if err != nil {
 		return fmt.Errorf("error decoding game data from %s: %w", filePath, err)
@@ -65,152 +63,65 @@ func (g *Game) LoadGameData(filePath string) error {
 	return nil
 }
 
-func (g *Game) Update() error {
-
-	g.updateTextToDrawTimer() // Estrai la logica del timer del testo
-
-	defer g.state.CalculateYOrderedEntities()
-
-	// Gestisci l'input prima di aggiornare lo stato
-	err := g.handleInput()
-	if err != nil {
-		return err // Ad esempio, se viene premuto ESC
-	}
-
-	// Aggiorna lo stato in base allo stato corrente
-	switch g.GetCurrentState() {
-	case model.EXECUTING_ACTION:
-		g.updateExecutingActionState()
-	case model.IDLE:
-		g.updateIdleState()
-	case model.WAITING_ACTION:
-		g.updateWaitingActionState()
-		// Aggiungi altri stati se necessario
-	}
-
-	// Aggiorna la posizione della camera (potrebbe essere estratta)
-	g.updateCameraPosition()
-
-	return nil
+// DataHash identifies the loaded game data, e.g. to check an input recording is replayed on the same game
+func (g *Game) DataHash() string {
+	return g.dataHash
 }
 
-// Nuova funzione per gestire il timer del testo
-func (g *Game) updateTextToDrawTimer() {
-	if len(g.state.textToDraw) > 0 {
-		textDrawnElapsed := time.Since(g.state.textDrawn).Milliseconds()
-		if textDrawnElapsed >= 2500 {
-			if len(g.state.textToDraw) == 1 {
-				g.state.textToDraw = make([]string, 0)
-			} else {
-				g.state.textToDraw = g.state.textToDraw[1:]
-			}
-			g.state.textDrawn = time.Now()
-		}
-	} else {
-		g.state.textDrawn = time.Now()
-	}
-}
-
-// Nuova funzione per gestire l'input
-func (g *Game) handleInput() error {
-	cursorX, cursorY := g.state.camera.ScreenToWorld(ebiten.CursorPosition())
-	g.state.cursorOnItem = g.ItemAt(int(cursorX), int(cursorY))
-
-	switch {
-	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
-		g.handleLeftClick() // Estrai la logica del click sinistro
-	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight):
-		g.handleRightClick() // Estrai la logica del click destro
-	case ebiten.IsKeyPressed(ebiten.KeyEscape):
-		return ebiten.Termination // Gestisci l'uscita qui
-	}
-	return nil
+func hashGameData(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
 }
 
 // Nuova funzione per gestire il click sinistro (da popolare con la logica esistente)
 func (g *Game) handleLeftClick() {
 	switch g.GetCurrentState() {
-	case model.IDLE:
+	case model.IDLE, model.EXECUTING_ACTION:
 
 		if g.state.cursorOnItem != "" {
-
-			selectedItemId := g.state.cursorOnItem
-			item := g.GetItem(selectedItemId)
-			if item.UseWith && g.GetCurrentVerb() == model.USE {
-				g.state.mainItemID = selectedItemId
-				g.SetCurrentState(model.WAITING_ACTION)
-			} else {
-				trigger := composeTrigger(g.state.currentCharacter.ID, g.state.currentVerb, g.state.cursorOnItem, model.NOTHING, g.state.currentLocation.ID)
-				location := g.GetCurrentLocation()
-				if g.GetCurrentCharacterPosition().X == location.Items[item.ID].InteractionPoint.X && g.GetCurrentCharacterPosition().Y == location.Items[item.ID].InteractionPoint.Y {
-					g.ExecuteAction(trigger)
-				} else {
-					g.MoveTo(location.Items[item.ID].InteractionPoint.X, location.Items[item.ID].InteractionPoint.Y)
-					g.state.watingActions = append(g.state.watingActions, trigger)
-				}
-			}
+			g.selectRoomItem(g.state.cursorOnItem)
+			return
 		}
 
 		switch g.GetCurrentVerb() {
 		case model.MOVE_TO:
-			worldX, worldY := g.state.camera.ScreenToWorld(ebiten.CursorPosition())
+			worldX, worldY := g.state.camera.ScreenToWorld(g.state.cursorPosition.X, g.state.cursorPosition.Y)
 
-			g.MoveTo(int(worldX), int(worldY))
+			// The exit clicked is taken once the walk towards it is over
+			if g.MoveTo(int(worldX), int(worldY)) {
+				g.state.pendingExit = g.exitIndexAt(int(worldX), int(worldY))
+			}
 		default:
 			g.SetCurrentVerb(model.MOVE_TO)
 		}
 	case model.WAITING_ACTION:
 
 		if g.state.cursorOnItem != "" {
-
-			selectedItemId := g.state.cursorOnItem
-			g.state.secondItemID = selectedItemId
-
-			trigger := composeTrigger(g.state.currentCharacter.ID, g.state.currentVerb, g.state.cursorOnItem, selectedItemId, g.state.currentLocation.ID)
-			g.ExecuteAction(trigger)
-
-			g.state.mainItemID = ""
-			g.state.secondItemID = ""
-			g.SetCurrentState(model.IDLE)
-			g.SetCurrentVerb(model.MOVE_TO)
+			g.selectRoomItem(g.state.cursorOnItem)
 		}
 
-	case model.EXECUTING_ACTION:
-
-		switch g.GetCurrentVerb() {
-		case model.MOVE_TO:
-			worldX, worldY := g.state.camera.ScreenToWorld(ebiten.CursorPosition())
+	case model.IN_DIALOGUE:
 
-			g.MoveTo(int(worldX), int(worldY))
-		}
+		g.handleDialogueClick()
 
 	}
 }
 
-// Nuova funzione per gestire il click destro (da popolare con la logica esistente)
+// Il click destro annulla la frase in costruzione e torna a "Walk to"
 func (g *Game) handleRightClick() {
 
-	switch g.GetCurrentVerb() {
-	case model.MOVE_TO:
-		g.SetCurrentVerb(model.LOOK_AT)
-	case model.LOOK_AT:
-		g.SetCurrentVerb(model.PICK_UP)
-	case model.PICK_UP:
-		g.SetCurrentVerb(model.USE)
-	case model.USE:
-		g.SetCurrentVerb(model.TALK_TO)
-	case model.TALK_TO:
-		g.SetCurrentVerb(model.GIVE_TO)
-	case model.GIVE_TO:
-		g.SetCurrentVerb(model.MOVE_TO)
+	if g.GetCurrentState() == model.IN_DIALOGUE {
+		return
 	}
+
+	g.SelectVerb(model.MOVE_TO)
 }
 
 // Nuova funzione per aggiornare lo stato EXECUTING_ACTION
 func (g *Game) updateExecutingActionState() {
 
 	if len(g.state.watingActions) == 0 {
-		g.SetCurrentState(model.IDLE)
+		g.actionsDone()
 		return
 	}
 
@@ -222,13 +133,31 @@ func (g *Game) updateExecutingActionState() {
 		g.updateMoveToAction() // Estrai la logica di MOVE_TO
 	default:
 		// ... logica per altre azioni ...
-		g.ExecuteAction(g.state.watingActions[0])
-		if len(g.state.watingActions) > 1 {
-			g.state.watingActions = g.state.watingActions[1:]
-		} else {
-			g.SetCurrentState(model.IDLE)
-			g.state.watingActions = make([]string, 0)
-		}
+		g.executeQueuedAction()
+	}
+}
+
+// executeQueuedAction executes the first queued action. It is dequeued first
+// because its script may queue new actions, e.g. with game:WalkTo.
+func (g *Game) executeQueuedAction() {
+	trigger := g.state.watingActions[0]
+	g.state.watingActions = g.state.watingActions[1:]
+	g.faceHotspot(trigger)
+	g.ExecuteAction(trigger)
+
+	// The action may have started a dialogue
+	if len(g.state.watingActions) == 0 && g.GetCurrentState() == model.EXECUTING_ACTION {
+		g.actionsDone()
+	}
+}
+
+// actionsDone goes back to IDLE, or to the dialogue a script walked away from
+func (g *Game) actionsDone() {
+	g.state.watingActions = make([]string, 0)
+	if g.IsInDialogue() {
+		g.SetCurrentState(model.IN_DIALOGUE)
+	} else {
+		g.SetCurrentState(model.IDLE)
 	}
 }
 
@@ -236,7 +165,7 @@ func (g *Game) updateExecutingActionState() {
 func (g *Game) updateMoveToAction() {
 	if len(g.state.watingActions) == 0 {
 		// Should not happen if called from updateExecutingActionState, but good practice
-		g.SetCurrentState(model.IDLE)
+		g.actionsDone()
 		return
 	}
 
@@ -245,13 +174,7 @@ func (g *Game) updateMoveToAction() {
 
 	if triggerActionParts[3] == model.NOTHING {
 		// Handle MOVE_TO NOTHING (e.g., interacting with an object without moving)
-		g.ExecuteAction(g.state.watingActions[0])
-		if len(g.state.watingActions) > 1 {
-			g.state.watingActions = g.state.watingActions[1:]
-		} else {
-			g.SetCurrentState(model.IDLE)
-			g.state.watingActions = make([]string, 0)
-		}
+		g.executeQueuedAction()
 		return
 	}
 
@@ -270,8 +193,7 @@ func (g *Game) updateMoveToAction() {
 		log.Printf("Error converting coordinates in trigger: %s. Errors: %v, %v, %v, %v", triggerAction, errX1, errY1, errX2, errY2)
 		// Decide how to handle the error: skip action, stop game, etc.
 		// For now, let's skip this action and go idle.
-		g.state.watingActions = make([]string, 0)
-		g.SetCurrentState(model.IDLE)
+		g.actionsDone()
 		return
 	}
 
@@ -295,9 +217,8 @@ func (g *Game) updateMoveToAction() {
 			}
 		} else {
 			// No more actions, stop moving animation and go idle
-			g.state.watingActions = make([]string, 0)
 			g.StopCharacterMovementAnimation() // Use a helper function
-			g.SetCurrentState(model.IDLE)
+			g.actionsDone()
 		}
 		return // Finished processing this segment
 	}
@@ -325,20 +246,11 @@ func (g *Game) updateMoveToAction() {
 			targetAnimation = string(model.WALK_DOWN_TO_UP)
 		}
 
-		if g.GetCurrentCharacterDirection() != targetDirection {
-			g.SetCurrentCharacterDirection(targetDirection)
-			g.SetCurrentCharacterAnimationFrame(0) // Reset frame when changing direction
-		}
+		// The walk animation starts over only when the direction changes, its frames are advanced by updateAnimations
+		g.SetCurrentCharacterDirection(targetDirection)
 		g.SetCurrentCharacterAnimation(targetAnimation)
 	}
 
-	// Advance animation frame based on time
-	elapsed := time.Since(g.state.lastUpdated).Milliseconds()
-	if elapsed >= 96 { // Consider making this duration a constant or configurable
-		g.state.lastUpdated = time.Now()
-		g.AdvanceCurrentAnimationFrame()
-	}
-
 	// Calculate path points and move character
 	// Note: CalculateLine might be inefficient if called every frame.
 	// Consider calculating it once per segment if performance is an issue.
@@ -377,16 +289,48 @@ func (g *Game) updateMoveToAction() {
 
 // Helper function to set idle animation based on current direction
 func (g *Game) StopCharacterMovementAnimation() {
-	switch g.GetCurrentCharacterDirection() {
+	if idle := idleAnimation(g.GetCurrentCharacterDirection()); idle != "" {
+		g.SetCurrentCharacterAnimationAtFrame(idle, 0)
+	}
+}
+
+// idleAnimation returns the idle animation of a character facing direction
+func idleAnimation(direction model.CharacterDirection) string {
+	switch direction {
+	case model.DOWN:
+		return string(model.IDLE_FACE_DOWN)
+	case model.RIGHT:
+		return string(model.IDLE_FACE_RIGHT)
+	case model.LEFT:
+		return string(model.IDLE_FACE_LEFT)
+	case model.UP:
+		return string(model.IDLE_FACE_UP)
+	}
+	return ""
+}
+
+// talkAnimation returns the talk animation of a character facing direction
+func talkAnimation(direction model.CharacterDirection) string {
+	switch direction {
 	case model.DOWN:
-		g.SetCurrentCharacterAnimationAtFrame(string(model.IDLE_FACE_DOWN), 0)
+		return string(model.TALK_UP_TO_DOWN)
 	case model.RIGHT:
-		g.SetCurrentCharacterAnimationAtFrame(string(model.IDLE_FACE_RIGHT), 0)
+		return string(model.TALK_LEFT_TO_RIGHT)
 	case model.LEFT:
-		g.SetCurrentCharacterAnimationAtFrame(string(model.IDLE_FACE_LEFT), 0)
+		return string(model.TALK_RIGHT_TO_LEFT)
 	case model.UP:
-		g.SetCurrentCharacterAnimationAtFrame(string(model.IDLE_FACE_UP), 0)
+		return string(model.TALK_DOWN_TO_UP)
 	}
+	return ""
+}
+
+// isTalkAnimation reports whether an animation is one of the talk animations
+func isTalkAnimation(name string) bool {
+	switch model.AnimationTypes(name) {
+	case model.TALK_UP_TO_DOWN, model.TALK_LEFT_TO_RIGHT, model.TALK_RIGHT_TO_LEFT, model.TALK_DOWN_TO_UP:
+		return true
+	}
+	return false
 }
 
 // Nuova funzione per aggiornare lo stato IDLE
@@ -405,10 +349,11 @@ func (g *Game) updateWaitingActionState() {
 
 // Nuova funzione per aggiornare la posizione della camera
 func (g *Game) updateCameraPosition() {
-	targetCameraX := float64(g.state.currentCharacterPosition.X - (screenWidth / 2))
+	position := g.GetCurrentCharacterPosition()
+	targetCameraX := float64(position.X - (ScreenWidth / 2))
 
 	// Clamp camera position to background bounds
-	maxCameraX := float64(g.state.currentBackGround.Bounds().Dx() - screenWidth)
+	maxCameraX := float64(g.state.backgroundSize.X - ScreenWidth)
 	if targetCameraX < 0 {
 		targetCameraX = 0
 	} else if targetCameraX > maxCameraX {
@@ -418,10 +363,12 @@ func (g *Game) updateCameraPosition() {
 	g.state.camera.Position[0] = targetCameraX
 
 	// Implement vertical camera scrolling
-	targetCameraY := float64(g.state.currentCharacterPosition.Y - (screenHeight / 2))
+	// La stanza è visibile solo sopra l'interfaccia dei verbi
+	roomHeight := g.RoomViewHeight()
+	targetCameraY := float64(position.Y - (roomHeight / 2))
 
 	// Clamp camera position to background bounds
-	maxCameraY := float64(g.state.currentBackGround.Bounds().Dy() - screenHeight)
+	maxCameraY := float64(g.state.backgroundSize.Y - roomHeight)
 	if targetCameraY < 0 {
 		targetCameraY = 0
 	} else if targetCameraY > maxCameraY {
@@ -431,320 +378,58 @@ func (g *Game) updateCameraPosition() {
 	g.state.camera.Position[1] = targetCameraY
 }
 
-func (g *Game) Draw(screen *ebiten.Image) {
-
-	// Disegna lo sfondo della location corrente (se presente)
-	g.drawBackground(g.state.world, g.GetCurrentLocation())
-
-	currentCharacter := g.GetCurrentCharacter()
-
-	// Disegna tutte le entità nell'ordine calcolato
-	for _, entityID := range g.state.yOrderedEntities {
-		if entityID == currentCharacter.ID {
-			// Disegna il personaggio del giocatore
-			g.drawCharacter(g.state.world, currentCharacter)
-		} else {
-			// Disegna l'oggetto
-			item := g.data.Items[entityID]
-			itemLocation := g.GetCurrentLocation().Items[entityID]
-			g.drawItem(g.state.world, item, itemLocation)
-		}
+// Font returns the data of a font, loading it on-demand
+func (g *Game) Font(name string) []byte {
+	if g.data.Fonts[name] == nil && g.packagedData != nil {
+		g.LoadFont(name)
 	}
-
-	// Scrive eventuali code che il personaggio deve dire
-	g.drawText(g.state.world, currentCharacter)
-
-	// Disegna l'interfaccia utente e le informazioni di debug
-	g.drawUI(g.state.world)
-
-	g.drawCursor(g.state.world)
-
-	g.state.camera.Render(g.state.world, screen)
+	return g.data.Fonts[name]
 }
 
-func (g *Game) drawText(screen *ebiten.Image, currentCharacter model.Character) {
-
-	if len(g.state.textToDraw) > 0 {
-		// Load fonts on-demand if not loaded
-		if g.data.Fonts["MonkeyIsland"] == nil && g.packagedData != nil {
-			g.LoadFont("MonkeyIsland")
-		}
-		if g.data.Fonts["MonkeyIslandOutline"] == nil && g.packagedData != nil {
-			g.LoadFont("MonkeyIslandOutline")
-		}
-
-		standard, err := text.NewGoTextFaceSource(bytes.NewReader(g.data.Fonts["MonkeyIsland"]))
-		if err != nil {
-			log.Fatal(err)
-		}
-		fontFaceSource := standard
-
-		outline, err := text.NewGoTextFaceSource(bytes.NewReader(g.data.Fonts["MonkeyIslandOutline"]))
-		if err != nil {
-			log.Fatal(err)
-		}
-		fontFaceOutlineSource := outline
-
-		talkColor := color.RGBA{
-			R: currentCharacter.TalkColor.R,
-			G: currentCharacter.TalkColor.G,
-			B: currentCharacter.TalkColor.B,
-			A: 255,
-		}
-
-		animation, frame := g.GetCurrentCharacterAnimation()
-		characterFrameImage := g.GetCurrentCharacter().Animations[animation][frame]
-
-		_, spriteHeight := g.GetSpriteDimensions(characterFrameImage)
-		textToDisplay := g.state.textToDraw[0]
-		fontSize := 24.0 // Assuming a fixed font size, adjust if dynamic
-
-		// Create a face for measuring text
-		goTextFace := &text.GoTextFace{
-			Source: fontFaceSource, // or fontFaceOutlineSource, assuming they have similar metrics
-			Size:   fontSize,
-		}
-		textAdvance, textHeight := text.Measure(textToDisplay, goTextFace, goTextFace.Size*2) // Using Size*2 for line height, adjust if needed
-		textWidth := float64(textAdvance)
-
-		// Calculate initial desired position (above character, centered)
-		charScaledHeight := float64(spriteHeight) * 2.5 // Assuming character is scaled by 2.5 as in drawCharacter
-
-		// Calculate the desired text center X and top Y in world coordinates
-		worldTextCenterX := float64(g.state.currentCharacterPosition.X)
-		worldTextTopY := float64(g.state.currentCharacterPosition.Y) - charScaledHeight - textHeight - 20.0
-
-		// What are the visible world coordinates on screen?
-		margin := 5.0 // Small margin from screen edges
-		halfTextWidth := textWidth / 2.0
-
-		// Adjust worldTextCenterX so the text stays on screen
-		visibleWorldLeft := g.state.camera.Position[0] + margin
-		visibleWorldRight := g.state.camera.Position[0] + screenWidth - margin
-
-		if worldTextCenterX-halfTextWidth < visibleWorldLeft {
-			worldTextCenterX = visibleWorldLeft + halfTextWidth
-		} else if worldTextCenterX+halfTextWidth > visibleWorldRight {
-			worldTextCenterX = visibleWorldRight - halfTextWidth
-		}
-
-		// Adjust text's top Y
-		visibleWorldTopEdge := g.state.camera.Position[1] + margin
-		visibleWorldBottomEdge := g.state.camera.Position[1] + screenHeight - margin - textHeight // Space for text height
-
-		if worldTextTopY < visibleWorldTopEdge {
-			worldTextTopY = visibleWorldTopEdge
-		} else if worldTextTopY > visibleWorldBottomEdge { // worldTextTopY is already the top of the text
-			worldTextTopY = visibleWorldBottomEdge
-		}
-
-		opRegular := &text.DrawOptions{}
-		opRegular.GeoM.Translate(worldTextCenterX, worldTextTopY)
-		opRegular.ColorScale.ScaleWithColor(talkColor)
-		opRegular.PrimaryAlign = text.AlignCenter               // Horizontal alignment
-		opRegular.SecondaryAlign = text.AlignStart              // Vertical alignment (Y is top)
-		text.Draw(screen, textToDisplay, goTextFace, opRegular) // Draw onto the world image
-
-		opOutline := &text.DrawOptions{}
-		opOutline.GeoM.Translate(worldTextCenterX, worldTextTopY)
-		opOutline.ColorScale.ScaleWithColor(color.Black)
-		opOutline.PrimaryAlign = text.AlignCenter
-		opOutline.SecondaryAlign = text.AlignStart
-		text.Draw(screen, textToDisplay, &text.GoTextFace{ // Use a new face for outline if needed, or reuse
-			Source: fontFaceOutlineSource,
-			Size:   fontSize,
-		}, opOutline)
-	}
-
-}
-
-func (g *Game) drawBackground(screen *ebiten.Image, location model.Location) {
-
-	op := &ebiten.DrawImageOptions{}
-
-	screen.DrawImage(g.state.currentBackGround, op)
-
-	for _, polygon := range location.GetWalkableArea(0).Polygons {
-		for from, point := range polygon {
-			to := from + 1
-			if from == len(polygon)-1 {
-				to = 0
-			}
-			vector.StrokeLine(screen, float32(point.X), float32(point.Y), float32(polygon[to].X), float32(polygon[to].Y), 1, color.White, false)
-		}
-	}
-}
-
-func (g *Game) drawCharacter(screen *ebiten.Image, character model.Character) {
-	animation, frame := g.GetCurrentCharacterAnimation()
-
-	// Verifica che l'animazione esista
-	if _, exists := character.Animations[animation]; !exists {
-		log.Printf("Warning: Animation '%s' not found for character '%s'", animation, character.Name)
-		return
-	}
-
-	// Verifica che il frame esista
-	if frame >= len(character.Animations[animation]) || len(character.Animations[animation]) == 0 {
-		log.Printf("Warning: Frame %d out of range for animation '%s' (length: %d)", frame, animation, len(character.Animations[animation]))
-		return
-	}
-
-	characterFrameImage := character.Animations[animation][frame]
-
-	frameImage, _, err := image.Decode(bytes.NewReader(characterFrameImage))
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	img := ebiten.NewImageFromImage(frameImage)
-
-	refPoint := image.Point{
-		X: int(float32(img.Bounds().Dx())*2.5) / 2,
-		Y: int(float32(img.Bounds().Dy()) * 2.5),
-	}
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Scale(2.5, 2.5)
-	op.GeoM.Translate(float64(g.state.currentCharacterPosition.X-refPoint.X), float64(g.state.currentCharacterPosition.Y-refPoint.Y))
-	screen.DrawImage(img, op)
-}
-
-func (g *Game) drawItem(screen *ebiten.Image, item model.Item, itemLocation model.ItemLocation) {
-	// Load item sprite on-demand if not loaded
-	if len(item.Image) == 0 && g.packagedData != nil {
-		g.LoadItemSprite(item.ID)
-		item = g.GetItem(item.ID) // Refresh item data
+// ItemFrame returns the image an item of the current location is showing, loading its sprite on-demand
+func (g *Game) ItemFrame(itemID string) []byte {
+	item := g.loadedItem(itemID)
+	player := g.itemAnimation(item)
+	if player == nil {
+		return item.Image
 	}
 
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(itemLocation.LocationPoint.X), float64(itemLocation.LocationPoint.Y))
-
-	if len(item.Animations) > 0 {
-		// Determine which animation to play (e.g., "IDLE" or the first one)
-		var currentAnimationName string
-		for animName := range item.Animations {
-			currentAnimationName = animName // Get the first animation name
-			break
-		}
-
-		if currentAnimationName != "" {
-			animationFrames := item.Animations[currentAnimationName]
-			if len(animationFrames) > 0 {
-				// Simple animation timer/frame logic
-				// We need a way to store current frame index for each item instance.
-				// For now, using a global or a game state map.
-				// This is a simplified example and might need a more robust solution
-				// for managing animation states of multiple items.
-
-				// Placeholder: use game time to cycle frames.
-				// This will make all animated items animate in sync.
-				frameIndex := int(time.Now().UnixNano()/100000000) % len(animationFrames)
-				frameData := animationFrames[frameIndex]
-
-				if len(frameData) > 0 {
-					itemImage, _, err := image.Decode(bytes.NewReader(frameData))
-					if err != nil {
-						log.Printf("Error decoding item animation frame: %v", err)
-						// Draw placeholder if frame decode fails
-						//vector.DrawFilledCircle(screen, float32(itemLocation.LocationPoint.X)+10, float32(itemLocation.LocationPoint.Y)+10, 5, color.RGBA{255, 0, 255, 255}, false)
-						return
-					}
-					img := ebiten.NewImageFromImage(itemImage)
-					screen.DrawImage(img, op)
-				} else {
-					// Draw placeholder if frame data is empty
-					//vector.DrawFilledCircle(screen, float32(itemLocation.LocationPoint.X)+10, float32(itemLocation.LocationPoint.Y)+10, 5, color.RGBA{0, 0, 255, 255}, false)
-				}
-			} else {
-				// Draw placeholder if animation has no frames
-				//vector.DrawFilledCircle(screen, float32(itemLocation.LocationPoint.X)+10, float32(itemLocation.LocationPoint.Y)+10, 5, color.RGBA{0, 255, 0, 255}, false)
-			}
-		} else {
-			// Fallback to static image or placeholder if no animation name found (should not happen if map is not empty)
-			drawStaticItemImage(screen, item, itemLocation, op)
-		}
-	} else {
-		// Fallback to static image or placeholder if no animations defined
-		drawStaticItemImage(screen, item, itemLocation, op)
+	frames := item.Animations[player.Animation]
+	if player.AnimationFrame < 0 || player.AnimationFrame >= len(frames) {
+		return item.Image
 	}
-
-	// Keep drawing interaction point for debugging or gameplay
-	//vector.DrawFilledCircle(screen, float32(itemLocation.InteractionPoint.X), float32(itemLocation.InteractionPoint.Y), 2, color.RGBA{0, 255, 0, 255}, false)
+	return frames[player.AnimationFrame]
 }
 
-// Helper function to draw static item image or placeholder
-func drawStaticItemImage(screen *ebiten.Image, item model.Item, itemLocation model.ItemLocation, op *ebiten.DrawImageOptions) {
-	if len(item.Image) > 0 {
-		itemImage, _, err := image.Decode(bytes.NewReader(item.Image))
-		if err != nil {
-			log.Printf("Error decoding item static image: %v", err)
-			vector.DrawFilledCircle(screen, float32(itemLocation.LocationPoint.X)+10, float32(itemLocation.LocationPoint.Y)+10, 3, color.RGBA{255, 0, 0, 255}, false) // Placeholder on error
-			return
-		}
-		img := ebiten.NewImageFromImage(itemImage)
-		screen.DrawImage(img, op)
-	} else {
-		// Placeholder if no static image and no animations
-		vector.DrawFilledCircle(screen, float32(itemLocation.LocationPoint.X)+10, float32(itemLocation.LocationPoint.Y)+10, 3, color.RGBA{255, 0, 0, 255}, false)
+// loadedItem returns an item, loading its sprite and animations on-demand
+func (g *Game) loadedItem(itemID string) model.Item {
+	item := g.GetItem(itemID)
+	if len(item.Image) == 0 && len(item.Animations) == 0 && g.packagedData != nil {
+		g.LoadItemSprite(itemID)
+		item = g.GetItem(itemID) // Refresh item data
 	}
+	return item
 }
 
-func (g *Game) drawUI(screen *ebiten.Image) {
-
-	/*
-		if g.state.cursorOnItem != "" {
-			ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Cursor On Item: %s", g.state.cursorOnItem), 10, 150)
-		}
-	*/
-
-	//ebitenutil.DebugPrintAt(screen, fmt.Sprintf("FPS: %0.2f", ebiten.ActualFPS()), 10, 10)
-	//ebitenutil.DebugPrintAt(screen, fmt.Sprintf("TPS: %0.2f", ebiten.ActualTPS()), 10, 25)
-	//ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Location: %s", g.state.currentLocation.Name), 10, 40)
-	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Verb: %s", g.state.currentVerb), 10, 10)
-	// Aggiungi altre informazioni di debug secondo necessità
-}
-
-func (g *Game) drawCursor(screen *ebiten.Image) {
-
-	x, y := g.state.camera.ScreenToWorld(ebiten.CursorPosition())
-
-	x -= float64(g.state.currentCursor.Bounds().Dx() / 2)
-	y -= float64(g.state.currentCursor.Bounds().Dy() / 2)
-
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(x, y)
-
-	screen.DrawImage(g.state.currentCursor, op)
-
-}
-
+// ItemAt returns the ID of the item of the current location under the given world position, or "".
+// Where sprites overlap the item drawn last, on top, wins.
 func (g *Game) ItemAt(x int, y int) string {
-	for id, location := range g.state.currentLocation.Items {
-		item := g.GetItem(id)
-
-		// Load item sprite and calculate alpha if not loaded
-		if item.Alpha == nil {
-			if len(item.Image) == 0 && g.packagedData != nil {
-				g.LoadItemSprite(id)
-				item = g.GetItem(id) // Refresh item data
-			}
-
-			if len(item.Image) > 0 {
-				img, _, err := image.Decode(bytes.NewReader(item.Image))
-				if err == nil {
-					b := img.Bounds()
-					alphaImage := image.NewAlpha(b)
-					for j := b.Min.Y; j < b.Max.Y; j++ {
-						for i := b.Min.X; i < b.Max.X; i++ {
-							alphaImage.Set(i, j, img.At(i, j))
-						}
-					}
-					item.Alpha = alphaImage
-				}
+	for i := len(g.state.yOrderedEntities) - 1; i >= 0; i-- {
+		id := g.state.yOrderedEntities[i]
+		if index, isWalkBehind := WalkBehindIndex(id); isWalkBehind {
+			// Gli item dietro un walk-behind non si cliccano attraverso
+			if isWalkBehindAt(g.WalkBehinds()[index], x, y) {
+				return ""
 			}
+			continue
+		}
+		location, isItem := g.state.currentLocation.Items[id]
+		if !isItem {
+			continue
 		}
 
-		if item.Alpha != nil && item.Alpha.At(x-location.LocationPoint.X, y-location.LocationPoint.Y).(color.Alpha).A > 0 {
+		mask := g.itemHitMask(id)
+		if mask != nil && mask.Contains(image.Pt(x-location.LocationPoint.X, y-location.LocationPoint.Y)) {
 			return id
 		}
 	}
@@ -752,18 +437,23 @@ func (g *Game) ItemAt(x int, y int) string {
 	return ""
 }
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return screenWidth, screenHeight
-}
-
-func (g *Game) MoveTo(x int, y int) {
+// MoveTo walks the current character towards a point of the location. It reports whether the walk
+// was queued: it is not in a location without walkable areas.
+func (g *Game) MoveTo(x int, y int) bool {
 	destination := image.Point{
 		X: x,
 		Y: y,
 	}
 
+	if g.state.pathFinder == nil {
+		log.Printf("Warning: location '%s' has no walkable area", g.state.currentLocation.Name)
+		return false
+	}
+
 	path := g.state.pathFinder.Path(g.GetCurrentCharacterPosition(), destination)
+	g.state.pendingExit = -1
 	g.state.watingActions = make([]string, 0)
+	g.state.committedSentence = ""
 	for i := 1; i < len(path); i++ {
 		trigger := composeTrigger(g.state.currentCharacter.ID, model.MOVE_TO, fmt.Sprintf("%d:%d", path[i-1].X, path[i-1].Y), fmt.Sprintf("%d:%d", path[i].X, path[i].Y), g.state.currentLocation.ID)
 		g.state.watingActions = append(g.state.watingActions, trigger)
@@ -774,17 +464,5 @@ func (g *Game) MoveTo(x int, y int) {
 		g.SetCurrentState(model.EXECUTING_ACTION)
 	}
 
-}
-
-func (g *Game) GetSpriteDimensions(frameImage []byte) (int, int) {
-
-	spriteImage, _, err := image.Decode(bytes.NewReader(frameImage))
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	img := ebiten.NewImageFromImage(spriteImage)
-
-	return img.Bounds().Dx(), img.Bounds().Dy()
-
+	return true
 }
@@ -2,11 +2,31 @@ package logic
 
 import (
 	"chemistry/engine/model"
+	"context"
+	"fmt"
 	"image"
+	"time"
 
 	lua "github.com/yuin/gopher-lua"
 )
 
+// scriptTimeBudget is how long a script may run before giving control back to the game,
+// either by ending or with a blocking call. A script over budget is aborted.
+const scriptTimeBudget = 250 * time.Millisecond
+
+// sandboxLibs are the standard Lua libraries scripts can use: no io, os, debug or channels
+var sandboxLibs = []struct {
+	name string
+	open lua.LGFunction
+}{
+	{lua.LoadLibName, lua.OpenPackage},
+	{lua.BaseLibName, lua.OpenBase},
+	{lua.TabLibName, lua.OpenTable},
+	{lua.StringLibName, lua.OpenString},
+	{lua.MathLibName, lua.OpenMath},
+	{lua.CoroutineLibName, lua.OpenCoroutine},
+}
+
 // LuaScripting holds the Lua state and provides methods to run scripts.
 // TODO: Consider if Game needs a direct reference to LuaScripting or vice-versa,
 // or if they interact through a higher-level coordinator.
@@ -20,7 +40,7 @@ func itemToLuaTable(L *lua.LState, item model.Item) *lua.LTable {
 	L.SetField(table, "type", lua.LString(string(item.Type)))
 	L.SetField(table, "use_with", lua.LBool(item.UseWith))
 	L.SetField(table, "pickable", lua.LBool(item.Pickable))
-	L.SetField(table, "has_alpha", lua.LBool(item.Alpha != nil))
+	L.SetField(table, "has_alpha", lua.LBool(item.HitMask != nil))
 	L.SetField(table, "has_image", lua.LBool(len(item.Image) > 0))
 	L.SetField(table, "has_inventory_image", lua.LBool(len(item.InventoryImage) > 0))
 	return table
@@ -76,10 +96,13 @@ func registerGameFunction(L *lua.LState, gameTable *lua.LTable, funcName string,
 
 // NewLuaState creates and initializes a new Lua state with game functions.
 func NewLuaState(game *Game) *lua.LState {
-	L := lua.NewState()
-	// lua.OpenBase(L) // Optional: Load Lua base libraries (print, math, etc.) if scripts need them.
-	// lua.OpenTable(L) // For table manipulation utilities
-	// lua.OpenString(L) // For string manipulation
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	openSandboxLibs(L)
+
+	// math.random draws from the random numbers of the game, so a recorded session replays the same
+	mathTable := L.GetGlobal(lua.MathLibName).(*lua.LTable)
+	registerGameFunction(L, mathTable, "random", luaMathRandom, game)
+	registerGameFunction(L, mathTable, "randomseed", luaMathRandomseed, game)
 
 	gameTable := L.NewTable()
 	L.SetGlobal("game", gameTable)
@@ -89,6 +112,7 @@ func NewLuaState(game *Game) *lua.LState {
 	registerGameFunction(L, gameTable, "ItemAt", luaItemAt, game)
 	registerGameFunction(L, gameTable, "StopCharacterMovementAnimation", luaStopCharacterMovementAnimation, game)
 	registerGameFunction(L, gameTable, "SaySomething", luaSaySomething, game)
+	registerGameFunction(L, gameTable, "SetTextSpeed", luaSetTextSpeed, game)
 
 	registerGameFunction(L, gameTable, "GetCurrentState", luaGetCurrentState, game)
 	registerGameFunction(L, gameTable, "SetCurrentState", luaSetCurrentState, game)
@@ -101,6 +125,10 @@ func NewLuaState(game *Game) *lua.LState {
 	registerGameFunction(L, gameTable, "GetCurrentCharacter", luaGetCurrentCharacter, game)
 	registerGameFunction(L, gameTable, "SetCurrentCharacter", luaSetCurrentCharacter, game)
 
+	registerGameFunction(L, gameTable, "EnableWalkableArea", luaEnableWalkableArea, game)
+	registerGameFunction(L, gameTable, "DisableWalkableArea", luaDisableWalkableArea, game)
+	registerGameFunction(L, gameTable, "IsWalkableAreaEnabled", luaIsWalkableAreaEnabled, game)
+
 	registerGameFunction(L, gameTable, "GetFlag", luaGetFlag, game)
 	registerGameFunction(L, gameTable, "SetFlag", luaSetFlag, game)
 	registerGameFunction(L, gameTable, "GetCounter", luaGetCounter, game)
@@ -121,8 +149,29 @@ func NewLuaState(game *Game) *lua.LState {
 	registerGameFunction(L, gameTable, "AdvanceCurrentAnimationFrame", luaAdvanceCurrentAnimationFrame, game)
 	registerGameFunction(L, gameTable, "GetCurrentCharacterAnimation", luaGetCurrentCharacterAnimation, game)
 
+	registerGameFunction(L, gameTable, "CharacterAt", luaCharacterAt, game)
+	registerGameFunction(L, gameTable, "GetCharacterPosition", luaGetCharacterPosition, game)
+	registerGameFunction(L, gameTable, "SetCharacterPosition", luaSetCharacterPosition, game)
+	registerGameFunction(L, gameTable, "SetCharacterDirection", luaSetCharacterDirection, game)
+	registerGameFunction(L, gameTable, "SetCharacterAnimation", luaSetCharacterAnimation, game)
+	registerGameFunction(L, gameTable, "SetItemAnimation", luaSetItemAnimation, game)
+	registerGameFunction(L, gameTable, "GetItemAnimation", luaGetItemAnimation, game)
+
 	registerGameFunction(L, gameTable, "ExecuteAction", luaExecuteAction, game)
 
+	// Blocking calls: the script is suspended until they are over (see coroutines.go)
+	registerGameFunction(L, gameTable, "WalkTo", luaWalkTo, game)
+	registerGameFunction(L, gameTable, "Say", luaSay, game)
+	registerGameFunction(L, gameTable, "Wait", luaWait, game)
+	registerGameFunction(L, gameTable, "PlayAnimation", luaPlayAnimation, game)
+
+	registerGameFunction(L, gameTable, "StartDialogue", luaStartDialogue, game)
+	registerGameFunction(L, gameTable, "EndDialogue", luaEndDialogue, game)
+	registerGameFunction(L, gameTable, "IsInDialogue", luaIsInDialogue, game)
+
+	registerGameFunction(L, gameTable, "Save", luaSave, game)
+	registerGameFunction(L, gameTable, "Load", luaLoad, game)
+
 	// Note: AddFont, AddCursor, AddCharacter, AddItem, AddLocation, AddAction are primarily for setup.
 	// They *could* be exposed, but scripts running during an ActionNode might not typically use them.
 	// Consider if they are needed for runtime scripting use cases.
@@ -130,9 +179,47 @@ func NewLuaState(game *Game) *lua.LState {
 	return L
 }
 
+// openSandboxLibs opens the whitelisted libraries, without the functions reaching the file system
+func openSandboxLibs(L *lua.LState) {
+	for _, lib := range sandboxLibs {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	L.SetGlobal("dofile", lua.LNil)
+	L.SetGlobal("loadfile", lua.LNil)
+
+	// Modules can only come from package.preload and from the game scripts
+	packageTable := L.GetGlobal(lua.LoadLibName)
+	if loaders, ok := L.GetField(packageTable, "loaders").(*lua.LTable); ok {
+		for i := loaders.Len(); i > 1; i-- {
+			loaders.Remove(i)
+		}
+	}
+	L.SetField(packageTable, "loadlib", lua.LNil)
+}
+
+// withScriptBudget runs fn with L limited to scriptTimeBudget; an over budget script raises an error
+func withScriptBudget(L *lua.LState, fn func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), scriptTimeBudget)
+	defer cancel()
+
+	L.SetContext(ctx)
+	defer L.RemoveContext()
+
+	err := fn()
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("script aborted after running for more than %v: %w", scriptTimeBudget, err)
+	}
+	return err
+}
+
 // RunScript executes a Lua script string in the given Lua state.
 func RunScript(L *lua.LState, script string) error {
-	return L.DoString(script)
+	return withScriptBudget(L, func() error {
+		return L.DoString(script)
+	})
 }
 
 // --- Lua Wrapper Functions ---
@@ -153,7 +240,7 @@ func luaItemAt(L *lua.LState, game *Game) int {
 }
 
 func luaStopCharacterMovementAnimation(L *lua.LState, game *Game) int {
-	game.SetCurrentCharacterAnimationAtFrame(game.state.currentCharacterAnimation, game.state.currentCharacterAnimationFrame)
+	game.SetCurrentCharacterAnimationAtFrame(game.GetCurrentCharacterAnimation())
 	return 0
 }
 
@@ -163,6 +250,12 @@ func luaSaySomething(L *lua.LState, game *Game) int {
 	return 0
 }
 
+// luaSetTextSpeed sets how many characters per second the player reads, the default if not positive
+func luaSetTextSpeed(L *lua.LState, game *Game) int {
+	game.SetTextSpeed(L.CheckInt(2))
+	return 0
+}
+
 func luaGetCurrentState(L *lua.LState, game *Game) int {
 	state := game.GetCurrentState()
 	L.Push(lua.LString(string(state))) // Assuming model.StateType is string-based or convertible
@@ -214,12 +307,15 @@ func layerToLuaTable(L *lua.LState, layer model.Layer) *lua.LTable {
 	table := L.NewTable()
 	L.SetField(table, "has_image", lua.LBool(len(layer.Image) > 0))
 	L.SetField(table, "transparent_color", lua.LString(layer.TransparentColor))
+	L.SetField(table, "z_order", lua.LNumber(layer.ZOrder))
+	L.SetField(table, "parallax", lua.LNumber(layer.Parallax))
 	return table
 }
 
 // Helper to convert model.WalkableArea to a Lua table (simplified)
 func walkableAreaToLuaTable(L *lua.LState, wa model.WalkableArea) *lua.LTable {
 	table := L.NewTable()
+	L.SetField(table, "name", lua.LString(wa.Name))
 	polygonsTable := L.NewTable()
 	for i, polygon := range wa.Polygons {
 		polygonsTable.RawSetInt(i+1, lua.LNumber(len(polygon))) // Use RawSetInt for array-like table
@@ -228,6 +324,26 @@ func walkableAreaToLuaTable(L *lua.LState, wa model.WalkableArea) *lua.LTable {
 	return table
 }
 
+// Helper to convert model.Exit to a Lua table
+func exitToLuaTable(L *lua.LState, exit model.Exit) *lua.LTable {
+	table := L.NewTable()
+	L.SetField(table, "name", lua.LString(exit.Name))
+	L.SetField(table, "target_location", lua.LString(exit.TargetLocation))
+	L.SetField(table, "entry_point", pointToLuaTable(L, exit.EntryPoint))
+	L.SetField(table, "entry_direction", lua.LString(string(exit.EntryDirection)))
+	return table
+}
+
+// Helper to convert model.Hotspot to a Lua table
+func hotspotToLuaTable(L *lua.LState, hotspot model.Hotspot) *lua.LTable {
+	table := L.NewTable()
+	L.SetField(table, "id", lua.LString(hotspot.ID))
+	L.SetField(table, "name", lua.LString(hotspot.Name))
+	L.SetField(table, "interaction_point", pointToLuaTable(L, hotspot.InteractionPoint))
+	L.SetField(table, "direction", lua.LString(string(hotspot.Direction)))
+	return table
+}
+
 // Helper to convert model.Location to a Lua table
 func locationToLuaTable(L *lua.LState, loc model.Location) *lua.LTable {
 	table := L.NewTable()
@@ -242,17 +358,23 @@ func locationToLuaTable(L *lua.LState, loc model.Location) *lua.LTable {
 	L.SetField(table, "layers", layersTable)
 
 	walkableAreasTable := L.NewTable()
-	// This simplified version only gets the first walkable area.
-	// A full implementation would need a way to get all walkable areas from model.Location.
-	// Safely defer-recover to handle empty walkable areas slice
-	func() {
-		defer func() { recover() }()
-		if len(loc.GetWalkableArea(0).Polygons) > 0 {
-			walkableAreasTable.RawSetInt(1, walkableAreaToLuaTable(L, loc.GetWalkableArea(0)))
-		}
-	}()
+	for i, area := range loc.GetWalkableAreas() {
+		walkableAreasTable.RawSetInt(i+1, walkableAreaToLuaTable(L, area))
+	}
 	L.SetField(table, "walkable_areas", walkableAreasTable)
 
+	exitsTable := L.NewTable()
+	for i, exit := range loc.GetExits() {
+		exitsTable.RawSetInt(i+1, exitToLuaTable(L, exit))
+	}
+	L.SetField(table, "exits", exitsTable)
+
+	hotspotsTable := L.NewTable()
+	for i, hotspot := range loc.GetHotspots() {
+		hotspotsTable.RawSetInt(i+1, hotspotToLuaTable(L, hotspot))
+	}
+	L.SetField(table, "hotspots", hotspotsTable)
+
 	itemsTable := L.NewTable()
 	for itemID, itemLoc := range loc.Items {
 		L.SetField(itemsTable, itemID, itemLocationToLuaTable(L, itemLoc))
@@ -304,6 +426,45 @@ func luaGetFlag(L *lua.LState, game *Game) int {
 	return 1
 }
 
+// luaWalkableAreaLocation returns the key of the location argument of the walkable area functions,
+// the current location by default
+func luaWalkableAreaLocation(L *lua.LState, game *Game) string {
+	key, exists := game.locationKey(L.OptString(3, game.state.currentLocation.ID))
+	if !exists {
+		L.ArgError(3, "location not found")
+	}
+	return key
+}
+
+func luaEnableWalkableArea(L *lua.LState, game *Game) int {
+	name := L.CheckString(2)
+	if err := game.SetWalkableAreaEnabled(luaWalkableAreaLocation(L, game), name, true); err != nil {
+		L.ArgError(2, err.Error())
+	}
+	return 0
+}
+
+func luaDisableWalkableArea(L *lua.LState, game *Game) int {
+	name := L.CheckString(2)
+	if err := game.SetWalkableAreaEnabled(luaWalkableAreaLocation(L, game), name, false); err != nil {
+		L.ArgError(2, err.Error())
+	}
+	return 0
+}
+
+func luaIsWalkableAreaEnabled(L *lua.LState, game *Game) int {
+	name := L.CheckString(2)
+	location := game.data.Locations[luaWalkableAreaLocation(L, game)]
+	for _, area := range location.GetWalkableAreas() {
+		if area.Name == name {
+			L.Push(lua.LBool(game.IsWalkableAreaEnabled(location.ID, area)))
+			return 1
+		}
+	}
+	L.Push(lua.LNil)
+	return 1
+}
+
 func luaSetFlag(L *lua.LState, game *Game) int {
 	flagName := L.CheckString(2)
 	flagValue := L.CheckBool(3)
@@ -413,6 +574,77 @@ func luaAdvanceCurrentAnimationFrame(L *lua.LState, game *Game) int {
 	return 0
 }
 
+func luaCharacterAt(L *lua.LState, game *Game) int {
+	x := L.CheckInt(2)
+	y := L.CheckInt(3)
+	L.Push(lua.LString(game.CharacterAt(x, y)))
+	return 1
+}
+
+func luaGetCharacterPosition(L *lua.LState, game *Game) int {
+	state, err := game.GetCharacterState(L.CheckString(2))
+	if err != nil {
+		L.Push(lua.LNil)
+		return 1
+	}
+	table := L.NewTable()
+	L.SetField(table, "x", lua.LNumber(state.Position.X))
+	L.SetField(table, "y", lua.LNumber(state.Position.Y))
+	L.Push(table)
+	return 1
+}
+
+func luaSetCharacterPosition(L *lua.LState, game *Game) int {
+	state, err := game.GetCharacterState(L.CheckString(2))
+	if err != nil {
+		L.ArgError(1, err.Error())
+		return 0
+	}
+	table := L.CheckTable(3)
+	x, okX := L.GetField(table, "x").(lua.LNumber)
+	y, okY := L.GetField(table, "y").(lua.LNumber)
+	if !okX || !okY {
+		L.ArgError(2, "expected a table with x and y number fields")
+		return 0
+	}
+	state.Position = image.Point{X: int(x), Y: int(y)}
+	return 0
+}
+
+func luaSetCharacterDirection(L *lua.LState, game *Game) int {
+	state, err := game.GetCharacterState(L.CheckString(2))
+	if err != nil {
+		L.ArgError(1, err.Error())
+		return 0
+	}
+	state.Direction = model.CharacterDirection(L.CheckString(3))
+	return 0
+}
+
+func luaSetCharacterAnimation(L *lua.LState, game *Game) int {
+	state, err := game.GetCharacterState(L.CheckString(2))
+	if err != nil {
+		L.ArgError(1, err.Error())
+		return 0
+	}
+	state.Play(L.CheckString(3), game.now())
+	return 0
+}
+
+func luaSetItemAnimation(L *lua.LState, game *Game) int {
+	if err := game.SetItemAnimation(L.CheckString(2), L.CheckString(3)); err != nil {
+		L.ArgError(1, err.Error())
+	}
+	return 0
+}
+
+func luaGetItemAnimation(L *lua.LState, game *Game) int {
+	animation, frame := game.ItemAnimation(L.CheckString(2))
+	L.Push(lua.LString(animation))
+	L.Push(lua.LNumber(frame))
+	return 2
+}
+
 func luaGetCurrentCharacterAnimation(L *lua.LState, game *Game) int {
 	animName, frame := game.GetCurrentCharacterAnimation()
 	L.Push(lua.LString(animName))
@@ -425,3 +657,68 @@ func luaExecuteAction(L *lua.LState, game *Game) int {
 	game.ExecuteAction(actionString) // Assuming ExecuteAction handles parsing this string
 	return 0
 }
+
+func luaStartDialogue(L *lua.LState, game *Game) int {
+	dialogueID := L.CheckString(2)
+	game.StartDialogue(dialogueID)
+	return 0
+}
+
+func luaEndDialogue(L *lua.LState, game *Game) int {
+	game.EndDialogue()
+	return 0
+}
+
+func luaIsInDialogue(L *lua.LState, game *Game) int {
+	L.Push(lua.LBool(game.IsInDialogue()))
+	return 1
+}
+
+func luaSave(L *lua.LState, game *Game) int {
+	slot := L.CheckInt(2)
+	if err := game.SaveGame(slot); err != nil {
+		L.Push(lua.LFalse)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(lua.LTrue)
+	return 1
+}
+
+func luaLoad(L *lua.LState, game *Game) int {
+	slot := L.CheckInt(2)
+	if err := game.LoadGame(slot); err != nil {
+		L.Push(lua.LFalse)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(lua.LTrue)
+	return 1
+}
+
+// luaMathRandom is math.random as in Lua 5.1: a float in [0,1), an integer in [1,m] or in [m,n]
+func luaMathRandom(L *lua.LState, game *Game) int {
+	random := game.state.random
+	switch L.GetTop() {
+	case 0:
+		L.Push(lua.LNumber(random.Float64()))
+	case 1:
+		upper := L.CheckInt(1)
+		if upper < 1 {
+			L.ArgError(1, "interval is empty")
+		}
+		L.Push(lua.LNumber(random.Intn(upper) + 1))
+	default:
+		lower, upper := L.CheckInt(1), L.CheckInt(2)
+		if lower > upper {
+			L.ArgError(2, "interval is empty")
+		}
+		L.Push(lua.LNumber(lower + random.Intn(upper-lower+1)))
+	}
+	return 1
+}
+
+func luaMathRandomseed(L *lua.LState, game *Game) int {
+	game.SetRandomSeed(int64(L.CheckNumber(1)))
+	return 0
+}
@@ -1,7 +1,11 @@
 package logic
 
 import (
+	"bytes"
 	"chemistry/engine/model"
+	"log"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -38,3 +42,53 @@ func TestLuaBindingsMethods(t *testing.T) {
 		t.Errorf("Lua script failed: %v", err)
 	}
 }
+
+func TestSandboxAbortsRunawayScripts(t *testing.T) {
+	game := &Game{
+		data:  initGameData(),
+		state: initGameState(),
+	}
+
+	L := NewLuaState(game)
+	defer L.Close()
+	if err := RunScript(L, `assert(os == nil and io == nil and debug == nil and dofile == nil)`); err != nil {
+		t.Errorf("expected unsafe libraries closed: %v", err)
+	}
+
+	var scriptErr error
+	game.StartScript("runaway", `while true do end`, func(err error) { scriptErr = err })
+	if game.IsScriptRunning() || scriptErr == nil {
+		t.Errorf("expected the runaway script aborted with an error")
+	}
+}
+
+func TestAbortedActionScriptHasNoEffects(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	game := newCharactersTestGame()
+	action := model.NewAction("guybrush-id", model.PICK_UP, "coin-id", model.NOTHING, "porto-id", `while true do end`, nil, nil, nil)
+	action.NodeID = "node-42"
+	game.AddAction(action)
+
+	trigger := composeTrigger("guybrush-id", model.PICK_UP, "coin-id", model.NOTHING, "porto-id")
+	game.ExecuteAction(trigger)
+	if game.IsScriptRunning() {
+		t.Fatalf("expected the runaway action script aborted")
+	}
+	if _, picked := game.GetCharacter("Guybrush").Inventory["coin-id"]; picked {
+		t.Errorf("expected no coin picked up by an aborted script")
+	}
+
+	// The report points the author to the action and to its diagram node
+	var report string
+	for _, line := range strings.Split(logged.String(), "\n") {
+		if strings.Contains(line, "Error executing Lua script") {
+			report = line
+		}
+	}
+	if !strings.Contains(report, trigger) || !strings.Contains(report, "node-42") {
+		t.Errorf("expected the abort report to name %s and node-42, got %q", trigger, report)
+	}
+}
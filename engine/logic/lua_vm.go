@@ -0,0 +1,235 @@
+package logic
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// luaState returns the Lua state shared by every script of the game session, creating it on first use.
+// Globals defined by a script are seen by the following ones and are saved with the game.
+func (g *Game) luaState() *lua.LState {
+	if g.state.lua == nil {
+		L := NewLuaState(g)
+		g.installScriptLoader(L)
+		g.state.luaBuiltins = luaGlobalNames(L)
+		g.state.lua = L
+	}
+	return g.state.lua
+}
+
+// scriptSource returns the text of a script entity, loading it on-demand from the packaged data
+func (g *Game) scriptSource(name string) (string, bool) {
+	if g.data.Scripts[name] == "" && g.packagedData != nil {
+		if err := g.LoadScript(name); err != nil {
+			log.Printf("Error loading Lua script '%s': %v", name, err)
+		}
+	}
+	scriptText, exists := g.data.Scripts[name]
+	return scriptText, exists
+}
+
+// installScriptLoader lets scripts require the script entities of the game as modules, e.g. require("Utils").
+// A module is loaded once: following requires get the value cached in package.loaded.
+func (g *Game) installScriptLoader(L *lua.LState) {
+	loaders, ok := L.GetField(L.GetGlobal("package"), "loaders").(*lua.LTable)
+	if !ok {
+		log.Printf("Warning: Lua package library not available, game scripts can not be required")
+		return
+	}
+
+	// Right after package.preload, before the file system
+	loaders.Insert(2, L.NewFunction(func(L *lua.LState) int {
+		name := L.CheckString(1)
+		scriptText, exists := g.scriptSource(name)
+		if !exists {
+			L.Push(lua.LString(fmt.Sprintf("\n\tno game script '%s'", name)))
+			return 1
+		}
+		fn, err := L.Load(strings.NewReader(scriptText), name)
+		if err != nil {
+			L.RaiseError("error loading game script '%s': %v", name, err)
+		}
+		L.Push(fn)
+		return 1
+	}))
+}
+
+func luaGlobalNames(L *lua.LState) map[string]bool {
+	names := make(map[string]bool)
+	L.G.Global.ForEach(func(key lua.LValue, _ lua.LValue) {
+		if name, ok := key.(lua.LString); ok {
+			names[string(name)] = true
+		}
+	})
+	return names
+}
+
+// scriptGlobals returns the globals defined by the scripts that hold plain data:
+// booleans, numbers, strings and tables of them. Functions and modules are not saved.
+func (g *Game) scriptGlobals() map[string]any {
+	if g.state.lua == nil {
+		return nil
+	}
+
+	globals := make(map[string]any)
+	g.state.lua.G.Global.ForEach(func(key lua.LValue, value lua.LValue) {
+		name, ok := key.(lua.LString)
+		if !ok || g.state.luaBuiltins[string(name)] {
+			return
+		}
+		if saved, ok := luaToSaved(value, make(map[*lua.LTable]bool)); ok {
+			globals[string(name)] = saved
+		}
+	})
+	return globals
+}
+
+// restoreScriptGlobals replaces the plain data globals of the scripts with the saved ones
+func (g *Game) restoreScriptGlobals(globals map[string]any) {
+	if g.state.lua == nil && len(globals) == 0 {
+		return
+	}
+	L := g.luaState()
+
+	stale := make([]string, 0)
+	L.G.Global.ForEach(func(key lua.LValue, value lua.LValue) {
+		name, ok := key.(lua.LString)
+		if !ok || g.state.luaBuiltins[string(name)] {
+			return
+		}
+		if _, ok := luaToSaved(value, make(map[*lua.LTable]bool)); ok {
+			stale = append(stale, string(name))
+		}
+	})
+	for _, name := range stale {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	names := make([]string, 0, len(globals))
+	for name := range globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		L.SetGlobal(name, savedToLua(L, globals[name]))
+	}
+}
+
+// savedEntriesKey marks a saved table with numeric keys that is not a sequence: it is saved as the list
+// of its [key, value] pairs, so that t[3] doesn't come back as t["3"]
+const savedEntriesKey = "$entries"
+
+// luaToSaved converts a Lua value to a JSON friendly one. Tables whose keys are 1..n become arrays,
+// tables with string keys objects, the others lists of entries (see savedEntriesKey).
+// It fails for functions, userdata and tables holding them or cycles.
+func luaToSaved(value lua.LValue, visiting map[*lua.LTable]bool) (any, bool) {
+	switch v := value.(type) {
+	case lua.LBool:
+		return bool(v), true
+	case lua.LNumber:
+		return float64(v), true
+	case lua.LString:
+		return string(v), true
+	case *lua.LTable:
+		if visiting[v] {
+			return nil, false
+		}
+		visiting[v] = true
+		defer delete(visiting, v)
+
+		length := v.Len()
+		ok := true
+		numericKeys := false
+		object := make(map[string]any)
+		entries := make([][]any, 0)
+		v.ForEach(func(key lua.LValue, value lua.LValue) {
+			saved, valid := luaToSaved(value, visiting)
+			if !valid {
+				ok = false
+				return
+			}
+			switch k := key.(type) {
+			case lua.LString:
+				object[string(k)] = saved
+				entries = append(entries, []any{string(k), saved})
+			case lua.LNumber:
+				numericKeys = true
+				entries = append(entries, []any{float64(k), saved})
+			default:
+				ok = false
+			}
+		})
+		if !ok {
+			return nil, false
+		}
+
+		if length > 0 && len(entries) == length {
+			array := make([]any, length)
+			for i := range array {
+				array[i], _ = luaToSaved(v.RawGetInt(i+1), visiting)
+			}
+			return array, true
+		}
+		if _, reserved := object[savedEntriesKey]; !numericKeys && !reserved {
+			return object, true
+		}
+
+		// Numeri prima delle stringhe, così il salvataggio non dipende dall'ordine della tabella
+		sort.Slice(entries, func(i, j int) bool {
+			a, aNumber := entries[i][0].(float64)
+			b, bNumber := entries[j][0].(float64)
+			if aNumber != bNumber {
+				return aNumber
+			}
+			if aNumber {
+				return a < b
+			}
+			return entries[i][0].(string) < entries[j][0].(string)
+		})
+		list := make([]any, len(entries))
+		for i, entry := range entries {
+			list[i] = entry
+		}
+		return map[string]any{savedEntriesKey: list}, true
+	}
+	return nil, false
+}
+
+// savedToLua converts a value decoded from a save game back to Lua
+func savedToLua(L *lua.LState, value any) lua.LValue {
+	switch v := value.(type) {
+	case bool:
+		return lua.LBool(v)
+	case float64:
+		return lua.LNumber(v)
+	case string:
+		return lua.LString(v)
+	case []any:
+		table := L.NewTable()
+		for _, element := range v {
+			table.Append(savedToLua(L, element))
+		}
+		return table
+	case map[string]any:
+		table := L.NewTable()
+		if entries, isEntries := v[savedEntriesKey].([]any); isEntries && len(v) == 1 {
+			for _, entry := range entries {
+				pair, valid := entry.([]any)
+				if !valid || len(pair) != 2 {
+					continue
+				}
+				table.RawSet(savedToLua(L, pair[0]), savedToLua(L, pair[1]))
+			}
+			return table
+		}
+		for key, element := range v {
+			table.RawSetString(key, savedToLua(L, element))
+		}
+		return table
+	}
+	return lua.LNil
+}
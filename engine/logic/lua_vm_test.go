@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"testing"
+)
+
+func TestScriptGlobalsSurviveActionsAndSaves(t *testing.T) {
+	game := newCharactersTestGame()
+	game.SetSaveDirectory(t.TempDir())
+	game.AddScript("Utils", `
+		loads = (loads or 0) + 1
+		return { twice = function(x) return x * 2 end }
+	`)
+
+	game.StartScript("first", `
+		local utils = require("Utils")
+		visits = { utils.twice(1), "porto" }
+	`, nil)
+	game.StartScript("second", `
+		local utils = require("Utils")
+		table.insert(visits, utils.twice(2))
+		game:SetCounter("loads", loads)
+	`, nil)
+
+	if loads := game.GetCounter("loads"); loads != 1 {
+		t.Fatalf("expected the module loaded once, got %d loads", loads)
+	}
+
+	if err := game.SaveGame(1); err != nil {
+		t.Fatalf("SaveGame failed: %v", err)
+	}
+	game.StartScript("diverge", `visits = nil; extra = true`, nil)
+
+	if err := game.LoadGame(1); err != nil {
+		t.Fatalf("LoadGame failed: %v", err)
+	}
+	game.StartScript("check", `
+		game:SetFlag("restored", #visits == 3 and visits[2] == "porto" and visits[3] == 4)
+		game:SetFlag("extra", extra == true)
+	`, nil)
+
+	if !game.GetFlag("restored") {
+		t.Errorf("expected script globals restored from the save")
+	}
+	if game.GetFlag("extra") {
+		t.Errorf("expected globals defined after the save dropped on load")
+	}
+}
+
+func TestSparseTablesKeepTheirNumericKeys(t *testing.T) {
+	game := newCharactersTestGame()
+	game.SetSaveDirectory(t.TempDir())
+	game.StartScript("first", `
+		doors = { [3] = "open", [10] = "locked", name = "castle", ["4"] = "text" }
+	`, nil)
+
+	if err := game.SaveGame(1); err != nil {
+		t.Fatalf("SaveGame failed: %v", err)
+	}
+	game.StartScript("diverge", `doors = nil`, nil)
+	if err := game.LoadGame(1); err != nil {
+		t.Fatalf("LoadGame failed: %v", err)
+	}
+
+	game.StartScript("check", `
+		game:SetFlag("restored", doors[3] == "open" and doors[10] == "locked" and doors.name == "castle"
+			and doors["4"] == "text" and doors[4] == nil and doors["3"] == nil)
+	`, nil)
+	if !game.GetFlag("restored") {
+		t.Errorf("expected the numeric keys of a sparse table restored as numbers")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"chemistry/engine/model"
 	"image"
 	"log"
+	"time"
 )
 
 // mapPackagedDataIndex maps the packaged data index without loading binary resources
@@ -14,16 +15,31 @@ func (g *Game) mapPackagedDataIndex(pkgData PackagedGameData) error {
 
 		if l.Details != nil {
 			// Walkable Areas
-			if len(l.Details.WalkableArea) > 0 {
-				var polyPoints [][]image.Point
-				for _, poly := range l.Details.WalkableArea {
-					var points []image.Point
-					for _, p := range poly {
-						points = append(points, image.Point{X: p.X, Y: p.Y})
-					}
-					polyPoints = append(polyPoints, points)
-				}
-				location.AddWalkableArea(model.WalkableArea{Polygons: polyPoints})
+			mapWalkableAreas(&location, l.Details.WalkableArea, l.Details.WalkableAreas)
+
+			// Scala dei personaggi in base alla profondità
+			if area := l.Details.ScaleArea; area != nil {
+				location.SetScaleArea(mapScaleArea(*area))
+			}
+
+			// Layers sopra lo sfondo (immagini caricate con lo sfondo)
+			for _, layer := range l.Details.Layers {
+				location.AddLayer(mapLayer(nil, layer.EditorLayerSettings))
+			}
+
+			// Walk-behind (maschere caricate con lo sfondo)
+			for _, walkBehind := range l.Details.WalkBehinds {
+				location.AddWalkBehind(mapWalkBehind(nil, walkBehind.Baseline))
+			}
+
+			// Uscite verso le altre location
+			for _, exit := range l.Details.Exits {
+				location.AddExit(mapExit(exit))
+			}
+
+			// Hotspot dipinti nello sfondo
+			for _, hotspot := range l.Details.Hotspots {
+				location.AddHotspot(mapHotspot(hotspot))
 			}
 
 			// Placed Items
@@ -33,13 +49,25 @@ func (g *Game) mapPackagedDataIndex(pkgData PackagedGameData) error {
 					InteractionPoint: image.Point{X: placedItem.InteractionSpot.X, Y: placedItem.InteractionSpot.Y},
 				})
 			}
+
+			// Placed Characters
+			for _, placedCharacter := range l.Details.PlacedCharacters {
+				location.AddCharacter(placedCharacter.EntityID, model.CharacterLocation{
+					LocationPoint:    image.Point{X: placedCharacter.Position.X, Y: placedCharacter.Position.Y},
+					InteractionPoint: image.Point{X: placedCharacter.InteractionSpot.X, Y: placedCharacter.InteractionSpot.Y},
+				})
+			}
 		}
 		g.AddLocation(location)
 	}
 
 	// 2. Characters (structure only)
 	for _, c := range pkgData.Characters {
-		char := model.NewCharacter(c.ID, c.Name, model.Color{R: 255, G: 255, B: 255})
+		talkColor := ""
+		if c.Details != nil {
+			talkColor = c.Details.TalkColor
+		}
+		char := model.NewCharacter(c.ID, c.Name, characterTalkColor(c.Name, talkColor))
 		g.AddCharacter(char)
 	}
 
@@ -86,6 +114,8 @@ func (g *Game) mapPackagedDataIndex(pkgData PackagedGameData) error {
 				model.DoNothing,
 				model.DoNothing,
 			)
+			action.Dialogue = node.Dialogue
+			action.NodeID = node.ID
 			g.AddAction(action)
 		case "state":
 			if node.Label == "Initial state" {
@@ -108,13 +138,21 @@ func (g *Game) mapPackagedDataIndex(pkgData PackagedGameData) error {
 
 	// 7. Cursors (structure only)
 	for _, c := range pkgData.Cursors {
-		g.data.Cursors[c.Name] = nil // Placeholder, loaded on-demand
+		g.data.Cursors[c.Name] = model.NewCursor(c.Name) // Placeholder, loaded on-demand
+	}
+
+	// 8. Dialogues (text only, no binary resources)
+	for _, d := range pkgData.Dialogues {
+		g.AddDialogue(mapDialogue(d.ID, d.Name, d.Details))
 	}
 
+	// 9. Interface layout
+	g.SetInterfaceSettings(pkgData.Interface)
+
 	return nil
 }
 
-// LoadLocationBackground loads location background on-demand
+// LoadLocationBackground loads location background, layers and walk-behind masks on-demand
 func (g *Game) LoadLocationBackground(locationID string) error {
 	for _, l := range g.packagedData.Locations {
 		if l.Name == locationID && l.Details != nil && l.Details.BackgroundRef != nil {
@@ -125,31 +163,150 @@ func (g *Game) LoadLocationBackground(locationID string) error {
 			// Update location with background
 			location := g.GetLocation(locationID)
 			location.SetBackground(data)
+
+			// The layers follow the background, in the order they were packaged
+			for i, layer := range l.Details.Layers {
+				if layer.ImageRef == nil || len(location.GetLayers()[i+1].Image) > 0 {
+					continue
+				}
+				data, err := g.resourceManager.LoadBinaryData(layer.ImageRef)
+				if err != nil {
+					log.Printf("Error loading layer %d of location %s: %v", i, l.Name, err)
+					continue
+				}
+				location.SetLayerImage(i+1, data)
+			}
+
+			for i, walkBehind := range l.Details.WalkBehinds {
+				if walkBehind.MaskRef == nil || len(location.GetWalkBehinds()[i].Mask) > 0 {
+					continue
+				}
+				data, err := g.resourceManager.LoadBinaryData(walkBehind.MaskRef)
+				if err != nil {
+					log.Printf("Error loading walk-behind %d of location %s: %v", i, l.Name, err)
+					continue
+				}
+				location.SetWalkBehindMask(i, data)
+			}
 			break
 		}
 	}
 	return nil
 }
 
+// polygonsToPoints converts packaged polygons to the points of a walkable area
+func polygonsToPoints(polygons []Polygon) [][]image.Point {
+	points := make([][]image.Point, 0, len(polygons))
+	for _, polygon := range polygons {
+		polygonPoints := make([]image.Point, 0, len(polygon))
+		for _, p := range polygon {
+			polygonPoints = append(polygonPoints, image.Point{X: p.X, Y: p.Y})
+		}
+		points = append(points, polygonPoints)
+	}
+	return points
+}
+
+// mapWalkableAreas adds the walkable areas of a location: the default one, always enabled, and the named ones
+func mapWalkableAreas(location *model.Location, defaultArea []Polygon, areas []NamedWalkableArea) {
+	if len(defaultArea) > 0 {
+		location.AddWalkableArea(model.WalkableArea{Polygons: polygonsToPoints(defaultArea)})
+	}
+	for _, area := range areas {
+		location.AddWalkableArea(model.WalkableArea{Name: area.Name, Polygons: polygonsToPoints(area.Polygons), Disabled: area.Disabled})
+	}
+}
+
+// mapExit converts a packaged exit of a location
+func mapExit(exit Exit) model.Exit {
+	return model.Exit{
+		Name:           exit.Name,
+		Area:           polygonsToPoints([]Polygon{exit.Area})[0],
+		TargetLocation: exit.TargetLocation,
+		EntryPoint:     image.Point{X: exit.EntryPoint.X, Y: exit.EntryPoint.Y},
+		EntryDirection: model.CharacterDirection(exit.EntryDirection),
+		OnExit:         exit.OnExit,
+		OnEnter:        exit.OnEnter,
+	}
+}
+
+// mapHotspot converts a packaged hotspot of a location
+func mapHotspot(hotspot Hotspot) model.Hotspot {
+	return model.Hotspot{
+		ID:               hotspot.ID,
+		Name:             hotspot.Name,
+		Area:             polygonsToPoints([]Polygon{hotspot.Area})[0],
+		InteractionPoint: image.Point{X: hotspot.InteractionSpot.X, Y: hotspot.InteractionSpot.Y},
+		Direction:        model.CharacterDirection(hotspot.Direction),
+	}
+}
+
+// mapDialogue converts a packaged dialogue tree, details is nil for a dialogue without nodes
+func mapDialogue(id string, name string, details *DialogueDetails) model.Dialogue {
+	dialogue := model.NewDialogue(id, name, "")
+	if details == nil {
+		return dialogue
+	}
+	dialogue.StartNode = details.StartNode
+	for _, n := range details.Nodes {
+		node := model.DialogueNode{ID: n.ID}
+		for _, l := range n.Lines {
+			node.Lines = append(node.Lines, model.DialogueLine{Speaker: l.Speaker, Text: l.Text, Script: l.Script})
+		}
+		for _, o := range n.Options {
+			option := model.DialogueOption{
+				ID:              o.ID,
+				Text:            o.Text,
+				HideAfterChosen: o.HideAfterChosen,
+				Script:          o.Script,
+				Next:            o.Next,
+			}
+			for _, c := range o.Conditions {
+				option.Conditions = append(option.Conditions, model.DialogueCondition{
+					Flag:     c.Flag,
+					Value:    c.Value,
+					Counter:  c.Counter,
+					Operator: c.Operator,
+					Amount:   c.Amount,
+				})
+			}
+			node.Options = append(node.Options, option)
+		}
+		dialogue.AddNode(node)
+	}
+	return dialogue
+}
+
+// mapScaleArea converts the packaged scale area of a location
+func mapScaleArea(area ScaleArea) model.ScaleArea {
+	return model.ScaleArea{FarY: area.FarY, FarScale: area.FarScale, NearY: area.NearY, NearScale: area.NearScale}
+}
+
+// mapWalkBehind converts a packaged walk-behind of a location, mask is nil until it is loaded
+func mapWalkBehind(mask []byte, baseline int) model.WalkBehind {
+	return model.NewWalkBehind(mask, baseline)
+}
+
+// mapLayer converts a packaged layer of a location, image is nil until it is loaded.
+// A layer without parallax scrolls with the room.
+func mapLayer(image []byte, settings model.EditorLayerSettings) model.Layer {
+	parallax := 1.0
+	if settings.Parallax != nil {
+		parallax = *settings.Parallax
+	}
+	return model.Layer{Image: image, ZOrder: settings.ZOrder, Parallax: parallax, TransparentColor: settings.TransparentColor}
+}
+
 // LoadCharacterAnimations loads character animations on-demand
 func (g *Game) LoadCharacterAnimations(characterID string) error {
 	for _, c := range g.packagedData.Characters {
 		if c.Name == characterID && c.Details != nil {
 			char := g.GetCharacter(characterID)
 			for _, anim := range c.Details.Animations {
-				var frames [][]byte
-				for _, frame := range anim.Frames {
-					if frame.ImageRef != nil {
-						data, err := g.resourceManager.LoadBinaryData(frame.ImageRef)
-						if err != nil {
-							log.Printf("Error loading animation frame: %v", err)
-							continue
-						}
-						frames = append(frames, data)
-					}
-				}
+				frames, timing := g.loadAnimation(anim)
 				if len(frames) > 0 {
 					char.Animations[anim.Name] = frames
+					char.AnimationTimings[anim.Name] = timing
 				}
 			}
 			break
@@ -158,16 +315,113 @@ func (g *Game) LoadCharacterAnimations(characterID string) error {
 	return nil
 }
 
-// LoadItemSprite loads item sprite on-demand
+// LoadItemSprite loads item sprite and animations on-demand
 func (g *Game) LoadItemSprite(itemID string) error {
 	for _, i := range g.packagedData.Items {
-		if i.ID == itemID && i.Details != nil && i.Details.ImageRef != nil {
+		if i.ID != itemID || i.Details == nil {
+			continue
+		}
+		item := g.data.Items[itemID]
+		if i.Details.ImageRef != nil {
 			data, err := g.resourceManager.LoadBinaryData(i.Details.ImageRef)
 			if err != nil {
 				return err
 			}
-			item := g.data.Items[itemID]
 			item.Image = data
+		}
+		if i.Details.HitMaskRef != nil {
+			item.HitMask = g.loadHitMask(i.Details.HitMaskRef)
+		}
+		for _, anim := range i.Details.Animations {
+			frames, timing := g.loadAnimation(anim)
+			if len(frames) > 0 {
+				item.Animations[anim.Name] = frames
+				item.AnimationTimings[anim.Name] = timing
+				item.FrameHitMasks[anim.Name] = g.loadFrameHitMasks(anim, len(frames))
+			}
+		}
+		g.data.Items[itemID] = item
+		break
+	}
+	return nil
+}
+
+// mapAnimation builds the frames of an animation and how they are played, from the frames
+// load returns with their duration in milliseconds. Frames that can't be loaded are skipped.
+func mapAnimation[F any](animFrames []F, loop bool, load func(frame F) ([]byte, int, bool)) ([][]byte, model.AnimationTiming) {
+	var frames [][]byte
+	timing := model.AnimationTiming{Loop: loop}
+	for _, frame := range animFrames {
+		data, duration, ok := load(frame)
+		if !ok {
+			continue
+		}
+		frames = append(frames, data)
+		timing.Durations = append(timing.Durations, time.Duration(duration)*time.Millisecond)
+	}
+	return frames, timing
+}
+
+// loadAnimation loads the frames of a packaged animation and how they are played
+func (g *Game) loadAnimation(anim Animation) ([][]byte, model.AnimationTiming) {
+	return mapAnimation(anim.Frames, anim.Loop, func(frame AnimationFrame) ([]byte, int, bool) {
+		if frame.ImageRef == nil {
+			return nil, 0, false
+		}
+		data, err := g.resourceManager.LoadBinaryData(frame.ImageRef)
+		if err != nil {
+			log.Printf("Error loading animation frame: %v", err)
+			return nil, 0, false
+		}
+		return data, frame.Duration, true
+	})
+}
+
+// loadHitMask loads a packaged hit mask, nil if it can't be loaded (the engine builds it from the sprite)
+func (g *Game) loadHitMask(ref *BinaryRef) *model.HitMask {
+	data, err := g.resourceManager.LoadBinaryData(ref)
+	if err != nil {
+		log.Printf("Error loading hit mask: %v", err)
+		return nil
+	}
+	mask := &model.HitMask{}
+	if err := mask.UnmarshalBinary(data); err != nil {
+		log.Printf("Error decoding hit mask: %v", err)
+		return nil
+	}
+	return mask
+}
+
+// loadFrameHitMasks loads the hit masks of the frames of a packaged animation; they are all nil
+// when they don't match the frames loaded by loadAnimation
+func (g *Game) loadFrameHitMasks(anim Animation, frames int) []*model.HitMask {
+	masks := make([]*model.HitMask, 0, frames)
+	for _, frame := range anim.Frames {
+		if frame.ImageRef == nil {
+			continue
+		}
+		var mask *model.HitMask
+		if frame.HitMaskRef != nil {
+			mask = g.loadHitMask(frame.HitMaskRef)
+		}
+		masks = append(masks, mask)
+	}
+	if len(masks) != frames {
+		return make([]*model.HitMask, frames)
+	}
+	return masks
+}
+
+// LoadItemInventoryImage loads item inventory image on-demand
+func (g *Game) LoadItemInventoryImage(itemID string) error {
+	for _, i := range g.packagedData.Items {
+		if i.ID == itemID && i.Details != nil && i.Details.InventoryImageRef != nil {
+			data, err := g.resourceManager.LoadBinaryData(i.Details.InventoryImageRef)
+			if err != nil {
+				return err
+			}
+			item := g.data.Items[itemID]
+			item.InventoryImage = data
 			g.data.Items[itemID] = item
 			break
 		}
@@ -205,18 +459,18 @@ func (g *Game) LoadScript(scriptName string) error {
 	return nil
 }
 
-// LoadCursor loads cursor on-demand
+// LoadCursor loads the animations of a cursor set on-demand
 func (g *Game) LoadCursor(cursorName string) error {
 	for _, c := range g.packagedData.Cursors {
-		if c.Name == cursorName && c.Details != nil && len(c.Details.Animations) > 0 {
-			anim := c.Details.Animations[0]
-			if len(anim.Frames) > 0 && anim.Frames[0].ImageRef != nil {
-				data, err := g.resourceManager.LoadBinaryData(anim.Frames[0].ImageRef)
-				if err != nil {
-					return err
+		if c.Name == cursorName && c.Details != nil {
+			cursor := model.NewCursor(c.Name)
+			for _, anim := range c.Details.Animations {
+				frames, timing := g.loadAnimation(anim)
+				if len(frames) > 0 {
+					cursor.AddAnimation(anim.Name, frames, timing)
 				}
-				g.AddCursor(c.Name, data)
 			}
+			g.AddCursorSet(cursor)
 			break
 		}
 	}
@@ -1,5 +1,10 @@
 package logic
 
+import (
+	"chemistry/engine/model"
+	"image"
+)
+
 // BinaryRef represents a reference to binary data in the packaged file
 type BinaryRef struct {
 	Offset int64 `json:"offset"`
@@ -8,15 +13,48 @@ type BinaryRef struct {
 
 // PackagedGameData represents the new packaged game data format
 type PackagedGameData struct {
-	ProjectName  string      `json:"projectName"`
-	Version      string      `json:"version"`
-	DiagramNodes []TypedNode `json:"diagramNodes"`
-	Locations    []Location  `json:"locations"`
-	Characters   []Character `json:"characters"`
-	Items        []Item      `json:"items"`
-	Fonts        []Font      `json:"fonts"`
-	Scripts      []Script    `json:"scripts"`
-	Cursors      []Cursor    `json:"cursors"`
+	ProjectName  string             `json:"projectName"`
+	Version      string             `json:"version"`
+	DiagramNodes []TypedNode        `json:"diagramNodes"`
+	Locations    []Location         `json:"locations"`
+	Characters   []Character        `json:"characters"`
+	Items        []Item             `json:"items"`
+	Fonts        []Font             `json:"fonts"`
+	Scripts      []Script           `json:"scripts"`
+	Cursors      []Cursor           `json:"cursors"`
+	Dialogues    []Dialogue         `json:"dialogues,omitempty"`
+	Interface    *InterfaceSettings `json:"interface,omitempty"`
+}
+
+// InterfaceSettings is the project-level layout of the verb interface, in screen coordinates.
+// Missing values keep the engine defaults.
+type InterfaceSettings struct {
+	Verbs        []string          `json:"verbs,omitempty"`
+	VerbLabels   map[string]string `json:"verbLabels,omitempty"`
+	Prepositions map[string]string `json:"prepositions,omitempty"`
+	VerbColumns  int               `json:"verbColumns,omitempty"`
+	SentenceLine *ScreenRect       `json:"sentenceLine,omitempty"`
+	VerbBar      *ScreenRect       `json:"verbBar,omitempty"`
+	Inventory    *ScreenRect       `json:"inventory,omitempty"`
+	Cursors      *CursorSettings   `json:"cursors,omitempty"`
+}
+
+// CursorSettings picks the cursor set (the name of a cursor) shown for each verb.
+// The animations of a cursor set are named after what the cursor is over, see CursorState.
+type CursorSettings struct {
+	Default string            `json:"default,omitempty"`
+	Verbs   map[string]string `json:"verbs,omitempty"`
+}
+
+type ScreenRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+func (r ScreenRect) Rectangle() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
 }
 
 type TypedNode struct {
@@ -31,6 +69,7 @@ type TypedNode struct {
 	With        string          `json:"with,omitempty"`
 	Where       string          `json:"where,omitempty"`
 	Script      string          `json:"script,omitempty"`
+	Dialogue    string          `json:"dialogue,omitempty"`
 	Description string          `json:"description,omitempty"`
 	Flags       []NodeFlag      `json:"flags,omitempty"`
 }
@@ -98,13 +137,27 @@ type Cursor struct {
 	Details  *CursorDetails `json:"details,omitempty"`
 }
 
+type Dialogue struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Name     string           `json:"name"`
+	Internal bool             `json:"internal,omitempty"`
+	Details  *DialogueDetails `json:"details,omitempty"`
+}
+
 type LocationDetails struct {
-	Description      string         `json:"description,omitempty"`
-	BackgroundRef    *BinaryRef     `json:"backgroundRef,omitempty"`
-	WalkableArea     []Polygon      `json:"walkableArea,omitempty"`
-	PlacedItems      []PlacedEntity `json:"placedItems,omitempty"`
-	PlacedCharacters []PlacedEntity `json:"placedCharacters,omitempty"`
-	BackgroundColor  string         `json:"backgroundColor,omitempty"`
+	Description      string              `json:"description,omitempty"`
+	BackgroundRef    *BinaryRef          `json:"backgroundRef,omitempty"`
+	WalkableArea     []Polygon           `json:"walkableArea,omitempty"` // The default area, always enabled
+	WalkableAreas    []NamedWalkableArea `json:"walkableAreas,omitempty"`
+	ScaleArea        *ScaleArea          `json:"scaleArea,omitempty"`
+	Layers           []LocationLayer     `json:"layers,omitempty"`
+	WalkBehinds      []WalkBehind        `json:"walkBehinds,omitempty"`
+	Exits            []Exit              `json:"exits,omitempty"`
+	Hotspots         []Hotspot           `json:"hotspots,omitempty"`
+	PlacedItems      []PlacedEntity      `json:"placedItems,omitempty"`
+	PlacedCharacters []PlacedEntity      `json:"placedCharacters,omitempty"`
+	BackgroundColor  string              `json:"backgroundColor,omitempty"`
 }
 
 type CharacterDetails struct {
@@ -113,11 +166,13 @@ type CharacterDetails struct {
 	InventoryImageRef *BinaryRef  `json:"inventoryImageRef,omitempty"`
 	Animations        []Animation `json:"animations,omitempty"`
 	InteractionSpot   *Point      `json:"interactionSpot,omitempty"`
+	TalkColor         string      `json:"talkColor,omitempty"` // #RRGGBB
 }
 
 type ItemDetails struct {
 	Description       string      `json:"description,omitempty"`
 	ImageRef          *BinaryRef  `json:"imageRef,omitempty"`
+	HitMaskRef        *BinaryRef  `json:"hitMaskRef,omitempty"`
 	CanBePickedUp     bool        `json:"canBePickedUp,omitempty"`
 	InventoryImageRef *BinaryRef  `json:"inventoryImageRef,omitempty"`
 	Animations        []Animation `json:"animations,omitempty"`
@@ -137,6 +192,9 @@ type CursorDetails struct {
 	Animations []Animation `json:"animations,omitempty"`
 }
 
+// Dialogues are packaged as the editor exports them, they have no binary resources
+type DialogueDetails = model.EditorDialogueDetails
+
 type Animation struct {
 	Name   string           `json:"name"`
 	Frames []AnimationFrame `json:"frames"`
@@ -144,16 +202,40 @@ type Animation struct {
 }
 
 type AnimationFrame struct {
+	ImageRef   *BinaryRef `json:"imageRef,omitempty"`
+	HitMaskRef *BinaryRef `json:"hitMaskRef,omitempty"` // Items only
+	Duration   int        `json:"duration,omitempty"`
+}
+
+// The geometry of a location is packaged as the editor exports it
+type Point = model.EditorPoint
+
+type Polygon = model.EditorPolygon
+
+// NamedWalkableArea is a walkable area scripts can enable and disable, Disabled is how it starts
+type NamedWalkableArea = model.EditorWalkableArea
+
+// LocationLayer is an image drawn over the background, in front of the actors when ZOrder is above 0
+type LocationLayer struct {
 	ImageRef *BinaryRef `json:"imageRef,omitempty"`
-	Duration int        `json:"duration,omitempty"`
+	model.EditorLayerSettings
 }
 
-type Point struct {
-	X int `json:"x"`
-	Y int `json:"y"`
+// WalkBehind is a mask of the background that hides the characters standing above Baseline
+type WalkBehind struct {
+	MaskRef  *BinaryRef `json:"maskRef,omitempty"`
+	Baseline int        `json:"baseline"`
 }
 
-type Polygon []Point
+// Exit is a region of a location that leads to TargetLocation (ID), entered at EntryPoint.
+// OnExit and OnEnter are the names of the scripts run when leaving and arriving.
+type Exit = model.EditorExit
+
+// Hotspot is an interactive region of the background, targeted by actions through its ID
+type Hotspot = model.EditorHotspot
+
+// ScaleArea is the scale of the characters at two Y lines of a location, packaged as the editor exports it
+type ScaleArea = model.EditorScaleArea
 
 type PlacedEntity struct {
 	EntityID        string `json:"entityId"`
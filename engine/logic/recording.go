@@ -0,0 +1,120 @@
+package logic
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+)
+
+// recordingVersion is the current version of the input recording format
+const recordingVersion = 1
+
+// RecordingHeader is the first line of an input recording. A recording can only be
+// replayed on the game data it was recorded on, starting from the same random seed.
+type RecordingHeader struct {
+	Version  int    `json:"version"`
+	DataHash string `json:"dataHash"`
+	Seed     int64  `json:"seed"`
+}
+
+// RecordedTick is a line of an input recording: the input events handled by a tick
+type RecordedTick struct {
+	Tick   int          `json:"tick"`
+	Events []InputEvent `json:"events"`
+}
+
+// SetRandomSeed restarts the random numbers of the game (e.g. Lua math.random) from seed
+func (g *Game) SetRandomSeed(seed int64) {
+	g.state.randomSeed = seed
+	g.state.random = rand.New(rand.NewSource(seed))
+}
+
+// RandomSeed returns the seed the random numbers of the game started from
+func (g *Game) RandomSeed() int64 {
+	return g.state.randomSeed
+}
+
+// InputRecorder writes the input of the player, tick by tick, as JSON lines
+type InputRecorder struct {
+	encoder *json.Encoder
+}
+
+// NewInputRecorder starts a recording of the input of game on writer
+func NewInputRecorder(writer io.Writer, game *Game) (*InputRecorder, error) {
+	recorder := &InputRecorder{encoder: json.NewEncoder(writer)}
+	header := RecordingHeader{
+		Version:  recordingVersion,
+		DataHash: game.DataHash(),
+		Seed:     game.RandomSeed(),
+	}
+	if err := recorder.encoder.Encode(header); err != nil {
+		return nil, fmt.Errorf("error writing recording header: %w", err)
+	}
+	return recorder, nil
+}
+
+// Record writes the events handled by a tick; ticks without events are not written
+func (r *InputRecorder) Record(tick int, events []InputEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.encoder.Encode(RecordedTick{Tick: tick, Events: events})
+}
+
+// InputReplay feeds the input of a recording back to the game, tick by tick
+type InputReplay struct {
+	ticks []RecordedTick
+	next  int
+}
+
+// LoadInputReplay reads a recording made with InputRecorder and gives game the random seed it was recorded with.
+// It fails when the recording was made on different game data.
+func LoadInputReplay(reader io.Reader, game *Game) (*InputReplay, error) {
+	decoder := json.NewDecoder(bufio.NewReader(reader))
+
+	var header RecordingHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, fmt.Errorf("error reading recording header: %w", err)
+	}
+	if header.Version != recordingVersion {
+		return nil, fmt.Errorf("unsupported recording version %d", header.Version)
+	}
+	if header.DataHash != game.DataHash() {
+		return nil, fmt.Errorf("the recording was made on different game data (hash %s, loaded %s)", header.DataHash, game.DataHash())
+	}
+
+	replay := &InputReplay{ticks: make([]RecordedTick, 0)}
+	for {
+		var tick RecordedTick
+		err := decoder.Decode(&tick)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading recorded tick %d: %w", len(replay.ticks)+1, err)
+		}
+		replay.ticks = append(replay.ticks, tick)
+	}
+
+	game.SetRandomSeed(header.Seed)
+	return replay, nil
+}
+
+// Events returns the events recorded for a tick
+func (r *InputReplay) Events(tick int) []InputEvent {
+	for r.next < len(r.ticks) && r.ticks[r.next].Tick < tick {
+		r.next++
+	}
+	if r.next < len(r.ticks) && r.ticks[r.next].Tick == tick {
+		r.next++
+		return r.ticks[r.next-1].Events
+	}
+	return nil
+}
+
+// Finished reports whether every recorded event has been replayed
+func (r *InputReplay) Finished() bool {
+	return r.next >= len(r.ticks)
+}
@@ -0,0 +1,91 @@
+package logic
+
+import (
+	"bytes"
+	"chemistry/engine/model"
+	"image"
+	"testing"
+)
+
+func newRecordingTestGame(t *testing.T) *Game {
+	game := newPortoTestGame(testImage(t, ScreenWidth, ScreenHeight), func(game *Game, porto *model.Location) {
+		game.dataHash = "test-data"
+		game.AddItem(model.NewItem("dice-id", "Dice", false, true, testImage(t, 10, 10)))
+		porto.AddItem("dice-id", model.ItemLocation{LocationPoint: image.Pt(300, 250), InteractionPoint: image.Pt(305, 300)})
+		game.AddAction(model.NewAction("guybrush-id", model.USE, "dice-id", model.NOTHING, "porto-id", `
+			game:SetCounter("roll", math.random(1000000))
+		`, nil, nil, nil))
+	})
+	game.Tick(nil)
+	return game
+}
+
+func TestReplayReproducesRecordedSession(t *testing.T) {
+	game := newRecordingTestGame(t)
+	game.SetRandomSeed(42)
+
+	var recording bytes.Buffer
+	recorder, err := NewInputRecorder(&recording, game)
+	if err != nil {
+		t.Fatalf("NewInputRecorder failed: %v", err)
+	}
+
+	// USE the dice (the roll depends on the seed), then walk away
+	use := game.VerbRects()[3].Min.Add(image.Pt(2, 2))
+	camera := game.GetCamera()
+	dice := image.Pt(305-int(camera.Position[0]), 255-int(camera.Position[1]))
+	input := map[int][]InputEvent{
+		10:  {{Type: CURSOR_MOVED, X: use.X, Y: use.Y}, {Type: LEFT_CLICK, X: use.X, Y: use.Y}},
+		20:  {{Type: CURSOR_MOVED, X: dice.X, Y: dice.Y}},
+		21:  {{Type: LEFT_CLICK, X: dice.X, Y: dice.Y}},
+		200: {{Type: LEFT_CLICK, X: dice.X + 300, Y: dice.Y + 80}},
+	}
+	run := func(game *Game, events func(tick int) []InputEvent) {
+		for i := 0; i < 8*TicksPerSecond; i++ {
+			game.Tick(events(game.TickCount()))
+		}
+	}
+	run(game, func(tick int) []InputEvent {
+		if err := recorder.Record(tick, input[tick]); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		return input[tick]
+	})
+	if game.GetCounter("roll") == 0 {
+		t.Fatalf("expected the dice rolled while recording")
+	}
+
+	replayed := newRecordingTestGame(t)
+	replayed.SetRandomSeed(7)
+	replay, err := LoadInputReplay(bytes.NewReader(recording.Bytes()), replayed)
+	if err != nil {
+		t.Fatalf("LoadInputReplay failed: %v", err)
+	}
+	if replayed.RandomSeed() != 42 {
+		t.Errorf("expected the recorded seed, got %d", replayed.RandomSeed())
+	}
+	run(replayed, replay.Events)
+	if !replay.Finished() {
+		t.Errorf("expected every recorded event replayed")
+	}
+
+	if a, b := game.GetCurrentCharacterPosition(), replayed.GetCurrentCharacterPosition(); a != b || a == image.Pt(100, 300) {
+		t.Errorf("expected the same walk, got %v and %v", a, b)
+	}
+	if game.GetCounter("roll") != replayed.GetCounter("roll") {
+		t.Errorf("expected the same roll, got %d and %d", game.GetCounter("roll"), replayed.GetCounter("roll"))
+	}
+}
+
+func TestReplayRejectsOtherGameData(t *testing.T) {
+	var recording bytes.Buffer
+	if _, err := NewInputRecorder(&recording, newRecordingTestGame(t)); err != nil {
+		t.Fatalf("NewInputRecorder failed: %v", err)
+	}
+
+	other := newRecordingTestGame(t)
+	other.dataHash = "other-data"
+	if _, err := LoadInputReplay(&recording, other); err == nil {
+		t.Errorf("expected an error replaying on different game data")
+	}
+}
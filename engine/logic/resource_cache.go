@@ -0,0 +1,71 @@
+package logic
+
+import "fmt"
+
+// ResourceCache keeps the resources decoded from the game data (e.g. the textures and the font
+// faces of a renderer), so that each of them is decoded once. Resources that belong to a location
+// are evicted when the game leaves it, the others (fonts, inventory images) stay until Clear.
+type ResourceCache[T any] struct {
+	entries map[string]cachedResource[T]
+	release func(T)
+}
+
+type cachedResource[T any] struct {
+	value    T
+	err      error
+	location string
+}
+
+// NewResourceCache creates a cache evicted by game when it leaves a location; release, if not nil,
+// frees the resources removed from the cache
+func NewResourceCache[T any](game *Game, release func(T)) *ResourceCache[T] {
+	cache := &ResourceCache[T]{entries: make(map[string]cachedResource[T]), release: release}
+	game.OnLeaveLocation(cache.EvictLocation)
+	return cache
+}
+
+// FrameKey is the cache key of a frame of an animation of a character or of an item
+func FrameKey(entityID string, animation string, frame int) string {
+	return fmt.Sprintf("%s/%s/%d", entityID, animation, frame)
+}
+
+// Get returns the resource of key, decoding it with decode the first time. location is the ID of
+// the location the resource belongs to, "" if it is used everywhere. Decoding errors are cached
+// too, so a broken resource is not decoded again on every frame.
+func (c *ResourceCache[T]) Get(key string, location string, decode func() (T, error)) (T, error) {
+	if entry, exists := c.entries[key]; exists {
+		return entry.value, entry.err
+	}
+
+	value, err := decode()
+	c.entries[key] = cachedResource[T]{value: value, err: err, location: location}
+	return value, err
+}
+
+// EvictLocation removes the resources of a location
+func (c *ResourceCache[T]) EvictLocation(location string) {
+	for key, entry := range c.entries {
+		if entry.location == location && location != "" {
+			c.remove(key, entry)
+		}
+	}
+}
+
+// Clear removes every resource
+func (c *ResourceCache[T]) Clear() {
+	for key, entry := range c.entries {
+		c.remove(key, entry)
+	}
+}
+
+// Len returns the number of resources in the cache
+func (c *ResourceCache[T]) Len() int {
+	return len(c.entries)
+}
+
+func (c *ResourceCache[T]) remove(key string, entry cachedResource[T]) {
+	delete(c.entries, key)
+	if c.release != nil && entry.err == nil {
+		c.release(entry.value)
+	}
+}
@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"errors"
+	"testing"
+)
+
+func TestResourceCacheDecodesOnceAndEvictsLeftLocation(t *testing.T) {
+	game := newCharactersTestGame()
+	game.AddLocation(model.NewLocation("isola-id", "Isola", nil))
+
+	released := make([]string, 0)
+	cache := NewResourceCache(game, func(value string) {
+		released = append(released, value)
+	})
+	decodes := 0
+	decode := func(value string) func() (string, error) {
+		return func() (string, error) {
+			decodes++
+			return value, nil
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if value, err := cache.Get(FrameKey("pirate-id", "IDLE", 0), "porto-id", decode("pirate")); err != nil || value != "pirate" {
+			t.Fatalf("unexpected resource %q, %v", value, err)
+		}
+	}
+	cache.Get("font/MonkeyIsland", "", decode("font"))
+	if decodes != 2 {
+		t.Fatalf("expected each resource decoded once, got %d decodes", decodes)
+	}
+
+	// A broken resource is not decoded again
+	broken := func() (string, error) {
+		decodes++
+		return "", errors.New("broken")
+	}
+	cache.Get("barrel-id//0", "porto-id", broken)
+	if _, err := cache.Get("barrel-id//0", "porto-id", broken); err == nil || decodes != 3 {
+		t.Fatalf("expected the cached error, got %v after %d decodes", err, decodes)
+	}
+
+	game.SetCurrentLocation("Isola")
+	if cache.Len() != 1 || len(released) != 1 || released[0] != "pirate" {
+		t.Errorf("expected only the font to survive leaving Porto, got %d resources, released %v", cache.Len(), released)
+	}
+
+	cache.Clear()
+	if cache.Len() != 0 || len(released) != 2 {
+		t.Errorf("expected an empty cache, got %d resources, released %v", cache.Len(), released)
+	}
+}
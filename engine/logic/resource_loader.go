@@ -80,6 +80,7 @@ func (g *Game) LoadPackagedGameData(dataFilePath string) error {
 
 	// Store packaged data for on-demand loading
 	g.packagedData = &packagedData
+	g.dataHash = hashGameData(jsonData)
 
 	// Map basic data structure (without loading binary resources)
 	return g.mapPackagedDataIndex(packagedData)
@@ -0,0 +1,367 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// saveGameVersion is the current version of the on-disk save game format.
+// Bump it whenever SaveGameData changes in a non backward compatible way.
+const saveGameVersion = 1
+
+const defaultSaveDirectory = "saves"
+
+// SaveGameData is the on-disk representation of a saved game
+type SaveGameData struct {
+	Version          int                                     `json:"version"`
+	ProjectName      string                                  `json:"projectName,omitempty"`
+	SavedAt          time.Time                               `json:"savedAt"`
+	Flags            map[string]bool                         `json:"flags"`
+	Counters         map[string]int                          `json:"counters"`
+	CurrentLocation  string                                  `json:"currentLocation"`
+	CurrentCharacter string                                  `json:"currentCharacter"`
+	Position         Point                                   `json:"position"`
+	Direction        string                                  `json:"direction"`
+	Animation        string                                  `json:"animation"`
+	AnimationFrame   int                                     `json:"animationFrame"`
+	CurrentVerb      string                                  `json:"currentVerb"`
+	Inventories      map[string]map[string]int               `json:"inventories"`
+	LocationItems    map[string]map[string]SavedItemLocation `json:"locationItems"`
+	ChosenOptions    []string                                `json:"chosenDialogueOptions,omitempty"`
+	Characters       map[string]SavedCharacter               `json:"characters,omitempty"`
+	ScriptGlobals    map[string]any                          `json:"scriptGlobals,omitempty"`
+	WalkableAreas    map[string]map[string]bool              `json:"walkableAreas,omitempty"`
+	TextSpeed        int                                     `json:"textSpeed,omitempty"` // Characters per second, the default when missing
+}
+
+// SavedCharacter is the saved runtime state of a character other than the current one
+type SavedCharacter struct {
+	Location       string `json:"location"`
+	Position       Point  `json:"position"`
+	Direction      string `json:"direction"`
+	Animation      string `json:"animation"`
+	AnimationFrame int    `json:"animationFrame"`
+}
+
+// SavedItemLocation is the saved placement of an item inside a location, and the animation it is playing
+type SavedItemLocation struct {
+	LocationPoint    Point  `json:"locationPoint"`
+	InteractionPoint Point  `json:"interactionPoint"`
+	Animation        string `json:"animation,omitempty"`
+	AnimationFrame   int    `json:"animationFrame,omitempty"`
+}
+
+// SetSaveDirectory sets the folder where save game slots are written
+func (g *Game) SetSaveDirectory(directory string) {
+	g.saveDirectory = directory
+}
+
+func (g *Game) saveSlotPath(slot int) string {
+	directory := g.saveDirectory
+	if directory == "" {
+		directory = defaultSaveDirectory
+	}
+	return filepath.Join(directory, fmt.Sprintf("slot_%d.sav", slot))
+}
+
+// SaveGame writes the current game state to the given numbered slot
+func (g *Game) SaveGame(slot int) error {
+	if slot < 0 {
+		return fmt.Errorf("invalid save slot %d", slot)
+	}
+
+	data := g.snapshotSaveGame()
+
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding save game: %w", err)
+	}
+
+	path := g.saveSlotPath(slot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating save directory: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated slot behind
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0644); err != nil {
+		return fmt.Errorf("error writing save game %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("error writing save game %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// LoadGame restores the game state saved in the given numbered slot
+func (g *Game) LoadGame(slot int) error {
+	path := g.saveSlotPath(slot)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading save game %s: %w", path, err)
+	}
+
+	var data SaveGameData
+	if err := json.Unmarshal(content, &data); err != nil {
+		return fmt.Errorf("error decoding save game %s: %w", path, err)
+	}
+
+	if data.Version < 1 || data.Version > saveGameVersion {
+		return fmt.Errorf("unsupported save game version %d (expected <= %d)", data.Version, saveGameVersion)
+	}
+
+	if g.packagedData != nil && data.ProjectName != "" && data.ProjectName != g.packagedData.ProjectName {
+		return fmt.Errorf("save game belongs to project '%s', not '%s'", data.ProjectName, g.packagedData.ProjectName)
+	}
+
+	return g.restoreSaveGame(data)
+}
+
+func (g *Game) snapshotSaveGame() SaveGameData {
+	current := g.currentCharacterState()
+	data := SaveGameData{
+		Version:        saveGameVersion,
+		SavedAt:        time.Now(),
+		Flags:          make(map[string]bool),
+		Counters:       make(map[string]int),
+		Position:       Point{X: current.Position.X, Y: current.Position.Y},
+		Direction:      string(current.Direction),
+		Animation:      current.Animation,
+		AnimationFrame: current.AnimationFrame,
+		CurrentVerb:    string(g.state.currentVerb),
+		TextSpeed:      g.state.textSpeed,
+		Inventories:    make(map[string]map[string]int),
+		LocationItems:  make(map[string]map[string]SavedItemLocation),
+		Characters:     make(map[string]SavedCharacter),
+	}
+
+	if g.packagedData != nil {
+		data.ProjectName = g.packagedData.ProjectName
+	}
+
+	for flag, value := range g.state.flags {
+		data.Flags[flag] = value
+	}
+	for counter, value := range g.state.counters {
+		data.Counters[counter] = value
+	}
+
+	if key, exists := g.locationKey(g.state.currentLocation.ID); exists && g.state.currentLocation.ID != "" {
+		data.CurrentLocation = key
+	}
+	if key, exists := g.characterKey(g.state.currentCharacter.ID); exists && g.state.currentCharacter.ID != "" {
+		data.CurrentCharacter = key
+	}
+
+	for option, chosen := range g.state.chosenDialogueOptions {
+		if chosen {
+			data.ChosenOptions = append(data.ChosenOptions, option)
+		}
+	}
+	sort.Strings(data.ChosenOptions)
+
+	for key, character := range g.data.Character {
+		inventory := make(map[string]int)
+		for itemID, slot := range character.Inventory {
+			inventory[itemID] = slot.Count
+		}
+		data.Inventories[key] = inventory
+	}
+
+	for id, state := range g.state.characters {
+		if id == "" || id == g.state.currentCharacter.ID {
+			continue
+		}
+		characterKey, exists := g.characterKey(id)
+		if !exists {
+			continue
+		}
+		locationKey, _ := g.locationKey(state.Location)
+		data.Characters[characterKey] = SavedCharacter{
+			Location:       locationKey,
+			Position:       Point{X: state.Position.X, Y: state.Position.Y},
+			Direction:      string(state.Direction),
+			Animation:      state.Animation,
+			AnimationFrame: state.AnimationFrame,
+		}
+	}
+
+	data.ScriptGlobals = g.scriptGlobals()
+
+	for locationID, areas := range g.state.walkableAreas {
+		key, exists := g.locationKey(locationID)
+		if !exists || len(areas) == 0 {
+			continue
+		}
+		if data.WalkableAreas == nil {
+			data.WalkableAreas = make(map[string]map[string]bool)
+		}
+		data.WalkableAreas[key] = make(map[string]bool)
+		for name, enabled := range areas {
+			data.WalkableAreas[key][name] = enabled
+		}
+	}
+
+	for key, location := range g.data.Locations {
+		items := make(map[string]SavedItemLocation)
+		for itemID, itemLocation := range location.Items {
+			saved := SavedItemLocation{
+				LocationPoint:    Point{X: itemLocation.LocationPoint.X, Y: itemLocation.LocationPoint.Y},
+				InteractionPoint: Point{X: itemLocation.InteractionPoint.X, Y: itemLocation.InteractionPoint.Y},
+			}
+			if player, exists := g.state.itemAnimations[itemID]; exists {
+				saved.Animation = player.Animation
+				saved.AnimationFrame = player.AnimationFrame
+			}
+			items[itemID] = saved
+		}
+		data.LocationItems[key] = items
+	}
+
+	return data
+}
+
+func (g *Game) restoreSaveGame(data SaveGameData) error {
+	if data.CurrentLocation != "" {
+		if _, exists := g.data.Locations[data.CurrentLocation]; !exists {
+			return fmt.Errorf("save game references unknown location '%s'", data.CurrentLocation)
+		}
+	}
+	if data.CurrentCharacter != "" {
+		if _, exists := g.data.Character[data.CurrentCharacter]; !exists {
+			return fmt.Errorf("save game references unknown character '%s'", data.CurrentCharacter)
+		}
+	}
+
+	g.state.flags = make(map[string]bool)
+	for flag, value := range data.Flags {
+		g.state.flags[flag] = value
+	}
+	g.state.counters = make(map[string]int)
+	for counter, value := range data.Counters {
+		g.state.counters[counter] = value
+	}
+
+	g.state.chosenDialogueOptions = make(map[string]bool)
+	for _, option := range data.ChosenOptions {
+		g.state.chosenDialogueOptions[option] = true
+	}
+
+	g.SetTextSpeed(data.TextSpeed)
+
+	// Before SetCurrentLocation, which builds the path finder from the enabled areas
+	g.state.walkableAreas = make(map[string]map[string]bool)
+	for key, areas := range data.WalkableAreas {
+		location, exists := g.data.Locations[key]
+		if !exists {
+			continue
+		}
+		g.state.walkableAreas[location.ID] = make(map[string]bool)
+		for name, enabled := range areas {
+			g.state.walkableAreas[location.ID][name] = enabled
+		}
+	}
+
+	// Items removed from (or added to) a location are restored by rebuilding its item map
+	g.state.itemAnimations = make(map[string]*AnimationPlayer)
+	for key, location := range g.data.Locations {
+		savedItems, exists := data.LocationItems[key]
+		if !exists {
+			continue
+		}
+		location.Items = make(map[string]model.ItemLocation)
+		for itemID, saved := range savedItems {
+			location.AddItem(itemID, model.ItemLocation{
+				LocationPoint:    image.Point{X: saved.LocationPoint.X, Y: saved.LocationPoint.Y},
+				InteractionPoint: image.Point{X: saved.InteractionPoint.X, Y: saved.InteractionPoint.Y},
+			})
+			if saved.Animation != "" {
+				// The frame is shown for its whole duration from now on
+				g.state.itemAnimations[itemID] = &AnimationPlayer{Animation: saved.Animation, AnimationFrame: saved.AnimationFrame, frameStarted: g.now()}
+			}
+		}
+		g.data.Locations[key] = location
+	}
+
+	for key, character := range g.data.Character {
+		savedInventory, exists := data.Inventories[key]
+		if !exists {
+			continue
+		}
+		if character.Inventory == nil {
+			character.Inventory = make(map[string]model.InventorySlot)
+		}
+		// Clear in place: the current character shares the same map
+		clear(character.Inventory)
+		for itemID, count := range savedInventory {
+			character.Inventory[itemID] = model.InventorySlot{
+				Count: count,
+				Item:  g.data.Items[itemID],
+			}
+		}
+		g.data.Character[key] = character
+	}
+
+	// Characters not in the save get their placement again when their location is entered
+	g.state.characters = make(map[string]*CharacterState)
+	for key, saved := range data.Characters {
+		character, exists := g.data.Character[key]
+		if !exists {
+			continue
+		}
+		state := &CharacterState{
+			Position:        image.Point{X: saved.Position.X, Y: saved.Position.Y},
+			Direction:       model.CharacterDirection(saved.Direction),
+			AnimationPlayer: AnimationPlayer{Animation: saved.Animation, AnimationFrame: saved.AnimationFrame, frameStarted: g.now()},
+		}
+		if saved.Location != "" {
+			state.Location = g.data.Locations[saved.Location].ID
+		}
+		g.state.characters[character.ID] = state
+	}
+
+	// Transient state is never saved, start from a clean slate
+	g.state.watingActions = make([]string, 0)
+	g.state.textToDraw = make([]SpeechLine, 0)
+	g.state.pathPointIndex = 0
+	g.state.mainItemID = ""
+	g.state.secondItemID = ""
+	g.state.committedSentence = ""
+	g.state.cursorOnItem = ""
+	g.state.cursorOnInventory = ""
+	g.state.inventoryScroll = 0
+	g.state.dialogue = nil
+	g.cancelScripts()
+	g.restoreScriptGlobals(data.ScriptGlobals)
+
+	if data.CurrentCharacter != "" {
+		g.SetCurrentCharacter(data.CurrentCharacter)
+	}
+	if data.CurrentLocation != "" {
+		g.SetCurrentLocation(data.CurrentLocation)
+	}
+
+	g.SetCurrentCharacterPosition(image.Point{X: data.Position.X, Y: data.Position.Y})
+	g.SetCurrentCharacterDirection(model.CharacterDirection(data.Direction))
+	g.SetCurrentCharacterAnimationAtFrame(data.Animation, data.AnimationFrame)
+
+	// A character loaded inside an exit takes it only after walking out and back in
+	g.state.insideExit = g.exitIndexAt(data.Position.X, data.Position.Y)
+	g.state.pendingExit = -1
+	if data.CurrentVerb != "" {
+		g.SetCurrentVerb(model.Verb(data.CurrentVerb))
+	} else {
+		g.SetCurrentVerb(model.MOVE_TO)
+	}
+	g.SetCurrentState(model.IDLE)
+	g.state.CalculateYOrderedEntities()
+
+	return nil
+}
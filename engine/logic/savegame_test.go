@@ -0,0 +1,86 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"testing"
+)
+
+func TestSaveAndLoadGameRestoresWorld(t *testing.T) {
+	game := &Game{
+		data:  initGameData(),
+		state: initGameState(),
+	}
+	game.SetSaveDirectory(t.TempDir())
+
+	game.AddCharacter(model.NewCharacter("guybrush-id", "Guybrush", model.Color{R: 255, G: 255, B: 255}))
+	game.AddItem(model.NewItem("key-id", "Key", false, true, nil))
+	location := model.NewLocation("porto-id", "Porto", nil)
+	location.AddItem("key-id", model.ItemLocation{
+		LocationPoint:    image.Pt(10, 20),
+		InteractionPoint: image.Pt(15, 30),
+	})
+	game.AddLocation(location)
+
+	game.state.currentCharacter = game.GetCharacter("Guybrush")
+	game.SetFlag("DOOR_OPEN", true)
+	game.SetCounter("coins", 3)
+	game.SetCurrentCharacterPosition(image.Pt(180, 355))
+	game.SetCurrentCharacterDirection(model.LEFT)
+
+	game.ExecuteAction(composeTrigger("guybrush-id", model.PICK_UP, "key-id", model.NOTHING, "porto-id"))
+
+	if _, exists := game.GetCharacter("Guybrush").Inventory["key-id"]; !exists {
+		t.Fatalf("expected key in inventory after PICK_UP")
+	}
+	if _, exists := game.GetLocation("Porto").Items["key-id"]; exists {
+		t.Fatalf("expected key removed from location after PICK_UP")
+	}
+
+	if err := game.SaveGame(1); err != nil {
+		t.Fatalf("SaveGame failed: %v", err)
+	}
+
+	// Diverge from the saved state
+	game.SetFlag("DOOR_OPEN", false)
+	game.SetCounter("coins", 0)
+	game.SetCurrentCharacterPosition(image.Pt(0, 0))
+	delete(game.GetCharacter("Guybrush").Inventory, "key-id")
+	game.GetLocation("Porto").Items["key-id"] = model.ItemLocation{}
+
+	if err := game.LoadGame(1); err != nil {
+		t.Fatalf("LoadGame failed: %v", err)
+	}
+
+	if !game.GetFlag("DOOR_OPEN") {
+		t.Errorf("flag DOOR_OPEN not restored")
+	}
+	if game.GetCounter("coins") != 3 {
+		t.Errorf("counter coins: expected 3, got %d", game.GetCounter("coins"))
+	}
+	if game.GetCurrentCharacterPosition() != image.Pt(180, 355) {
+		t.Errorf("position: expected (180,355), got %v", game.GetCurrentCharacterPosition())
+	}
+	if game.GetCurrentCharacterDirection() != model.LEFT {
+		t.Errorf("direction: expected LEFT, got %s", game.GetCurrentCharacterDirection())
+	}
+	slot, exists := game.GetCharacter("Guybrush").Inventory["key-id"]
+	if !exists || slot.Count != 1 || slot.Item.ID != "key-id" {
+		t.Errorf("inventory not restored: %+v", slot)
+	}
+	if _, exists := game.GetLocation("Porto").Items["key-id"]; exists {
+		t.Errorf("picked up item reappeared in location")
+	}
+}
+
+func TestLoadGameMissingSlot(t *testing.T) {
+	game := &Game{
+		data:  initGameData(),
+		state: initGameState(),
+	}
+	game.SetSaveDirectory(t.TempDir())
+
+	if err := game.LoadGame(7); err == nil {
+		t.Errorf("expected error loading an empty slot")
+	}
+}
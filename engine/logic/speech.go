@@ -0,0 +1,120 @@
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultTextSpeed is how many characters per second the player reads
+	DefaultTextSpeed = 15
+	// minSpeechDuration is how long even the shortest line stays on screen
+	minSpeechDuration = 2500 * time.Millisecond
+)
+
+// SpeechLine is a line said by a character, shown over its sprite in its talk color
+type SpeechLine struct {
+	Speaker string // Character ID
+	Text    string
+}
+
+// SaySomething makes the current character say a sentence, after the lines already queued
+func (g *Game) SaySomething(sentence string) {
+	g.state.textToDraw = append(g.state.textToDraw, SpeechLine{Speaker: g.state.currentCharacter.ID, Text: sentence})
+}
+
+// Say makes a character (by ID or name, "" for the current one) say a sentence, after the lines already queued
+func (g *Game) Say(characterID string, sentence string) error {
+	if characterID == "" {
+		g.SaySomething(sentence)
+		return nil
+	}
+	character, exists := g.CharacterByID(characterID)
+	if !exists {
+		return fmt.Errorf("character '%s' not found", characterID)
+	}
+	g.state.textToDraw = append(g.state.textToDraw, SpeechLine{Speaker: character.ID, Text: sentence})
+	return nil
+}
+
+// Speech returns the line being said, false if nobody is talking
+func (g *Game) Speech() (SpeechLine, bool) {
+	if len(g.state.textToDraw) == 0 {
+		return SpeechLine{}, false
+	}
+	return g.state.textToDraw[0], true
+}
+
+// TextToDraw returns the line being said, or ""
+func (g *Game) TextToDraw() string {
+	line, _ := g.Speech()
+	return line.Text
+}
+
+// SetTextSpeed sets how many characters per second the player reads, DefaultTextSpeed if not positive
+func (g *Game) SetTextSpeed(charactersPerSecond int) {
+	if charactersPerSecond <= 0 {
+		charactersPerSecond = DefaultTextSpeed
+	}
+	g.state.textSpeed = charactersPerSecond
+}
+
+// TextSpeed returns how many characters per second the player reads
+func (g *Game) TextSpeed() int {
+	return g.state.textSpeed
+}
+
+// speechDuration returns how long a line stays on screen: the time to read it, at least minSpeechDuration
+func (g *Game) speechDuration(text string) time.Duration {
+	duration := time.Duration(utf8.RuneCountInString(text)) * time.Second / time.Duration(g.TextSpeed())
+	return max(duration, minSpeechDuration)
+}
+
+// SkipSpeech moves on to the next line, e.g. when the player has already read the current one
+func (g *Game) SkipSpeech() {
+	if len(g.state.textToDraw) == 0 {
+		return
+	}
+	if len(g.state.textToDraw) == 1 {
+		g.state.textToDraw = make([]SpeechLine, 0)
+	} else {
+		g.state.textToDraw = g.state.textToDraw[1:]
+	}
+	g.state.textDrawn = g.now()
+}
+
+// Nuova funzione per gestire il timer del testo
+func (g *Game) updateTextToDrawTimer() {
+	if len(g.state.textToDraw) > 0 {
+		if g.now()-g.state.textDrawn >= g.speechDuration(g.state.textToDraw[0].Text) {
+			g.SkipSpeech()
+		}
+	} else {
+		g.state.textDrawn = g.now()
+	}
+}
+
+// WrapText splits a line in rows no wider than maxWidth, breaking between words. width measures
+// a row (e.g. with the font it is drawn with); a word wider than maxWidth gets a row of its own.
+func WrapText(text string, maxWidth float64, width func(string) float64) []string {
+	rows := make([]string, 0)
+	row := ""
+	for _, word := range strings.Fields(text) {
+		if row == "" {
+			row = word
+			continue
+		}
+		if width(row+" "+word) > maxWidth {
+			rows = append(rows, row)
+			row = word
+			continue
+		}
+		row += " " + word
+	}
+	if row != "" {
+		rows = append(rows, row)
+	}
+	return rows
+}
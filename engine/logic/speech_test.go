@@ -0,0 +1,156 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSayQueuesLinesWithTheirSpeaker(t *testing.T) {
+	game := newCharactersTestGame()
+	game.StartScript("conversation", `
+		game:Say("Ahoy!")
+		game:Say("pirate-id", "Arr.")
+		game:SetFlag("done", true)
+	`, nil)
+
+	line, talking := game.Speech()
+	if !talking || line.Speaker != "guybrush-id" || line.Text != "Ahoy!" {
+		t.Fatalf("expected Guybrush talking first, got %+v", line)
+	}
+
+	game.state.textToDraw = make([]SpeechLine, 0)
+	game.updateScripts()
+	if line, _ := game.Speech(); line.Speaker != "pirate-id" || line.Text != "Arr." {
+		t.Fatalf("expected the pirate answering, got %+v", line)
+	}
+
+	game.state.textToDraw = make([]SpeechLine, 0)
+	game.updateScripts()
+	if !game.GetFlag("done") {
+		t.Errorf("expected the script over once both lines are read")
+	}
+
+	if err := game.Say("ghost-id", "Boo"); err == nil {
+		t.Errorf("expected an unknown speaker rejected")
+	}
+}
+
+func TestCharactersTalkInTheirColor(t *testing.T) {
+	cases := map[string]model.Color{
+		"":        {R: 255, G: 255, B: 255},
+		"#ff8000": {R: 255, G: 128},
+		"orange":  {R: 255, G: 255, B: 255},
+	}
+	for hex, expected := range cases {
+		if c := characterTalkColor("Pirate", hex); c != expected {
+			t.Errorf("talk color %q: expected %+v, got %+v", hex, expected, c)
+		}
+	}
+}
+
+func TestLongLinesStayLongerAtTheTextSpeed(t *testing.T) {
+	game := newCharactersTestGame()
+	long := strings.Repeat("a", 60)
+
+	if d := game.speechDuration("Hi"); d != minSpeechDuration {
+		t.Errorf("expected a short line shown for %v, got %v", minSpeechDuration, d)
+	}
+	if d := game.speechDuration(long); d != 4*time.Second {
+		t.Errorf("expected 60 characters read in 4s at %d characters per second, got %v", DefaultTextSpeed, d)
+	}
+
+	game.StartScript("options", `game:SetTextSpeed(30)`, nil)
+	if d := game.speechDuration(long); d != minSpeechDuration {
+		t.Errorf("expected 60 characters read in 2s at 30 characters per second, got %v", d)
+	}
+
+	// The player's text speed is saved, older saves get the default one
+	data := game.snapshotSaveGame()
+	game.SetTextSpeed(10)
+	if err := game.restoreSaveGame(data); err != nil {
+		t.Fatalf("restoreSaveGame failed: %v", err)
+	}
+	if game.TextSpeed() != 30 {
+		t.Errorf("expected the saved text speed 30, got %d", game.TextSpeed())
+	}
+	data.TextSpeed = 0
+	if err := game.restoreSaveGame(data); err != nil {
+		t.Fatalf("restoreSaveGame failed: %v", err)
+	}
+	if game.TextSpeed() != DefaultTextSpeed {
+		t.Errorf("expected the default text speed, got %d", game.TextSpeed())
+	}
+}
+
+func TestClickSkipsTheLineBeingSaid(t *testing.T) {
+	game := newCharactersTestGame()
+	game.StartScript("cutscene", `
+		game:Say("pirate-id", "One")
+		game:Say("pirate-id", "Two")
+		game:SetFlag("done", true)
+	`, nil)
+	game.Tick(nil)
+
+	game.Tick([]InputEvent{{Type: LEFT_CLICK, X: 10, Y: 10}})
+	if game.TextToDraw() != "Two" {
+		t.Fatalf("expected the click to skip to the next line, got '%s'", game.TextToDraw())
+	}
+	if game.GetCurrentState() != model.IDLE {
+		t.Errorf("expected the click not to move the character, got state %s", game.GetCurrentState())
+	}
+
+	game.Tick([]InputEvent{{Type: SKIP_TEXT}})
+	game.Tick(nil)
+	if game.TextToDraw() != "" || !game.GetFlag("done") {
+		t.Errorf("expected the skip key to end the conversation, got '%s'", game.TextToDraw())
+	}
+}
+
+func TestWrapTextBreaksBetweenWords(t *testing.T) {
+	width := func(row string) float64 {
+		return float64(len(row))
+	}
+	rows := WrapText("Look behind you, a three-headed monkey!", 16, width)
+	expected := []string{"Look behind you,", "a three-headed", "monkey!"}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %q, got %q", expected, rows)
+	}
+
+	if rows := WrapText("Supercalifragilistic", 5, width); !reflect.DeepEqual(rows, []string{"Supercalifragilistic"}) {
+		t.Errorf("expected a long word kept whole, got %q", rows)
+	}
+}
+
+func TestSpeakerPlaysTalkAnimationWhileTheLineIsShown(t *testing.T) {
+	game := newCharactersTestGame()
+	pirate := game.GetCharacter("Pirate")
+	pirate.Animations[string(model.IDLE_FACE_DOWN)] = [][]byte{{0}}
+	pirate.Animations[string(model.TALK_UP_TO_DOWN)] = [][]byte{{1}, {2}}
+	pirate.Animations["DANCE"] = [][]byte{{3}}
+	state, _ := game.GetCharacterState("pirate-id")
+
+	if err := game.Say("pirate-id", "Arr."); err != nil {
+		t.Fatalf("Say failed: %v", err)
+	}
+	game.Tick(nil)
+	if state.Animation != string(model.TALK_UP_TO_DOWN) {
+		t.Fatalf("expected the pirate talking facing down, got %s", state.Animation)
+	}
+
+	game.SkipSpeech()
+	game.Tick(nil)
+	if state.Animation != string(model.IDLE_FACE_DOWN) {
+		t.Fatalf("expected the pirate back to idle, got %s", state.Animation)
+	}
+
+	// A scripted animation is not interrupted
+	state.Play("DANCE", game.now())
+	game.Say("pirate-id", "Watch this!")
+	game.Tick(nil)
+	if state.Animation != "DANCE" {
+		t.Errorf("expected the pirate still dancing, got %s", state.Animation)
+	}
+}
@@ -0,0 +1,296 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"strings"
+)
+
+// InterfaceLayout is the screen layout of the verb interface: the sentence line,
+// the verb bar and the inventory. The room is shown above the topmost of them.
+type InterfaceLayout struct {
+	SentenceLine  image.Rectangle
+	VerbBar       image.Rectangle
+	VerbColumns   int
+	Inventory     image.Rectangle
+	VerbLabels    map[model.Verb]string
+	Prepositions  map[model.Verb]string
+	Cursors       map[model.Verb]string // Cursor set of each verb
+	DefaultCursor string                // Cursor set of the verbs without one
+}
+
+func defaultInterfaceLayout() InterfaceLayout {
+	return InterfaceLayout{
+		SentenceLine: image.Rect(0, 392, ScreenWidth, 420),
+		VerbBar:      image.Rect(0, 420, 400, ScreenHeight),
+		VerbColumns:  3,
+		Inventory:    image.Rect(400, 420, ScreenWidth, ScreenHeight),
+		VerbLabels: map[model.Verb]string{
+			model.MOVE_TO: "Walk to",
+			model.LOOK_AT: "Look at",
+			model.PICK_UP: "Pick up",
+			model.USE:     "Use",
+			model.TALK_TO: "Talk to",
+			model.GIVE_TO: "Give",
+		},
+		Prepositions: map[model.Verb]string{
+			model.USE:     "with",
+			model.GIVE_TO: "to",
+		},
+		Cursors: make(map[model.Verb]string),
+	}
+}
+
+// SetInterfaceSettings applies the project settings on top of the default interface layout
+func (g *Game) SetInterfaceSettings(settings *InterfaceSettings) {
+	layout := defaultInterfaceLayout()
+	if settings == nil {
+		g.data.Interface = layout
+		return
+	}
+
+	if settings.SentenceLine != nil {
+		layout.SentenceLine = settings.SentenceLine.Rectangle()
+	}
+	if settings.VerbBar != nil {
+		layout.VerbBar = settings.VerbBar.Rectangle()
+	}
+	if settings.Inventory != nil {
+		layout.Inventory = settings.Inventory.Rectangle()
+	}
+	if settings.VerbColumns > 0 {
+		layout.VerbColumns = settings.VerbColumns
+	}
+	for verb, label := range settings.VerbLabels {
+		layout.VerbLabels[model.Verb(verb)] = label
+	}
+	for verb, preposition := range settings.Prepositions {
+		layout.Prepositions[model.Verb(verb)] = preposition
+	}
+	if cursors := settings.Cursors; cursors != nil {
+		layout.DefaultCursor = cursors.Default
+		for verb, cursor := range cursors.Verbs {
+			layout.Cursors[model.Verb(verb)] = cursor
+		}
+	}
+
+	if len(settings.Verbs) > 0 {
+		g.data.Verbs = make([]model.Verb, 0, len(settings.Verbs))
+		for _, verb := range settings.Verbs {
+			g.data.Verbs = append(g.data.Verbs, model.Verb(verb))
+		}
+	}
+
+	g.data.Interface = layout
+}
+
+// InterfaceLayout returns the screen layout of the verb interface
+func (g *Game) InterfaceLayout() InterfaceLayout {
+	return g.data.Interface
+}
+
+// Verbs returns the verbs of the verb bar, in the order they are shown
+func (g *Game) Verbs() []model.Verb {
+	return g.data.Verbs
+}
+
+func (g *Game) IsInterfaceVisible() bool {
+	return g.GetCurrentState() != model.IN_DIALOGUE && g.state.currentCharacter.ID != ""
+}
+
+// IsOverInterface reports whether the given screen position is covered by the verb interface
+func (g *Game) IsOverInterface(x int, y int) bool {
+	if !g.IsInterfaceVisible() {
+		return false
+	}
+	layout := g.data.Interface
+	point := image.Pt(x, y)
+	return point.In(layout.SentenceLine) || point.In(layout.VerbBar) || point.In(layout.Inventory)
+}
+
+// RoomViewHeight returns the height of the screen area where the room is visible
+func (g *Game) RoomViewHeight() int {
+	top := ScreenHeight
+	for _, rect := range []image.Rectangle{g.data.Interface.SentenceLine, g.data.Interface.VerbBar, g.data.Interface.Inventory} {
+		if !rect.Empty() && rect.Min.Y < top {
+			top = rect.Min.Y
+		}
+	}
+	return top
+}
+
+// VerbRects returns the screen area of every verb of the verb bar, in GameData.Verbs order
+func (g *Game) VerbRects() []image.Rectangle {
+	bar := g.data.Interface.VerbBar
+	columns := max(g.data.Interface.VerbColumns, 1)
+	rows := (len(g.data.Verbs) + columns - 1) / columns
+	if rows == 0 {
+		return nil
+	}
+
+	width := bar.Dx() / columns
+	height := bar.Dy() / rows
+	rects := make([]image.Rectangle, len(g.data.Verbs))
+	for i := range rects {
+		x := bar.Min.X + (i%columns)*width
+		y := bar.Min.Y + (i/columns)*height
+		rects[i] = image.Rect(x, y, x+width, y+height)
+	}
+	return rects
+}
+
+// VerbAt returns the verb under the given screen position, or ""
+func (g *Game) VerbAt(x int, y int) model.Verb {
+	for i, rect := range g.VerbRects() {
+		if image.Pt(x, y).In(rect) {
+			return g.data.Verbs[i]
+		}
+	}
+	return ""
+}
+
+// VerbLabel returns the text shown for a verb in the verb bar and in the sentence line
+func (g *Game) VerbLabel(verb model.Verb) string {
+	if label, exists := g.data.Interface.VerbLabels[verb]; exists {
+		return label
+	}
+	return string(verb)
+}
+
+// SelectVerb starts a new sentence with the given verb
+func (g *Game) SelectVerb(verb model.Verb) {
+	g.ClearSentence()
+	g.SetCurrentVerb(verb)
+}
+
+// ClearSentence drops the objects picked for the sentence being built
+func (g *Game) ClearSentence() {
+	g.state.mainItemID = ""
+	g.state.secondItemID = ""
+	if g.GetCurrentState() == model.WAITING_ACTION {
+		g.SetCurrentState(model.IDLE)
+	}
+}
+
+// entityName returns the name shown to the player for an item, a character or a hotspot
+func (g *Game) entityName(id string) string {
+	if item, exists := g.data.Items[id]; exists {
+		return item.Name
+	}
+	if key, exists := g.characterKey(id); exists {
+		return g.data.Character[key].Name
+	}
+	if hotspot, exists := g.hotspot(id); exists {
+		return hotspot.Name
+	}
+	return ""
+}
+
+// SentenceLine returns the sentence being built, e.g. "Use Key with Door"
+func (g *Game) SentenceLine() string {
+	if g.state.committedSentence != "" {
+		return g.state.committedSentence
+	}
+	return g.buildSentence()
+}
+
+func (g *Game) buildSentence() string {
+	verb := g.GetCurrentVerb()
+	words := []string{g.VerbLabel(verb)}
+
+	hovered := g.state.cursorOnInventory
+	if hovered == "" {
+		hovered = g.state.cursorOnItem
+	}
+
+	if g.state.mainItemID != "" {
+		words = append(words, g.entityName(g.state.mainItemID))
+		if preposition := g.data.Interface.Prepositions[verb]; preposition != "" {
+			words = append(words, preposition)
+		}
+		if g.state.secondItemID != "" {
+			hovered = g.state.secondItemID
+		}
+		if hovered == g.state.mainItemID {
+			hovered = ""
+		}
+	}
+
+	if name := g.entityName(hovered); name != "" {
+		words = append(words, name)
+	}
+	return strings.Join(words, " ")
+}
+
+// commitSentence freezes the sentence line while the action it describes is carried out
+func (g *Game) commitSentence() {
+	g.state.committedSentence = g.buildSentence()
+}
+
+// selectRoomItem uses an item or a character of the current location as main or second object of the current verb
+func (g *Game) selectRoomItem(itemID string) {
+	verb := g.GetCurrentVerb()
+
+	if g.GetCurrentState() == model.WAITING_ACTION {
+		if itemID == g.state.mainItemID {
+			return
+		}
+		g.state.secondItemID = itemID
+		trigger := composeTrigger(g.state.currentCharacter.ID, verb, g.state.mainItemID, itemID, g.state.currentLocation.ID)
+		g.carryOutSentence(trigger, g.state.mainItemID, itemID)
+		return
+	}
+
+	if verb == model.USE && g.GetItem(itemID).UseWith {
+		g.stopCurrentActions()
+		g.state.mainItemID = itemID
+		g.SetCurrentState(model.WAITING_ACTION)
+		return
+	}
+
+	trigger := composeTrigger(g.state.currentCharacter.ID, verb, itemID, model.NOTHING, g.state.currentLocation.ID)
+	g.carryOutSentence(trigger, itemID)
+}
+
+// carryOutSentence executes trigger once the current character has reached the room object of the
+// sentence (the second object wins); with inventory objects only, the action is executed at once.
+// The sentence line keeps showing the action until it has been carried out.
+func (g *Game) carryOutSentence(trigger string, objects ...string) {
+	sentence := g.buildSentence()
+	g.state.mainItemID = ""
+	g.state.secondItemID = ""
+	g.SetCurrentVerb(model.MOVE_TO)
+	g.stopCurrentActions()
+
+	for i := len(objects) - 1; i >= 0; i-- {
+		point, inRoom := g.interactionPoint(objects[i])
+		if !inRoom {
+			continue
+		}
+		g.MoveTo(point.X, point.Y)
+		g.state.watingActions = append(g.state.watingActions, trigger)
+		g.SetCurrentState(model.EXECUTING_ACTION)
+		g.state.committedSentence = sentence
+		return
+	}
+
+	g.ExecuteAction(trigger)
+}
+
+// stopCurrentActions drops the queued actions and stops the current character where it is
+func (g *Game) stopCurrentActions() {
+	g.state.watingActions = make([]string, 0)
+	g.StopCharacterMovementAnimation()
+	g.SetCurrentState(model.IDLE)
+}
+
+// handleInterfaceClick handles a left click on the verb interface
+func (g *Game) handleInterfaceClick(x int, y int) {
+	if verb := g.VerbAt(x, y); verb != "" {
+		g.SelectVerb(verb)
+		return
+	}
+	if image.Pt(x, y).In(g.data.Interface.Inventory) {
+		g.handleInventoryClick(x, y)
+	}
+}
@@ -0,0 +1,68 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"testing"
+)
+
+func TestSentenceLineTracksObjects(t *testing.T) {
+	game := &Game{
+		data:  initGameData(),
+		state: initGameState(),
+	}
+	game.AddItem(model.NewItem("key", "Key", true, true, nil))
+	game.AddItem(model.NewItem("door", "Door", false, false, nil))
+	game.SetCurrentVerb(model.MOVE_TO)
+
+	game.state.cursorOnItem = "door"
+	if sentence := game.SentenceLine(); sentence != "Walk to Door" {
+		t.Errorf("expected 'Walk to Door', got '%s'", sentence)
+	}
+
+	game.SelectVerb(model.USE)
+	game.state.cursorOnItem = ""
+	game.state.cursorOnInventory = "key"
+	game.selectInventoryItem("key")
+	if game.GetCurrentState() != model.WAITING_ACTION {
+		t.Fatalf("expected state %s, got %s", model.WAITING_ACTION, game.GetCurrentState())
+	}
+
+	game.state.cursorOnInventory = ""
+	game.state.cursorOnItem = "door"
+	if sentence := game.SentenceLine(); sentence != "Use Key with Door" {
+		t.Errorf("expected 'Use Key with Door', got '%s'", sentence)
+	}
+
+	game.handleRightClick()
+	game.state.cursorOnItem = ""
+	if sentence := game.SentenceLine(); sentence != "Walk to" {
+		t.Errorf("expected right click to reset the sentence, got '%s'", sentence)
+	}
+}
+
+func TestInterfaceSettingsOverrideDefaults(t *testing.T) {
+	game := &Game{
+		data:  initGameData(),
+		state: initGameState(),
+	}
+	game.SetInterfaceSettings(&InterfaceSettings{
+		Verbs:        []string{"LOOK_AT", "USE"},
+		VerbLabels:   map[string]string{"USE": "Usa"},
+		Prepositions: map[string]string{"USE": "con"},
+		VerbColumns:  2,
+		VerbBar:      &ScreenRect{X: 0, Y: 500, Width: 200, Height: 40},
+	})
+
+	if len(game.data.Verbs) != 2 || game.data.Verbs[1] != model.USE {
+		t.Fatalf("expected verbs from settings, got %v", game.data.Verbs)
+	}
+	if verb := game.VerbAt(150, 520); verb != model.USE {
+		t.Errorf("expected USE in the second column of the verb bar, got '%s'", verb)
+	}
+	if label := game.VerbLabel(model.LOOK_AT); label != "Look at" {
+		t.Errorf("expected default label for LOOK_AT, got '%s'", label)
+	}
+	if label := game.VerbLabel(model.USE); label != "Usa" {
+		t.Errorf("expected overridden label for USE, got '%s'", label)
+	}
+}
@@ -0,0 +1,69 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"fmt"
+	"image"
+)
+
+// IsWalkableAreaEnabled reports whether characters can walk on a walkable area of a location
+func (g *Game) IsWalkableAreaEnabled(locationID string, area model.WalkableArea) bool {
+	if enabled, changed := g.state.walkableAreas[locationID][area.Name]; changed {
+		return enabled
+	}
+	return !area.Disabled
+}
+
+// SetWalkableAreaEnabled enables or disables the walkable areas with the given name of a location (key or ID).
+// In the current location the path finder is rebuilt, so characters walk on the new areas right away.
+func (g *Game) SetWalkableAreaEnabled(location string, name string, enabled bool) error {
+	key, exists := g.locationKey(location)
+	if !exists {
+		return fmt.Errorf("location '%s' not found", location)
+	}
+	locationData := g.data.Locations[key]
+
+	found := false
+	for _, area := range locationData.GetWalkableAreas() {
+		found = found || area.Name == name
+	}
+	if !found {
+		return fmt.Errorf("walkable area '%s' not found in location '%s'", name, locationData.Name)
+	}
+
+	if g.state.walkableAreas[locationData.ID] == nil {
+		g.state.walkableAreas[locationData.ID] = make(map[string]bool)
+	}
+	g.state.walkableAreas[locationData.ID][name] = enabled
+
+	if locationData.ID == g.state.currentLocation.ID {
+		g.updatePathFinder()
+	}
+	return nil
+}
+
+// EnabledWalkableAreas returns the walkable areas of the current location characters can walk on
+func (g *Game) EnabledWalkableAreas() []model.WalkableArea {
+	areas := make([]model.WalkableArea, 0)
+	for _, area := range g.state.currentLocation.GetWalkableAreas() {
+		if g.IsWalkableAreaEnabled(g.state.currentLocation.ID, area) {
+			areas = append(areas, area)
+		}
+	}
+	return areas
+}
+
+// updatePathFinder rebuilds the path finder from the enabled walkable areas of the current location
+func (g *Game) updatePathFinder() {
+	areas := make([][][]image.Point, 0)
+	for _, area := range g.EnabledWalkableAreas() {
+		if len(area.Polygons) > 0 {
+			areas = append(areas, area.Polygons)
+		}
+	}
+
+	g.state.pathFinder = nil
+	if len(areas) > 0 {
+		g.state.pathFinder = model.NewAreasPathfinder(areas)
+	}
+}
@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestScriptsEnableWalkableAreas(t *testing.T) {
+	game := NewGame()
+	game.SetSaveDirectory(t.TempDir())
+	game.AddCharacter(model.NewCharacter("guybrush-id", "Guybrush", model.Color{R: 255, G: 255, B: 255}))
+
+	// Two banks of a river, and a bridge between them overlapping both
+	location := model.NewLocation("porto-id", "Porto", nil)
+	location.AddWalkableArea(model.WalkableArea{Polygons: [][]image.Point{
+		{{0, 200}, {300, 200}, {300, 380}, {0, 380}},
+		{{500, 200}, {900, 200}, {900, 380}, {500, 380}},
+	}})
+	location.AddWalkableArea(model.WalkableArea{Name: "bridge", Disabled: true, Polygons: [][]image.Point{
+		{{250, 260}, {550, 260}, {550, 320}, {250, 320}},
+	}})
+	game.AddLocation(location)
+	game.SetCurrentCharacter("Guybrush")
+	game.SetCurrentLocation("Porto")
+
+	destination := image.Pt(800, 300)
+	path := game.state.pathFinder.Path(image.Pt(100, 300), destination)
+	if len(path) > 0 && path[len(path)-1] == destination {
+		t.Fatalf("expected the other bank out of reach without the bridge, got %v", path)
+	}
+
+	if err := RunScript(game.luaState(), `game:EnableWalkableArea("bridge")`); err != nil {
+		t.Fatalf("EnableWalkableArea failed: %v", err)
+	}
+	path = game.state.pathFinder.Path(image.Pt(100, 300), destination)
+	if len(path) == 0 || path[len(path)-1] != destination {
+		t.Fatalf("expected a path over the bridge, got %v", path)
+	}
+	for _, point := range path {
+		if point.X > 250 && point.X < 550 && (point.Y < 260 || point.Y > 320) {
+			t.Errorf("expected the path to cross the river on the bridge, got %v", path)
+		}
+	}
+
+	// Along the banks the path turns at the corners between the bridge and the banks
+	path = game.state.pathFinder.Path(image.Pt(100, 220), image.Pt(800, 220))
+	if len(path) < 4 || path[len(path)-1] != image.Pt(800, 220) {
+		t.Errorf("expected a path around the bridge corners, got %v", path)
+	}
+
+	// The bridge stays after saving and loading
+	if err := game.SaveGame(1); err != nil {
+		t.Fatalf("SaveGame failed: %v", err)
+	}
+	if err := game.SetWalkableAreaEnabled("Porto", "bridge", false); err != nil {
+		t.Fatalf("SetWalkableAreaEnabled failed: %v", err)
+	}
+	if err := game.LoadGame(1); err != nil {
+		t.Fatalf("LoadGame failed: %v", err)
+	}
+	if areas := game.EnabledWalkableAreas(); len(areas) != 2 {
+		t.Errorf("expected the bridge enabled after loading, got %d enabled areas", len(areas))
+	}
+
+	if err := game.SetWalkableAreaEnabled("Porto", "tunnel", true); err == nil {
+		t.Errorf("expected an error for an unknown walkable area")
+	}
+
+	// Script errors name the argument that is wrong
+	for script, argument := range map[string]string{
+		`game:EnableWalkableArea("tunnel")`:                 "#2",
+		`game:DisableWalkableArea("bridge", "Atlantide")`:   "#3",
+		`game:IsWalkableAreaEnabled("bridge", "Atlantide")`: "#3",
+	} {
+		err := RunScript(game.luaState(), script)
+		if err == nil || !strings.Contains(err.Error(), "bad argument "+argument) {
+			t.Errorf("expected %s to fail on argument %s, got %v", script, argument, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"fmt"
+	"image"
+	"image/draw"
+	"strconv"
+	"strings"
+)
+
+// walkBehindPrefix marks the walk-behinds among the IDs of YOrderedEntities
+const walkBehindPrefix = "walk-behind:"
+
+func walkBehindID(index int) string {
+	return fmt.Sprintf("%s%d", walkBehindPrefix, index)
+}
+
+// WalkBehindIndex returns the index in WalkBehinds of an ID of YOrderedEntities, if it is a walk-behind.
+// A walk-behind is drawn, over what comes before it, as the part of the background under its mask.
+func WalkBehindIndex(id string) (int, bool) {
+	if !strings.HasPrefix(id, walkBehindPrefix) {
+		return 0, false
+	}
+	index, err := strconv.Atoi(strings.TrimPrefix(id, walkBehindPrefix))
+	return index, err == nil
+}
+
+// WalkBehinds returns the walk-behinds of the current location
+func (g *Game) WalkBehinds() []model.WalkBehind {
+	return g.state.currentLocation.GetWalkBehinds()
+}
+
+// WalkBehindImage returns the part of the background covered by the mask of a walk-behind, transparent elsewhere
+func WalkBehindImage(background image.Image, walkBehind model.WalkBehind) image.Image {
+	bounds := background.Bounds()
+	cutout := image.NewNRGBA(bounds)
+	if walkBehind.Alpha != nil {
+		draw.DrawMask(cutout, bounds, background, bounds.Min, walkBehind.Alpha, walkBehind.Alpha.Bounds().Min, draw.Src)
+	}
+	return cutout
+}
+
+// isWalkBehindAt reports whether the walk-behind covers the given world position
+func isWalkBehindAt(walkBehind model.WalkBehind, x int, y int) bool {
+	return walkBehind.Alpha != nil && walkBehind.Alpha.AlphaAt(x, y).A > 0
+}
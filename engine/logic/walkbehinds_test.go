@@ -0,0 +1,63 @@
+package logic
+
+import (
+	"bytes"
+	"chemistry/engine/model"
+	"image"
+	"image/color"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+// counterMask is a mask of a counter covering the room from x 280 to 320
+func counterMask(t *testing.T) []byte {
+	mask := image.NewNRGBA(image.Rect(0, 0, 400, 400))
+	for y := 0; y < 400; y++ {
+		for x := 280; x < 320; x++ {
+			mask.Set(x, y, color.Black)
+		}
+	}
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, mask); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestWalkBehindCoversCharactersAboveItsBaseline(t *testing.T) {
+	game := newCharactersTestGame()
+	pirate := game.GetCharacter("Pirate")
+	pirate.Animations[string(model.IDLE_FACE_DOWN)] = [][]byte{testImage(t, 20, 40)}
+	game.state.currentLocation.AddWalkBehind(model.NewWalkBehind(counterMask(t), 250))
+	game.state.CalculateYOrderedEntities()
+
+	// The pirate (y 200) is behind the counter, the barrel (y 250) on its baseline and Guybrush (y 300) in front
+	expected := []string{"pirate-id", walkBehindID(0), "barrel-id", "guybrush-id"}
+	if !reflect.DeepEqual(game.YOrderedEntities(), expected) {
+		t.Fatalf("expected draw order %v, got %v", expected, game.YOrderedEntities())
+	}
+	if index, isWalkBehind := WalkBehindIndex(expected[1]); !isWalkBehind || index != 0 {
+		t.Errorf("expected %s to be walk-behind 0", expected[1])
+	}
+
+	// The pirate can be clicked only where the counter does not hide it
+	if id := game.CharacterAt(290, 150); id != "" {
+		t.Errorf("expected the pirate hidden by the counter, got '%s'", id)
+	}
+	if id := game.CharacterAt(275, 150); id != "pirate-id" {
+		t.Errorf("expected the pirate beside the counter, got '%s'", id)
+	}
+
+	// The walk-behind is drawn as the part of the background under the mask
+	background := image.NewNRGBA(image.Rect(0, 0, 400, 400))
+	background.Set(300, 10, color.NRGBA{R: 200, A: 255})
+	background.Set(10, 10, color.NRGBA{R: 200, A: 255})
+	cutout := WalkBehindImage(background, game.WalkBehinds()[0])
+	if r, _, _, _ := cutout.At(300, 10).RGBA(); r == 0 {
+		t.Errorf("expected the background under the mask")
+	}
+	if _, _, _, a := cutout.At(10, 10).RGBA(); a != 0 {
+		t.Errorf("expected the walk-behind transparent outside the mask")
+	}
+}
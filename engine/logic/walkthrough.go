@@ -0,0 +1,265 @@
+package logic
+
+import (
+	"bufio"
+	"chemistry/engine/model"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// walkthroughSettleTime is the longest game time a walkthrough command may take to be carried out
+const walkthroughSettleTime = 10 * time.Minute
+
+// WalkthroughStep is a command of a walkthrough and the state expected once it has been carried out.
+// The first step has no command when the file starts with expectations on the initial state.
+type WalkthroughStep struct {
+	Line         int
+	Command      string
+	Expectations []WalkthroughExpectation
+}
+
+// WalkthroughExpectation is an EXPECT line of a walkthrough, e.g. "EXPECT FLAG DOOR_OPEN true"
+type WalkthroughExpectation struct {
+	Line  int
+	Kind  string // FLAG, COUNTER, INVENTORY, NOT_INVENTORY or LOCATION
+	Name  string
+	Value string
+}
+
+// WalkthroughMismatch is an expectation the game state does not meet
+type WalkthroughMismatch struct {
+	Expectation WalkthroughExpectation
+	Expected    string
+	Actual      string
+}
+
+// ParseWalkthrough reads a walkthrough: one command per line, each followed by the
+// expectations on the state of the game after it. Empty lines and lines starting with # are skipped.
+//
+//	PICK_UP Key
+//	EXPECT INVENTORY Key
+//	USE Key WITH Door
+//	EXPECT FLAG DOOR_OPEN true
+//	GOTO Location2
+//	EXPECT LOCATION Location2
+func ParseWalkthrough(reader io.Reader) ([]WalkthroughStep, error) {
+	steps := make([]WalkthroughStep, 0)
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if fields[0] != "EXPECT" {
+			steps = append(steps, WalkthroughStep{Line: lineNumber, Command: line})
+			continue
+		}
+
+		expectation, err := parseExpectation(lineNumber, fields[1:])
+		if err != nil {
+			return nil, err
+		}
+		if len(steps) == 0 {
+			steps = append(steps, WalkthroughStep{Line: lineNumber})
+		}
+		last := &steps[len(steps)-1]
+		last.Expectations = append(last.Expectations, expectation)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+func parseExpectation(line int, fields []string) (WalkthroughExpectation, error) {
+	if len(fields) >= 2 && fields[0] == "NOT" && fields[1] == "INVENTORY" {
+		fields = append([]string{"NOT_INVENTORY"}, fields[2:]...)
+	}
+	if len(fields) < 2 {
+		return WalkthroughExpectation{}, fmt.Errorf("line %d: incomplete expectation", line)
+	}
+
+	expectation := WalkthroughExpectation{Line: line, Kind: fields[0]}
+	switch expectation.Kind {
+	case "FLAG", "COUNTER":
+		if len(fields) != 3 {
+			return WalkthroughExpectation{}, fmt.Errorf("line %d: expected EXPECT %s <name> <value>", line, expectation.Kind)
+		}
+		expectation.Name = fields[1]
+		expectation.Value = fields[2]
+		if _, err := strconv.ParseBool(expectation.Value); expectation.Kind == "FLAG" && err != nil {
+			return WalkthroughExpectation{}, fmt.Errorf("line %d: invalid flag value '%s'", line, expectation.Value)
+		}
+		if _, err := strconv.Atoi(expectation.Value); expectation.Kind == "COUNTER" && err != nil {
+			return WalkthroughExpectation{}, fmt.Errorf("line %d: invalid counter value '%s'", line, expectation.Value)
+		}
+	case "INVENTORY", "NOT_INVENTORY", "LOCATION":
+		expectation.Name = strings.Join(fields[1:], " ")
+	default:
+		return WalkthroughExpectation{}, fmt.Errorf("line %d: unknown expectation '%s'", line, expectation.Kind)
+	}
+	return expectation, nil
+}
+
+// RunWalkthroughCommand carries out a walkthrough command and lets the game run until it is over:
+//
+//	<VERB> <object> [WITH|TO <object>]   an action, executed with ExecuteAction
+//	GOTO <location>                      moves to a location
+//	CHOOSE <option text>                 chooses an option of the running dialogue
+func (g *Game) RunWalkthroughCommand(command string) error {
+	fields := strings.Fields(command)
+	if len(fields) < 2 {
+		return fmt.Errorf("incomplete command '%s'", command)
+	}
+	argument := strings.TrimSpace(strings.TrimPrefix(command, fields[0]))
+
+	switch fields[0] {
+	case "GOTO":
+		key, exists := g.locationKey(argument)
+		if !exists {
+			return fmt.Errorf("location '%s' not found", argument)
+		}
+		g.SetCurrentLocation(key)
+	case "CHOOSE":
+		options := g.DialogueOptions()
+		index := -1
+		for i, option := range options {
+			if option.Text == argument {
+				index = i
+			}
+		}
+		if index < 0 {
+			texts := make([]string, len(options))
+			for i, option := range options {
+				texts[i] = option.Text
+			}
+			return fmt.Errorf("dialogue option '%s' not available, options are %q", argument, texts)
+		}
+		g.ChooseDialogueOption(index)
+	default:
+		verb := model.Verb(fields[0])
+		mainName, secondName := argument, ""
+		for _, preposition := range []string{" WITH ", " TO "} {
+			if before, after, found := strings.Cut(argument, preposition); found {
+				mainName, secondName = before, after
+				break
+			}
+		}
+
+		mainObject, err := g.walkthroughObject(mainName)
+		if err != nil {
+			return err
+		}
+		secondObject := model.NOTHING
+		if secondName != "" {
+			if secondObject, err = g.walkthroughObject(secondName); err != nil {
+				return err
+			}
+		}
+		g.ExecuteAction(composeTrigger(g.state.currentCharacter.ID, verb, mainObject, secondObject, g.state.currentLocation.ID))
+	}
+
+	return g.settle()
+}
+
+// walkthroughObject returns the ID of the item, character or hotspot of the current location with the given name.
+// Items at hand (held or in the current location) win over the others with the same name.
+func (g *Game) walkthroughObject(name string) (string, error) {
+	ids := make([]string, 0)
+	for id, item := range g.data.Items {
+		if item.Name == name || id == name {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		_, held := g.state.currentCharacter.Inventory[id]
+		_, inRoom := g.state.currentLocation.Items[id]
+		if held || inRoom {
+			return id, nil
+		}
+	}
+	if len(ids) > 0 {
+		return ids[0], nil
+	}
+
+	if character, exists := g.CharacterByID(name); exists {
+		return character.ID, nil
+	}
+	for _, hotspot := range g.state.currentLocation.GetHotspots() {
+		if hotspot.Name == name || hotspot.ID == name {
+			return hotspot.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no item, character or hotspot named '%s'", name)
+}
+
+// settle ticks the game until it waits for the player: no script running, no action
+// being carried out, no line being said and, during a dialogue, the options shown
+func (g *Game) settle() error {
+	for elapsed := time.Duration(0); elapsed < walkthroughSettleTime; elapsed += TickDuration {
+		busy := g.IsScriptRunning() ||
+			g.GetCurrentState() == model.EXECUTING_ACTION ||
+			g.TextToDraw() != "" ||
+			(g.IsInDialogue() && g.DialogueOptions() == nil)
+		if !busy {
+			return nil
+		}
+		g.Tick(nil)
+	}
+	return fmt.Errorf("the game is still busy after %v of game time", walkthroughSettleTime)
+}
+
+// CheckWalkthroughExpectations returns the expectations the current state of the game does not meet
+func (g *Game) CheckWalkthroughExpectations(expectations []WalkthroughExpectation) []WalkthroughMismatch {
+	mismatches := make([]WalkthroughMismatch, 0)
+	for _, expectation := range expectations {
+		expected, actual := expectation.Value, ""
+		switch expectation.Kind {
+		case "FLAG":
+			actual = strconv.FormatBool(g.GetFlag(expectation.Name))
+			parsed, _ := strconv.ParseBool(expected)
+			expected = strconv.FormatBool(parsed)
+		case "COUNTER":
+			actual = strconv.Itoa(g.GetCounter(expectation.Name))
+		case "INVENTORY", "NOT_INVENTORY":
+			expected = "held"
+			if expectation.Kind == "NOT_INVENTORY" {
+				expected = "not held"
+			}
+			actual = "not held"
+			if g.isHeld(expectation.Name) {
+				actual = "held"
+			}
+		case "LOCATION":
+			expected = expectation.Name
+			actual = g.state.currentLocation.Name
+			if key, exists := g.locationKey(expectation.Name); exists && g.data.Locations[key].ID == g.state.currentLocation.ID {
+				actual = expected
+			}
+		}
+
+		if expected != actual {
+			mismatches = append(mismatches, WalkthroughMismatch{Expectation: expectation, Expected: expected, Actual: actual})
+		}
+	}
+	return mismatches
+}
+
+// isHeld reports whether the current character holds an item with the given name or ID
+func (g *Game) isHeld(name string) bool {
+	for id, slot := range g.state.currentCharacter.Inventory {
+		if slot.Count > 0 && (id == name || g.GetItem(id).Name == name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"chemistry/engine/model"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestWalkthroughReplaysCommandsAndChecksState(t *testing.T) {
+	game := newCharactersTestGame()
+	location := game.GetLocation("Porto")
+	location.AddItem("coin-id", model.ItemLocation{LocationPoint: image.Pt(120, 300), InteractionPoint: image.Pt(120, 300)})
+	game.AddLocation(model.NewLocation("isola-id", "Isola", nil))
+	game.AddAction(model.NewAction("guybrush-id", model.GIVE_TO, "coin-id", "pirate-id", "porto-id", `
+		game:Say("Here you are")
+		game:SetFlag("PIRATE_PAID", true)
+		game:IncreaseCounter("coins_given", 1)
+	`, nil, nil, nil))
+
+	steps, err := ParseWalkthrough(strings.NewReader(`
+		# Pay the pirate
+		EXPECT FLAG PIRATE_PAID false
+		PICK_UP Coin
+		EXPECT INVENTORY Coin
+		GIVE_TO Coin TO Pirate
+		EXPECT FLAG PIRATE_PAID true
+		EXPECT COUNTER coins_given 1
+		EXPECT NOT INVENTORY Coin
+		GOTO Isola
+		EXPECT LOCATION Porto
+	`))
+	if err != nil {
+		t.Fatalf("ParseWalkthrough failed: %v", err)
+	}
+	if len(steps) != 4 || steps[0].Command != "" || steps[2].Command != "GIVE_TO Coin TO Pirate" {
+		t.Fatalf("unexpected steps %+v", steps)
+	}
+
+	for _, step := range steps[:3] {
+		if step.Command != "" {
+			if err := game.RunWalkthroughCommand(step.Command); err != nil {
+				t.Fatalf("%s: %v", step.Command, err)
+			}
+		}
+		if mismatches := game.CheckWalkthroughExpectations(step.Expectations); len(mismatches) > 0 {
+			t.Fatalf("%s: unexpected mismatches %+v", step.Command, mismatches)
+		}
+	}
+
+	// The last expectation is wrong on purpose
+	if err := game.RunWalkthroughCommand(steps[3].Command); err != nil {
+		t.Fatalf("%s: %v", steps[3].Command, err)
+	}
+	mismatches := game.CheckWalkthroughExpectations(steps[3].Expectations)
+	if len(mismatches) != 1 || mismatches[0].Expected != "Porto" || mismatches[0].Actual != "Isola" {
+		t.Errorf("expected a location mismatch, got %+v", mismatches)
+	}
+}
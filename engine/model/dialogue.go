@@ -0,0 +1,58 @@
+package model
+
+type Dialogue struct {
+	Entity
+	StartNode string
+	Nodes     map[string]DialogueNode
+}
+
+type DialogueNode struct {
+	ID      string
+	Lines   []DialogueLine
+	Options []DialogueOption
+}
+
+// DialogueLine is a sentence said when a dialogue node is entered.
+// Script is a Lua hook executed right before the line is shown.
+type DialogueLine struct {
+	Speaker string
+	Text    string
+	Script  string
+}
+
+// DialogueOption is a choice offered to the player at the end of a node.
+// An empty Next ends the dialogue once the option has been said.
+type DialogueOption struct {
+	ID              string
+	Text            string
+	Conditions      []DialogueCondition
+	HideAfterChosen bool
+	Script          string
+	Next            string
+}
+
+// DialogueCondition checks either a flag (Flag/Value) or a counter (Counter/Operator/Amount)
+type DialogueCondition struct {
+	Flag     string
+	Value    bool
+	Counter  string
+	Operator string
+	Amount   int
+}
+
+func NewDialogue(id string, name string, startNode string) Dialogue {
+	data := Dialogue{
+		Entity: Entity{
+			ID:   id,
+			Type: DIALOGUE,
+			Name: name,
+		},
+		StartNode: startNode,
+		Nodes:     make(map[string]DialogueNode),
+	}
+	return data
+}
+
+func (d *Dialogue) AddNode(node DialogueNode) {
+	d.Nodes[node.ID] = node
+}
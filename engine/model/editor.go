@@ -9,6 +9,54 @@ type EditorPoint struct {
 
 type EditorPolygon []EditorPoint
 
+type EditorWalkableArea struct {
+	Name     string          `json:"name"`
+	Polygons []EditorPolygon `json:"polygons"`
+	Disabled bool            `json:"disabled,omitempty"`
+}
+
+type EditorLocationLayer struct {
+	Image string `json:"image,omitempty"`
+	EditorLayerSettings
+}
+
+// EditorLayerSettings are how a layer is drawn, the same in the editor export and in the packaged data
+type EditorLayerSettings struct {
+	ZOrder           int      `json:"zOrder,omitempty"`
+	Parallax         *float64 `json:"parallax,omitempty"` // 1 when missing
+	TransparentColor string   `json:"transparentColor,omitempty"`
+}
+
+type EditorWalkBehind struct {
+	Mask     string `json:"mask,omitempty"`
+	Baseline int    `json:"baseline"`
+}
+
+type EditorExit struct {
+	Name           string        `json:"name,omitempty"`
+	Area           EditorPolygon `json:"area"`
+	TargetLocation string        `json:"targetLocation"`
+	EntryPoint     EditorPoint   `json:"entryPoint"`
+	EntryDirection string        `json:"entryDirection,omitempty"` // LEFT, RIGHT, UP or DOWN
+	OnExit         string        `json:"onExit,omitempty"`
+	OnEnter        string        `json:"onEnter,omitempty"`
+}
+
+type EditorHotspot struct {
+	ID              string        `json:"id"`
+	Name            string        `json:"name"`
+	Area            EditorPolygon `json:"area"`
+	InteractionSpot EditorPoint   `json:"interactionSpot"`
+	Direction       string        `json:"direction,omitempty"` // LEFT, RIGHT, UP or DOWN
+}
+
+type EditorScaleArea struct {
+	FarY      int     `json:"farY"`
+	FarScale  float64 `json:"farScale"`
+	NearY     int     `json:"nearY"`
+	NearScale float64 `json:"nearScale"`
+}
+
 type EditorPlacedEntity struct {
 	EntityID        string      `json:"entityId"`
 	Position        EditorPoint `json:"position"`
@@ -28,12 +76,18 @@ type EditorAnimation struct {
 
 // --- Dettagli Entità ---
 type EditorLocationDetails struct {
-	Description      string               `json:"description,omitempty"`
-	BackgroundImage  string               `json:"backgroundImage,omitempty"`
-	WalkableArea     []EditorPolygon      `json:"walkableArea,omitempty"`
-	PlacedItems      []EditorPlacedEntity `json:"placedItems,omitempty"`
-	PlacedCharacters []EditorPlacedEntity `json:"placedCharacters,omitempty"`
-	BackgroundColor  string               `json:"backgroundColor,omitempty"`
+	Description      string                `json:"description,omitempty"`
+	BackgroundImage  string                `json:"backgroundImage,omitempty"`
+	WalkableArea     []EditorPolygon       `json:"walkableArea,omitempty"`
+	WalkableAreas    []EditorWalkableArea  `json:"walkableAreas,omitempty"`
+	ScaleArea        *EditorScaleArea      `json:"scaleArea,omitempty"`
+	Layers           []EditorLocationLayer `json:"layers,omitempty"`
+	WalkBehinds      []EditorWalkBehind    `json:"walkBehinds,omitempty"`
+	Exits            []EditorExit          `json:"exits,omitempty"`
+	Hotspots         []EditorHotspot       `json:"hotspots,omitempty"`
+	PlacedItems      []EditorPlacedEntity  `json:"placedItems,omitempty"`
+	PlacedCharacters []EditorPlacedEntity  `json:"placedCharacters,omitempty"`
+	BackgroundColor  string                `json:"backgroundColor,omitempty"`
 }
 
 type EditorCharacterDetails struct {
@@ -42,6 +96,7 @@ type EditorCharacterDetails struct {
 	InventoryImageData string            `json:"inventoryImageData,omitempty"`
 	Animations         []EditorAnimation `json:"animations,omitempty"`
 	InteractionSpot    *EditorPoint      `json:"interactionSpot,omitempty"`
+	TalkColor          string            `json:"talkColor,omitempty"` // #RRGGBB
 }
 
 type EditorItemDetails struct {
@@ -66,6 +121,64 @@ type EditorCursorDetails struct {
 	Animations []EditorAnimation
 }
 
+type EditorDialogueCondition struct {
+	Flag     string `json:"flag,omitempty"`
+	Value    bool   `json:"value,omitempty"`
+	Counter  string `json:"counter,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Amount   int    `json:"amount,omitempty"`
+}
+
+type EditorDialogueLine struct {
+	Speaker string `json:"speaker,omitempty"`
+	Text    string `json:"text"`
+	Script  string `json:"script,omitempty"`
+}
+
+type EditorDialogueOption struct {
+	ID              string                    `json:"id"`
+	Text            string                    `json:"text"`
+	Conditions      []EditorDialogueCondition `json:"conditions,omitempty"`
+	HideAfterChosen bool                      `json:"hideAfterChosen,omitempty"`
+	Script          string                    `json:"script,omitempty"`
+	Next            string                    `json:"next,omitempty"`
+}
+
+type EditorDialogueNode struct {
+	ID      string                 `json:"id"`
+	Lines   []EditorDialogueLine   `json:"lines,omitempty"`
+	Options []EditorDialogueOption `json:"options,omitempty"`
+}
+
+type EditorDialogueDetails struct {
+	StartNode string               `json:"startNode"`
+	Nodes     []EditorDialogueNode `json:"nodes,omitempty"`
+}
+
+// EditorInterfaceSettings is the project-level layout of the verb interface, in screen coordinates
+type EditorInterfaceSettings struct {
+	Verbs        []string              `json:"verbs,omitempty"`
+	VerbLabels   map[string]string     `json:"verbLabels,omitempty"`
+	Prepositions map[string]string     `json:"prepositions,omitempty"`
+	VerbColumns  int                   `json:"verbColumns,omitempty"`
+	SentenceLine *EditorScreenRect     `json:"sentenceLine,omitempty"`
+	VerbBar      *EditorScreenRect     `json:"verbBar,omitempty"`
+	Inventory    *EditorScreenRect     `json:"inventory,omitempty"`
+	Cursors      *EditorCursorSettings `json:"cursors,omitempty"`
+}
+
+type EditorCursorSettings struct {
+	Default string            `json:"default,omitempty"`
+	Verbs   map[string]string `json:"verbs,omitempty"`
+}
+
+type EditorScreenRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
 // ActionDetails - definiscila se usata come entità separata
 type EditorActionDetails struct {
 	ActionType     string `json:"actionType,omitempty"`
@@ -100,10 +213,11 @@ type EditorGenericEntity struct {
 
 // --- ProjectData (Struttura principale del JSON) ---
 type ProjectData struct {
-	Version     string                `json:"version"`
-	ProjectName string                `json:"projectName"`
-	Nodes       []json.RawMessage     `json:"nodes"`
-	Entities    []EditorGenericEntity `json:"entities"`
+	Version     string                   `json:"version"`
+	ProjectName string                   `json:"projectName"`
+	Nodes       []json.RawMessage        `json:"nodes"`
+	Entities    []EditorGenericEntity    `json:"entities"`
+	Interface   *EditorInterfaceSettings `json:"interface,omitempty"`
 }
 
 // Strutture complete per le Entità (da usare dopo il parsing in due fasi di GenericEntity)
@@ -163,6 +277,14 @@ type EditorCursor struct {
 	Details  *EditorCursorDetails `json:"details,omitempty"`
 }
 
+type EditorDialogue struct {
+	ID       string                 `json:"id"`
+	Type     string                 `json:"type"`
+	Name     string                 `json:"name"`
+	Internal bool                   `json:"internal,omitempty"`
+	Details  *EditorDialogueDetails `json:"details,omitempty"`
+}
+
 // TypedNode (per il parsing in due fasi dei nodi del diagramma)
 type EditorTypedNode struct {
 	ID          string                `json:"id"`
@@ -177,6 +299,7 @@ type EditorTypedNode struct {
 	With        string           `json:"with,omitempty"`
 	Where       string           `json:"where,omitempty"`
 	Script      string           `json:"script,omitempty"`
+	Dialogue    string           `json:"dialogue,omitempty"`
 	Description string           `json:"description,omitempty"`
 	Flags       []EditorNodeFlag `json:"flags,omitempty"`
 }
@@ -193,4 +316,6 @@ type PackagedGameData struct {
 	Scripts      []EditorScript
 	Actions      []EditorAction
 	Cursors      []EditorCursor
+	Dialogues    []EditorDialogue
+	Interface    *EditorInterfaceSettings
 }
@@ -3,7 +3,10 @@ package model
 import (
 	"bytes"
 	"image"
+	"image/draw"
 	"log"
+	"math"
+	"time"
 )
 
 var DoNothing = func() {}
@@ -26,6 +29,8 @@ type Action struct {
 	With          string
 	Where         string
 	Script        string
+	Dialogue      string
+	NodeID        string // ID of the diagram node the action comes from, if any
 	ExecuteBefore func()
 	ExecuteAction func()
 	ExecuteAfter  func()
@@ -45,6 +50,7 @@ const (
 	CHARACTER EntityType = "character"
 	ITEM      EntityType = "item"
 	LOCATION  EntityType = "location"
+	DIALOGUE  EntityType = "dialogue"
 )
 
 type AnimationTypes string
@@ -74,6 +80,7 @@ const (
 	IDLE             StateType = "IDLE"
 	WAITING_ACTION   StateType = "AWAITING_COMMAND"
 	EXECUTING_ACTION StateType = "EXECUTING_ACTION"
+	IN_DIALOGUE      StateType = "IN_DIALOGUE"
 )
 
 type CharacterDirection string
@@ -93,9 +100,44 @@ type Entity struct {
 
 type Character struct {
 	Entity
-	TalkColor  Color
-	Animations map[string][][]byte
-	Inventory  map[string]InventorySlot
+	TalkColor        Color
+	Animations       map[string][][]byte
+	AnimationTimings map[string]AnimationTiming
+	Inventory        map[string]InventorySlot
+}
+
+// DefaultFrameDuration is how long a frame is shown when its animation does not say
+const DefaultFrameDuration = 96 * time.Millisecond
+
+// AnimationTiming is how an animation is played: how long each of its frames is shown
+// and whether it starts over after the last one
+type AnimationTiming struct {
+	Durations []time.Duration
+	Loop      bool
+}
+
+// FrameDuration returns how long a frame is shown, DefaultFrameDuration when unknown
+func (t AnimationTiming) FrameDuration(frame int) time.Duration {
+	if frame < 0 || frame >= len(t.Durations) || t.Durations[frame] <= 0 {
+		return DefaultFrameDuration
+	}
+	return t.Durations[frame]
+}
+
+// animationTiming returns the timing of an animation; animations without one loop at DefaultFrameDuration
+func animationTiming(timings map[string]AnimationTiming, name string) AnimationTiming {
+	if timing, exists := timings[name]; exists {
+		return timing
+	}
+	return AnimationTiming{Loop: true}
+}
+
+func (c Character) AnimationTiming(name string) AnimationTiming {
+	return animationTiming(c.AnimationTimings, name)
+}
+
+func (i Item) AnimationTiming(name string) AnimationTiming {
+	return animationTiming(i.AnimationTimings, name)
 }
 
 type Color struct {
@@ -111,44 +153,203 @@ type InventorySlot struct {
 
 type Item struct {
 	Entity
-	Alpha          *image.Alpha
-	Image          []byte
-	InventoryImage []byte
-	Animations     map[string][][]byte
-	UseWith        bool
-	Pickable       bool
+	Image            []byte
+	HitMask          *HitMask // Of Image, built on-demand if the game data has none
+	InventoryImage   []byte
+	Animations       map[string][][]byte
+	AnimationTimings map[string]AnimationTiming
+	FrameHitMasks    map[string][]*HitMask // Of the frames of Animations, nil until known
+	UseWith          bool
+	Pickable         bool
+}
+
+// Cursor is a set of cursor animations, one for each hover state of the cursor (e.g. over an item)
+type Cursor struct {
+	Name             string
+	Animations       map[string][][]byte
+	AnimationTimings map[string]AnimationTiming
+	DefaultAnimation string // Shown in the hover states the set has no animation for
+}
+
+func NewCursor(name string) Cursor {
+	return Cursor{
+		Name:             name,
+		Animations:       make(map[string][][]byte),
+		AnimationTimings: make(map[string]AnimationTiming),
+	}
+}
+
+// AddAnimation adds an animation to the set, the first one added is the default one
+func (c *Cursor) AddAnimation(name string, frames [][]byte, timing AnimationTiming) {
+	if c.DefaultAnimation == "" {
+		c.DefaultAnimation = name
+	}
+	c.Animations[name] = frames
+	c.AnimationTimings[name] = timing
 }
 
+func (c Cursor) AnimationTiming(name string) AnimationTiming {
+	return animationTiming(c.AnimationTimings, name)
+}
+
+// Layer is an image of a location. The first layer is the background, which gives the size of the room.
 type Layer struct {
 	Image            []byte
-	TransparentColor string
+	TransparentColor string  // Pixels of this color (#RRGGBB) are transparent, "" for none
+	ZOrder           int     // Layers are drawn by ZOrder, the ones above 0 in front of characters and items
+	Parallax         float64 // How much the layer scrolls with the camera: 1 like the room, 0 fixed on screen
+}
+
+// InFrontOfActors reports whether the layer is drawn over characters and items
+func (l Layer) InFrontOfActors() bool {
+	return l.ZOrder > 0
+}
+
+// ScaleArea is how big characters are drawn depending on how far they stand: FarScale on
+// the FarY line, NearScale on the NearY line, interpolated in between and clamped outside
+type ScaleArea struct {
+	FarY      int
+	FarScale  float64
+	NearY     int
+	NearScale float64
+}
+
+// ScaleAt returns the scale of a character whose feet are at y
+func (a ScaleArea) ScaleAt(y int) float64 {
+	if a.NearY == a.FarY {
+		return a.NearScale
+	}
+	t := float64(y-a.FarY) / float64(a.NearY-a.FarY)
+	t = math.Max(0, math.Min(1, t))
+	return a.FarScale + (a.NearScale-a.FarScale)*t
+}
+
+// WalkBehind is a part of the background drawn over the characters standing behind it, i.e. above Baseline.
+// Mask has the size of the background: its opaque pixels are the part in front.
+type WalkBehind struct {
+	Mask     []byte
+	Alpha    *image.Alpha
+	Baseline int
 }
 
+func NewWalkBehind(mask []byte, baseline int) WalkBehind {
+	walkBehind := WalkBehind{Baseline: baseline}
+	walkBehind.SetMask(mask)
+	return walkBehind
+}
+
+// SetMask updates the mask image and its alpha, used for hit-testing
+func (w *WalkBehind) SetMask(mask []byte) {
+	w.Mask = mask
+	w.Alpha = nil
+	if len(mask) == 0 {
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(mask))
+	if err != nil {
+		log.Printf("Error decoding walk-behind mask: %v", err)
+		return
+	}
+	alpha := image.NewAlpha(img.Bounds())
+	draw.Draw(alpha, alpha.Bounds(), img, img.Bounds().Min, draw.Src)
+	w.Alpha = alpha
+}
+
+// Exit is a region of a location that takes the character walking into it to another location,
+// at EntryPoint facing EntryDirection. OnExit runs before leaving, OnEnter after arriving.
+type Exit struct {
+	Name           string
+	Area           []image.Point
+	TargetLocation string
+	EntryPoint     image.Point
+	EntryDirection CharacterDirection
+	OnExit         string
+	OnEnter        string
+}
+
+// Contains reports whether a point of the location is inside the exit region
+func (e Exit) Contains(pt image.Point) bool {
+	if len(e.Area) < 3 {
+		return false
+	}
+	return Polygon(ps2vs(e.Area)).Contains(p2v(pt), true)
+}
+
+// Hotspot is an interactive region painted in the background of a location, without a sprite.
+// Actions target it by ID like items; the character walks to InteractionPoint and turns to Direction.
+type Hotspot struct {
+	ID               string
+	Name             string
+	Area             []image.Point
+	InteractionPoint image.Point
+	Direction        CharacterDirection
+}
+
+// Contains reports whether a point of the location is inside the hotspot
+func (h Hotspot) Contains(pt image.Point) bool {
+	if len(h.Area) < 3 {
+		return false
+	}
+	return Polygon(ps2vs(h.Area)).Contains(p2v(pt), true)
+}
+
+// WalkableArea is a part of a location characters can walk on. Named areas can be
+// enabled and disabled while playing, Disabled is how the area starts.
 type WalkableArea struct {
+	Name     string
 	Polygons [][]image.Point
+	Disabled bool
 }
 
 type Location struct {
 	Entity
-	scaleArea     Layer
+	scaleArea     *ScaleArea
 	layers        []Layer
 	walkableAreas []WalkableArea
+	walkBehinds   []WalkBehind
+	exits         []Exit
+	hotspots      []Hotspot
 	Items         map[string]ItemLocation
+	Characters    map[string]CharacterLocation
 }
 
 func (l *Location) AddItem(itemID string, itemLocation ItemLocation) {
 	l.Items[itemID] = itemLocation
 }
 
+func (l *Location) AddCharacter(characterID string, characterLocation CharacterLocation) {
+	l.Characters[characterID] = characterLocation
+}
+
 type ItemLocation struct {
 	InteractionPoint image.Point
 	LocationPoint    image.Point
 }
 
+// CharacterLocation is where a character is placed when its location is entered the first time
+type CharacterLocation struct {
+	InteractionPoint image.Point
+	LocationPoint    image.Point
+}
+
 func (l Location) GetWalkableArea(index int) WalkableArea {
 	return l.walkableAreas[index]
 }
 
+func (l Location) GetWalkableAreas() []WalkableArea {
+	return l.walkableAreas
+}
+
+// GetScaleArea returns the scale area of the location, nil when characters are drawn at the default scale
+func (l Location) GetScaleArea() *ScaleArea {
+	return l.scaleArea
+}
+
+func (l *Location) SetScaleArea(area ScaleArea) {
+	l.scaleArea = &area
+}
+
 func (l Location) GetLayers() []Layer {
 	return l.layers
 }
@@ -160,9 +361,10 @@ func NewCharacter(id string, name string, talkColor Color) Character {
 			Type: CHARACTER,
 			Name: name,
 		},
-		TalkColor:  talkColor,
-		Animations: make(map[string][][]byte),
-		Inventory:  make(map[string]InventorySlot),
+		TalkColor:        talkColor,
+		Animations:       make(map[string][][]byte),
+		AnimationTimings: make(map[string]AnimationTiming),
+		Inventory:        make(map[string]InventorySlot),
 	}
 	return data
 }
@@ -174,14 +376,19 @@ func NewLocation(id string, name string, background []byte) Location {
 			Type: LOCATION,
 			Name: name,
 		},
-		scaleArea:     Layer{},
+		scaleArea:     nil,
 		layers:        make([]Layer, 0),
 		walkableAreas: make([]WalkableArea, 0),
+		walkBehinds:   make([]WalkBehind, 0),
+		exits:         make([]Exit, 0),
+		hotspots:      make([]Hotspot, 0),
 		Items:         make(map[string]ItemLocation),
+		Characters:    make(map[string]CharacterLocation),
 	}
 
 	layerBackground := Layer{
-		Image: background,
+		Image:    background,
+		Parallax: 1,
 	}
 
 	data.layers = append(data.layers, layerBackground)
@@ -198,7 +405,50 @@ func (l *Location) SetBackground(background []byte) {
 	if len(l.layers) > 0 {
 		l.layers[0].Image = background
 	} else {
-		l.layers = append(l.layers, Layer{Image: background})
+		l.layers = append(l.layers, Layer{Image: background, Parallax: 1})
+	}
+}
+
+func (l *Location) AddWalkBehind(walkBehind WalkBehind) {
+	l.walkBehinds = append(l.walkBehinds, walkBehind)
+}
+
+func (l Location) GetWalkBehinds() []WalkBehind {
+	return l.walkBehinds
+}
+
+// SetWalkBehindMask updates the mask of a walk-behind, e.g. when it is loaded on-demand
+func (l *Location) SetWalkBehindMask(index int, mask []byte) {
+	if index >= 0 && index < len(l.walkBehinds) {
+		l.walkBehinds[index].SetMask(mask)
+	}
+}
+
+func (l *Location) AddHotspot(hotspot Hotspot) {
+	l.hotspots = append(l.hotspots, hotspot)
+}
+
+func (l Location) GetHotspots() []Hotspot {
+	return l.hotspots
+}
+
+func (l *Location) AddExit(exit Exit) {
+	l.exits = append(l.exits, exit)
+}
+
+func (l Location) GetExits() []Exit {
+	return l.exits
+}
+
+// AddLayer adds a layer over the background
+func (l *Location) AddLayer(layer Layer) {
+	l.layers = append(l.layers, layer)
+}
+
+// SetLayerImage updates the image of a layer, e.g. when it is loaded on-demand
+func (l *Location) SetLayerImage(index int, image []byte) {
+	if index >= 0 && index < len(l.layers) {
+		l.layers[index].Image = image
 	}
 }
 
@@ -209,9 +459,11 @@ func NewItem(id string, name string, useWith bool, pickable bool, sprite []byte)
 			Type: ITEM,
 			Name: name,
 		},
-		UseWith:    useWith,
-		Pickable:   pickable,
-		Animations: make(map[string][][]byte),
+		UseWith:          useWith,
+		Pickable:         pickable,
+		Animations:       make(map[string][][]byte),
+		AnimationTimings: make(map[string]AnimationTiming),
+		FrameHitMasks:    make(map[string][]*HitMask),
 	}
 
 	if len(sprite) != 0 {
@@ -222,15 +474,8 @@ func NewItem(id string, name string, useWith bool, pickable bool, sprite []byte)
 			log.Fatal(err)
 		}
 
-		b := img.Bounds()
-		ebitenAlphaImage := image.NewAlpha(b)
-		for j := b.Min.Y; j < b.Max.Y; j++ {
-			for i := b.Min.X; i < b.Max.X; i++ {
-				ebitenAlphaImage.Set(i, j, img.At(i, j))
-			}
-		}
-
-		data.Alpha = ebitenAlphaImage
+		hitMask := NewHitMask(img)
+		data.HitMask = &hitMask
 	}
 
 	return data
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+)
+
+// hitMaskHeaderSize is the size of the bounds at the start of an encoded hit mask
+const hitMaskHeaderSize = 16
+
+// HitMask tells which pixels of a sprite can be clicked: the opaque ones. Only the bounding
+// box of the opaque pixels is kept, one bit per pixel, row by row.
+type HitMask struct {
+	Bounds image.Rectangle // In sprite coordinates, empty if the sprite is fully transparent
+	Bits   []byte
+}
+
+// NewHitMask builds the hit mask of a sprite
+func NewHitMask(img image.Image) HitMask {
+	b := img.Bounds()
+	opaque := image.Rectangle{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, alpha := img.At(x, y).RGBA(); alpha > 0 {
+				opaque = opaque.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+
+	mask := HitMask{Bounds: opaque, Bits: make([]byte, hitMaskStride(opaque)*opaque.Dy())}
+	for y := opaque.Min.Y; y < opaque.Max.Y; y++ {
+		for x := opaque.Min.X; x < opaque.Max.X; x++ {
+			if _, _, _, alpha := img.At(x, y).RGBA(); alpha > 0 {
+				index, bit := mask.bit(x, y)
+				mask.Bits[index] |= bit
+			}
+		}
+	}
+	return mask
+}
+
+func hitMaskStride(bounds image.Rectangle) int {
+	return (bounds.Dx() + 7) / 8
+}
+
+func (m HitMask) bit(x int, y int) (int, byte) {
+	x -= m.Bounds.Min.X
+	y -= m.Bounds.Min.Y
+	return y*hitMaskStride(m.Bounds) + x/8, 1 << (7 - x%8)
+}
+
+// Contains reports whether the pixel of the sprite at point is opaque
+func (m HitMask) Contains(point image.Point) bool {
+	if !point.In(m.Bounds) {
+		return false
+	}
+	index, bit := m.bit(point.X, point.Y)
+	return m.Bits[index]&bit != 0
+}
+
+// MarshalBinary encodes the mask as its bounds (four little-endian int32) followed by the bits
+func (m HitMask) MarshalBinary() ([]byte, error) {
+	data := make([]byte, hitMaskHeaderSize, hitMaskHeaderSize+len(m.Bits))
+	for i, value := range []int{m.Bounds.Min.X, m.Bounds.Min.Y, m.Bounds.Max.X, m.Bounds.Max.Y} {
+		binary.LittleEndian.PutUint32(data[i*4:], uint32(int32(value)))
+	}
+	return append(data, m.Bits...), nil
+}
+
+// UnmarshalBinary decodes a mask encoded with MarshalBinary
+func (m *HitMask) UnmarshalBinary(data []byte) error {
+	if len(data) < hitMaskHeaderSize {
+		return fmt.Errorf("hit mask too short (%d bytes)", len(data))
+	}
+	var values [4]int
+	for i := range values {
+		values[i] = int(int32(binary.LittleEndian.Uint32(data[i*4:])))
+	}
+	bounds := image.Rect(values[0], values[1], values[2], values[3])
+	bits := data[hitMaskHeaderSize:]
+	if len(bits) != hitMaskStride(bounds)*bounds.Dy() {
+		return fmt.Errorf("hit mask of %v has %d bytes of bits", bounds, len(bits))
+	}
+	m.Bounds = bounds
+	m.Bits = bits
+	return nil
+}
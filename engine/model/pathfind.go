@@ -29,7 +29,7 @@ func (g graph[Node]) Neighbours(n Node) iter.Seq[Node] {
 // in this polygon set.
 type Pathfinder struct {
 	polygons        [][]image.Point
-	polygonSet      PolygonSet
+	areas           AreaSet
 	concaveVertices []image.Point
 	visibilityGraph graph[image.Point]
 }
@@ -48,14 +48,30 @@ type Pathfinder struct {
 //   - Polygons contained inside an area polygon are holes.
 //   - Polygons contained inside a hole are area polygons again.
 func NewPathfinder(polygons [][]image.Point) *Pathfinder {
-	polygonSet := convert(polygons, func(ps []image.Point) Polygon {
-		return ps2vs(ps)
-	})
-	return &Pathfinder{
-		polygons:        polygons,
-		polygonSet:      polygonSet,
-		concaveVertices: concaveVertices(polygonSet),
+	return NewAreasPathfinder([][][]image.Point{polygons})
+}
+
+// NewAreasPathfinder creates a Pathfinder for the union of several areas, each
+// a set of polygons as described in NewPathfinder. Unlike the polygons of a
+// set, areas can overlap or touch: a path goes from an area to the other
+// where they do.
+func NewAreasPathfinder(areas [][][]image.Point) *Pathfinder {
+	p := &Pathfinder{areas: make(AreaSet, 0, len(areas))}
+	for _, polygons := range areas {
+		polygonSet := convert(polygons, func(ps []image.Point) Polygon {
+			return ps2vs(ps)
+		})
+		p.polygons = append(p.polygons, polygons...)
+		p.areas = append(p.areas, polygonSet)
+		p.concaveVertices = append(p.concaveVertices, concaveVertices(polygonSet)...)
 	}
+
+	// Where the outlines of two areas cross the union has corners that are
+	// vertices of neither, paths may have to turn around them
+	for _, pt := range p.areas.outlineCrossings() {
+		p.concaveVertices = append(p.concaveVertices, ensureInside(p.areas.Contains, pt))
+	}
+	return p
 }
 
 // VisibilityGraph returns the calculated visibility graph from the last Path
@@ -72,23 +88,27 @@ func (p *Pathfinder) VisibilityGraph() map[image.Point][]image.Point {
 // the polygon set.
 func (p *Pathfinder) Path(start, dest image.Point) []image.Point {
 
+	if len(p.areas) == 0 {
+		return nil
+	}
+
 	d := p2v(dest)
-	if !p.polygonSet.Contains(d) {
-		dest = ensureInside(p.polygonSet, v2p(p.polygonSet.ClosestPt(d)))
+	if !p.areas.Contains(d) {
+		dest = ensureInside(p.areas.Contains, v2p(p.areas.ClosestPt(d)))
 	}
 
 	s := p2v(start)
-	if !p.polygonSet.Contains(s) {
-		start = ensureInside(p.polygonSet, v2p(p.polygonSet.ClosestPt(s)))
+	if !p.areas.Contains(s) {
+		start = ensureInside(p.areas.Contains, v2p(p.areas.ClosestPt(s)))
 	}
 
 	graphVertices := append(p.concaveVertices, start, dest)
-	p.visibilityGraph = visibilityGraph(p.polygonSet, graphVertices)
+	p.visibilityGraph = visibilityGraph(p.areas, graphVertices)
 	return FindPath(p.visibilityGraph, start, dest, nodeDist, nodeDist)
 }
 
-func ensureInside(ps PolygonSet, pt image.Point) image.Point {
-	if ps.Contains(p2v(pt)) {
+func ensureInside(contains func(Vec2) bool, pt image.Point) image.Point {
+	if contains(p2v(pt)) {
 		return pt
 	}
 adjustment:
@@ -98,7 +118,7 @@ adjustment:
 				continue
 			}
 			npt := pt.Add(image.Point{X: dx, Y: dy})
-			if ps.Contains(p2v(npt)) {
+			if contains(p2v(npt)) {
 				pt = npt
 				break adjustment
 			}
@@ -147,14 +167,14 @@ func verticesOfType(p Polygon, t vertexType) []image.Point {
 	return vs
 }
 
-func visibilityGraph(ps PolygonSet, points []image.Point) graph[image.Point] {
+func visibilityGraph(as AreaSet, points []image.Point) graph[image.Point] {
 	vis := make(graph[image.Point])
 	for i, a := range points {
 		for j, b := range points {
 			if i == j {
 				continue
 			}
-			if inLineOfSight(ps, p2v(a), p2v(b)) {
+			if as.inLineOfSight(p2v(a), p2v(b)) {
 				vis.link(a, b)
 			}
 		}
@@ -456,6 +476,110 @@ func (ps PolygonSet) ClosestPt(pt Vec2) Vec2 {
 	return best.pt
 }
 
+// An AreaSet is the union of several polygon sets, e.g. the walkable areas of
+// a room. Each polygon set has its own islands and holes.
+type AreaSet []PolygonSet
+
+// Contains checks if point pt lies inside any of the polygon sets.
+func (as AreaSet) Contains(pt Vec2) bool {
+	for _, ps := range as {
+		if ps.Contains(pt) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClosestPt returns the closest point to point pt on any of the outlines of
+// the polygon sets.
+func (as AreaSet) ClosestPt(pt Vec2) Vec2 {
+	var best match
+	for i, ps := range as {
+		var current match
+		current.pt = ps.ClosestPt(pt)
+		current.dist = current.pt.SqDist(pt)
+		if i == 0 || current.dist < best.dist {
+			best = current
+		}
+	}
+	return best.pt
+}
+
+// inLineOfSight checks if the line segment from start to end stays inside
+// the union of the polygon sets.
+func (as AreaSet) inLineOfSight(start, end Vec2) bool {
+	if len(as) == 1 {
+		return inLineOfSight(as[0], start, end)
+	}
+
+	// The segment may leave an area only where it meets an outline: split it
+	// there and check that every piece lies inside some area.
+	lineOfSight := LineSeg{A: start, B: end}
+	direction := end.Sub(start)
+	length := direction.Dot(direction)
+	if length == 0 {
+		return as.Contains(start)
+	}
+	at := func(pt Vec2) float32 {
+		return pt.Sub(start).Dot(direction) / length
+	}
+
+	breaks := []float32{0, 1}
+	for _, ps := range as {
+		for _, p := range ps {
+			for i, v := range p {
+				if lineOfSight.ClosestPt(v).NearEq(v) {
+					breaks = append(breaks, at(v))
+				}
+				edge := p.Edge(i)
+				if lineOfSight.Crosses(edge) {
+					if pt, exists := (Line{lineOfSight}).Intersect(Line{edge}); exists {
+						breaks = append(breaks, at(pt))
+					}
+				}
+			}
+		}
+	}
+	slices.Sort(breaks)
+
+	for i := 1; i < len(breaks); i++ {
+		if breaks[i]-breaks[i-1] < 1e-6 {
+			continue
+		}
+		middle := start.Add(direction.Mul((breaks[i-1] + breaks[i]) / 2))
+		if !as.Contains(middle) {
+			return false
+		}
+	}
+	return true
+}
+
+// outlineCrossings returns the points where the outlines of different
+// polygon sets cross each other.
+func (as AreaSet) outlineCrossings() []image.Point {
+	var pts []image.Point
+	for i, ps := range as {
+		for _, other := range as[i+1:] {
+			for _, p := range ps {
+				for _, q := range other {
+					for e := range p {
+						for f := range q {
+							a, b := p.Edge(e), q.Edge(f)
+							if !a.Crosses(b) {
+								continue
+							}
+							if pt, exists := (Line{a}).Intersect(Line{b}); exists {
+								pts = append(pts, v2p(pt))
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return pts
+}
+
 // ParseFloats parses a slice of float32s from a comma-separated
 // string of numbers, for example "186.5,364.7,303.25,374,303.1,412".
 // Spaces are ignored.
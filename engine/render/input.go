@@ -0,0 +1,33 @@
+package render
+
+import (
+	"chemistry/engine/logic"
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// inputEvents returns the input events of the player since the previous update
+func (r *Renderer) inputEvents() []logic.InputEvent {
+	x, y := ebiten.CursorPosition()
+	events := make([]logic.InputEvent, 0)
+
+	if cursor := image.Pt(x, y); cursor != r.cursorPosition {
+		r.cursorPosition = cursor
+		events = append(events, logic.InputEvent{Type: logic.CURSOR_MOVED, X: x, Y: y})
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		events = append(events, logic.InputEvent{Type: logic.LEFT_CLICK, X: x, Y: y})
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		events = append(events, logic.InputEvent{Type: logic.RIGHT_CLICK, X: x, Y: y})
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyPeriod) {
+		events = append(events, logic.InputEvent{Type: logic.SKIP_TEXT, X: x, Y: y})
+	}
+	if _, wheelY := ebiten.Wheel(); wheelY != 0 {
+		events = append(events, logic.InputEvent{Type: logic.WHEEL_SCROLLED, X: x, Y: y, Delta: wheelY})
+	}
+	return events
+}
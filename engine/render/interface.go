@@ -0,0 +1,173 @@
+package render
+
+import (
+	"chemistry/engine/logic"
+	"image"
+	"image/color"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	interfaceFontSize      = 20.0
+	dialogueOptionFontSize = 20.0
+	dialogueBackdropMargin = 20
+)
+
+func (r *Renderer) drawInterface(screen *ebiten.Image) {
+	g := r.game
+
+	if !g.IsInterfaceVisible() {
+		return
+	}
+
+	layout := g.InterfaceLayout()
+	for _, rect := range []image.Rectangle{layout.SentenceLine, layout.VerbBar, layout.Inventory} {
+		vector.FillRect(screen, float32(rect.Min.X), float32(rect.Min.Y), float32(rect.Dx()), float32(rect.Dy()), color.Black, false)
+	}
+
+	fontSource, err := r.fontFaceSource("MonkeyIsland")
+
+	// Sentence line
+	sentence := g.SentenceLine()
+	sentenceColor := color.RGBA{R: 200, G: 200, B: 255, A: 255}
+	if err != nil {
+		ebitenutil.DebugPrintAt(screen, sentence, layout.SentenceLine.Min.X, layout.SentenceLine.Min.Y)
+	} else {
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(float64(layout.SentenceLine.Min.X+layout.SentenceLine.Max.X)/2, float64(layout.SentenceLine.Min.Y+layout.SentenceLine.Max.Y)/2)
+		op.ColorScale.ScaleWithColor(sentenceColor)
+		op.PrimaryAlign = text.AlignCenter
+		op.SecondaryAlign = text.AlignCenter
+		text.Draw(screen, sentence, &text.GoTextFace{Source: fontSource, Size: interfaceFontSize}, op)
+	}
+
+	// Verb bar
+	cursor := g.CursorPosition()
+	hovered := g.VerbAt(cursor.X, cursor.Y)
+	for i, rect := range g.VerbRects() {
+		verb := g.Verbs()[i]
+		label := g.VerbLabel(verb)
+		if err != nil {
+			ebitenutil.DebugPrintAt(screen, label, rect.Min.X, rect.Min.Y)
+			continue
+		}
+
+		verbColor := color.RGBA{R: 120, G: 80, B: 200, A: 255}
+		if verb == hovered || verb == g.GetCurrentVerb() {
+			verbColor = color.RGBA{R: 255, G: 255, B: 120, A: 255}
+		}
+
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(float64(rect.Min.X+rect.Max.X)/2, float64(rect.Min.Y+rect.Max.Y)/2)
+		op.ColorScale.ScaleWithColor(verbColor)
+		op.PrimaryAlign = text.AlignCenter
+		op.SecondaryAlign = text.AlignCenter
+		text.Draw(screen, label, &text.GoTextFace{Source: fontSource, Size: interfaceFontSize}, op)
+	}
+
+	r.drawInventory(screen)
+}
+
+func (r *Renderer) drawInventory(screen *ebiten.Image) {
+	g := r.game
+
+	items := g.InventoryItems()
+	slotColor := color.RGBA{R: 60, G: 40, B: 110, A: 255}
+	for i, rect := range g.InventorySlotRects() {
+		vector.StrokeRect(screen, float32(rect.Min.X), float32(rect.Min.Y), float32(rect.Dx()), float32(rect.Dy()), 1, slotColor, false)
+
+		index := g.InventoryScroll() + i
+		if index >= len(items) {
+			continue
+		}
+
+		itemID := items[index]
+		imageData := g.InventoryImage(itemID)
+		if len(imageData) == 0 {
+			continue
+		}
+
+		img, err := r.image("inventory/"+itemID, "", imageData)
+		if err != nil {
+			continue
+		}
+
+		// Fit the image inside the slot keeping its aspect ratio
+		scale := min(float64(rect.Dx())/float64(img.Bounds().Dx()), float64(rect.Dy())/float64(img.Bounds().Dy()))
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(
+			float64(rect.Min.X)+(float64(rect.Dx())-float64(img.Bounds().Dx())*scale)/2,
+			float64(rect.Min.Y)+(float64(rect.Dy())-float64(img.Bounds().Dy())*scale)/2,
+		)
+		screen.DrawImage(img, op)
+
+		if count := g.GetCurrentCharacter().Inventory[itemID].Count; count > 1 {
+			ebitenutil.DebugPrintAt(screen, "x"+strconv.Itoa(count), rect.Max.X-20, rect.Max.Y-16)
+		}
+	}
+
+	arrowColor := color.RGBA{R: 120, G: 80, B: 200, A: 255}
+	if g.InventoryScroll() > 0 {
+		drawArrow(screen, g.InventoryScrollBackRect(), -1, arrowColor)
+	}
+	if g.InventoryScroll()+g.InventoryVisibleSlots() < len(items) {
+		drawArrow(screen, g.InventoryScrollForwardRect(), 1, arrowColor)
+	}
+}
+
+// drawArrow draws a triangle pointing left (direction < 0) or right (direction > 0) centered in rect
+func drawArrow(screen *ebiten.Image, rect image.Rectangle, direction int, clr color.Color) {
+	cx := float32(rect.Min.X+rect.Max.X) / 2
+	cy := float32(rect.Min.Y+rect.Max.Y) / 2
+	size := float32(rect.Dx()) / 3
+
+	var path vector.Path
+	path.MoveTo(cx+float32(direction)*size, cy)
+	path.LineTo(cx-float32(direction)*size, cy-size)
+	path.LineTo(cx-float32(direction)*size, cy+size)
+	path.Close()
+
+	op := &vector.DrawPathOptions{}
+	op.ColorScale.ScaleWithColor(clr)
+	vector.FillPath(screen, &path, nil, op)
+}
+
+func (r *Renderer) drawDialogueOptions(screen *ebiten.Image) {
+	g := r.game
+
+	rects := g.DialogueOptionRects()
+	if len(rects) == 0 {
+		return
+	}
+
+	top := rects[0].Min.Y - dialogueBackdropMargin
+	vector.FillRect(screen, 0, float32(top), logic.ScreenWidth, float32(logic.ScreenHeight-top), color.RGBA{A: 180}, false)
+
+	cursor := g.CursorPosition()
+	hovered := g.DialogueOptionAt(cursor.X, cursor.Y)
+
+	fontSource, err := r.fontFaceSource("MonkeyIsland")
+	for i, option := range g.DialogueOptions() {
+		rect := rects[i]
+		if err != nil {
+			ebitenutil.DebugPrintAt(screen, option.Text, rect.Min.X, rect.Min.Y)
+			continue
+		}
+
+		optionColor := color.RGBA{R: 120, G: 80, B: 200, A: 255}
+		if i == hovered {
+			optionColor = color.RGBA{R: 255, G: 255, B: 120, A: 255}
+		}
+
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(float64(rect.Min.X), float64(rect.Min.Y))
+		op.ColorScale.ScaleWithColor(optionColor)
+		text.Draw(screen, option.Text, &text.GoTextFace{Source: fontSource, Size: dialogueOptionFontSize}, op)
+	}
+}
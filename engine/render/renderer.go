@@ -0,0 +1,443 @@
+package render
+
+import (
+	"bytes"
+	"chemistry/engine/logic"
+	"chemistry/engine/model"
+	"image"
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// maxSpeechWidth is the width the lines said by the characters wrap at
+const maxSpeechWidth = logic.ScreenWidth * 2 / 3
+
+// Renderer is the ebiten adapter of a logic.Game: it turns the ebiten input into
+// input events, advances the game one tick per update and draws it
+type Renderer struct {
+	game *logic.Game
+
+	world              *ebiten.Image
+	layers             []renderedLayer
+	walkBehinds        []*ebiten.Image
+	backgroundLocation string
+	cursorPosition     image.Point
+	images             *logic.ResourceCache[*ebiten.Image]
+	fonts              *logic.ResourceCache[*text.GoTextFaceSource]
+
+	recorder *logic.InputRecorder
+	replay   *logic.InputReplay
+}
+
+// renderedLayer is a layer of the current location decoded to an ebiten image
+type renderedLayer struct {
+	layer model.Layer
+	image *ebiten.Image
+}
+
+func NewRenderer(game *logic.Game) *Renderer {
+	ebiten.SetTPS(logic.TicksPerSecond)
+	return &Renderer{
+		game:   game,
+		images: logic.NewResourceCache(game, (*ebiten.Image).Deallocate),
+		fonts:  logic.NewResourceCache[*text.GoTextFaceSource](game, nil),
+	}
+}
+
+// image returns the texture of an image of the game data, decoding it only the first time.
+// location is the location the image belongs to, "" if it is shown everywhere.
+func (r *Renderer) image(key string, location string, data []byte) (*ebiten.Image, error) {
+	return r.images.Get(key, location, func() (*ebiten.Image, error) {
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			log.Printf("Error decoding image %s: %v", key, err)
+			return nil, err
+		}
+		return ebiten.NewImageFromImage(decoded), nil
+	})
+}
+
+// characterImage returns the texture of the frame a character is showing
+func (r *Renderer) characterImage(character model.Character, animation string, frame int) (*ebiten.Image, error) {
+	return r.image(logic.FrameKey(character.ID, animation, frame), r.game.GetCurrentLocation().ID, character.Animations[animation][frame])
+}
+
+func (r *Renderer) Update() error {
+	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
+		return ebiten.Termination // Gestisci l'uscita qui
+	}
+
+	tick := r.game.TickCount()
+	events := r.inputEvents()
+	if r.replay != nil {
+		// Durante il replay l'input del giocatore è ignorato, alla fine torna a lui
+		events = r.replay.Events(tick)
+		if r.replay.Finished() {
+			log.Printf("Replay finished at tick %d", tick)
+			r.replay = nil
+		}
+	}
+	if r.recorder != nil {
+		if err := r.recorder.Record(tick, events); err != nil {
+			log.Printf("Error recording input, recording stopped: %v", err)
+			r.recorder = nil
+		}
+	}
+
+	r.game.Tick(events)
+	return nil
+}
+
+// RecordTo records the input of every tick with recorder
+func (r *Renderer) RecordTo(recorder *logic.InputRecorder) {
+	r.recorder = recorder
+}
+
+// ReplayFrom feeds the game the input of replay instead of the player's, until it is over
+func (r *Renderer) ReplayFrom(replay *logic.InputReplay) {
+	r.replay = replay
+}
+
+func (r *Renderer) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return logic.ScreenWidth, logic.ScreenHeight
+}
+
+func (r *Renderer) Draw(screen *ebiten.Image) {
+	g := r.game
+
+	if !r.updateBackground() {
+		return
+	}
+
+	// Disegna lo sfondo della location corrente (se presente)
+	r.drawBackground(r.world, g.GetCurrentLocation())
+
+	location := g.GetCurrentLocation()
+
+	// Disegna tutte le entità nell'ordine calcolato
+	for _, entityID := range g.YOrderedEntities() {
+		if index, isWalkBehind := logic.WalkBehindIndex(entityID); isWalkBehind {
+			// Ridisegna la parte di sfondo che copre chi sta dietro
+			if index < len(r.walkBehinds) && r.walkBehinds[index] != nil {
+				r.world.DrawImage(r.walkBehinds[index], &ebiten.DrawImageOptions{})
+			}
+		} else if character, isCharacter := g.CharacterByID(entityID); isCharacter {
+			// Disegna il personaggio del giocatore o un personaggio non giocante
+			state, _ := g.GetCharacterState(entityID)
+			r.drawCharacter(r.world, character, state)
+		} else {
+			// Disegna l'oggetto
+			r.drawItem(r.world, g.GetItem(entityID), location.Items[entityID])
+		}
+	}
+
+	// I layer in primo piano coprono personaggi e oggetti (es. una colonna)
+	r.drawLayers(r.world, true)
+
+	// Scrive sopra chi parla le battute in coda
+	r.drawText(r.world)
+
+	r.drawCursor(r.world)
+
+	camera := g.GetCamera()
+	screen.DrawImage(r.world, &ebiten.DrawImageOptions{
+		GeoM: cameraGeoM(camera),
+	})
+
+	// Interfaccia dei verbi e opzioni di dialogo sono disegnate in coordinate schermo
+	r.drawInterface(screen)
+	r.drawDialogueOptions(screen)
+}
+
+// updateBackground decodes the layers and the walk-behinds when the location changes; it reports whether there is a background to draw
+func (r *Renderer) updateBackground() bool {
+	location := r.game.GetCurrentLocation()
+	if r.world != nil && r.backgroundLocation == location.ID {
+		return true
+	}
+
+	data := r.game.BackgroundImage()
+	if len(data) == 0 {
+		return false
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r.layers = make([]renderedLayer, 0)
+	for _, layer := range r.game.Layers() {
+		if len(layer.Image) == 0 {
+			continue
+		}
+		layerImage, _, err := image.Decode(bytes.NewReader(layer.Image))
+		if err != nil {
+			log.Printf("Error decoding a layer of location '%s': %v", location.Name, err)
+			continue
+		}
+		layerImage = logic.KeyTransparentColor(layerImage, layer.TransparentColor)
+		r.layers = append(r.layers, renderedLayer{layer: layer, image: ebiten.NewImageFromImage(layerImage)})
+	}
+
+	r.walkBehinds = make([]*ebiten.Image, len(r.game.WalkBehinds()))
+	if len(r.walkBehinds) > 0 {
+		background, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i, walkBehind := range r.game.WalkBehinds() {
+			if walkBehind.Alpha != nil {
+				r.walkBehinds[i] = ebiten.NewImageFromImage(logic.WalkBehindImage(background, walkBehind))
+			}
+		}
+	}
+
+	r.world = ebiten.NewImage(config.Width, config.Height)
+	r.backgroundLocation = location.ID
+	return true
+}
+
+// cameraGeoM returns the ebiten transformation of the camera world matrix
+func cameraGeoM(camera logic.Camera) ebiten.GeoM {
+	m := camera.WorldMatrix()
+	geoM := ebiten.GeoM{}
+	geoM.SetElement(0, 0, m[0])
+	geoM.SetElement(0, 1, m[1])
+	geoM.SetElement(0, 2, m[2])
+	geoM.SetElement(1, 0, m[3])
+	geoM.SetElement(1, 1, m[4])
+	geoM.SetElement(1, 2, m[5])
+	return geoM
+}
+
+func (r *Renderer) drawText(screen *ebiten.Image) {
+	g := r.game
+
+	line, talking := g.Speech()
+	speaker, exists := g.CharacterByID(line.Speaker)
+	if !exists {
+		speaker = g.GetCurrentCharacter()
+	}
+	state, err := g.GetCharacterState(speaker.ID)
+	textToDisplay := line.Text
+	if talking && err == nil && textToDisplay != "" {
+		standard, err := r.fontFaceSource("MonkeyIsland")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fontFaceSource := standard
+
+		outline, err := r.fontFaceSource("MonkeyIslandOutline")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fontFaceOutlineSource := outline
+
+		talkColor := color.RGBA{
+			R: speaker.TalkColor.R,
+			G: speaker.TalkColor.G,
+			B: speaker.TalkColor.B,
+			A: 255,
+		}
+
+		spriteHeight := 0
+		animation, frame := state.Animation, state.AnimationFrame
+		if frame < len(speaker.Animations[animation]) {
+			if img, err := r.characterImage(speaker, animation, frame); err == nil {
+				spriteHeight = img.Bounds().Dy()
+			}
+		}
+		fontSize := 24.0 // Assuming a fixed font size, adjust if dynamic
+
+		// Create a face for measuring text
+		goTextFace := &text.GoTextFace{
+			Source: fontFaceSource, // or fontFaceOutlineSource, assuming they have similar metrics
+			Size:   fontSize,
+		}
+		lineSpacing := goTextFace.Size * 1.2
+
+		// Le battute lunghe vanno a capo invece di uscire dallo schermo
+		rows := logic.WrapText(textToDisplay, maxSpeechWidth, func(row string) float64 {
+			width, _ := text.Measure(row, goTextFace, lineSpacing)
+			return width
+		})
+		textToDisplay = strings.Join(rows, "\n")
+		textAdvance, textHeight := text.Measure(textToDisplay, goTextFace, lineSpacing)
+		textWidth := float64(textAdvance)
+
+		// Calculate initial desired position (above the speaker, centered)
+		position := state.Position
+		charScaledHeight := float64(spriteHeight) * g.CharacterScale(position)
+
+		// Calculate the desired text center X and top Y in world coordinates
+		worldTextCenterX := float64(position.X)
+		worldTextTopY := float64(position.Y) - charScaledHeight - textHeight - 20.0
+
+		// What are the visible world coordinates on screen?
+		margin := 5.0 // Small margin from screen edges
+		halfTextWidth := textWidth / 2.0
+
+		// Adjust worldTextCenterX so the text stays on screen
+		camera := g.GetCamera()
+		visibleWorldLeft := camera.Position[0] + margin
+		visibleWorldRight := camera.Position[0] + logic.ScreenWidth - margin
+
+		if worldTextCenterX-halfTextWidth < visibleWorldLeft {
+			worldTextCenterX = visibleWorldLeft + halfTextWidth
+		} else if worldTextCenterX+halfTextWidth > visibleWorldRight {
+			worldTextCenterX = visibleWorldRight - halfTextWidth
+		}
+
+		// Adjust text's top Y
+		visibleWorldTopEdge := camera.Position[1] + margin
+		visibleWorldBottomEdge := camera.Position[1] + float64(g.RoomViewHeight()) - margin - textHeight // Space for text height
+
+		if worldTextTopY < visibleWorldTopEdge {
+			worldTextTopY = visibleWorldTopEdge
+		} else if worldTextTopY > visibleWorldBottomEdge { // worldTextTopY is already the top of the text
+			worldTextTopY = visibleWorldBottomEdge
+		}
+
+		opRegular := &text.DrawOptions{}
+		opRegular.GeoM.Translate(worldTextCenterX, worldTextTopY)
+		opRegular.ColorScale.ScaleWithColor(talkColor)
+		opRegular.PrimaryAlign = text.AlignCenter  // Horizontal alignment
+		opRegular.SecondaryAlign = text.AlignStart // Vertical alignment (Y is top)
+		opRegular.LineSpacing = lineSpacing
+		text.Draw(screen, textToDisplay, goTextFace, opRegular) // Draw onto the world image
+
+		opOutline := &text.DrawOptions{}
+		opOutline.GeoM.Translate(worldTextCenterX, worldTextTopY)
+		opOutline.ColorScale.ScaleWithColor(color.Black)
+		opOutline.PrimaryAlign = text.AlignCenter
+		opOutline.SecondaryAlign = text.AlignStart
+		opOutline.LineSpacing = lineSpacing
+		text.Draw(screen, textToDisplay, &text.GoTextFace{ // Use a new face for outline if needed, or reuse
+			Source: fontFaceOutlineSource,
+			Size:   fontSize,
+		}, opOutline)
+	}
+
+}
+
+// fontFaceSource returns the face source of a font, loading the font on-demand
+func (r *Renderer) fontFaceSource(name string) (*text.GoTextFaceSource, error) {
+	return r.fonts.Get("font/"+name, "", func() (*text.GoTextFaceSource, error) {
+		return text.NewGoTextFaceSource(bytes.NewReader(r.game.Font(name)))
+	})
+}
+
+func (r *Renderer) drawBackground(screen *ebiten.Image, location model.Location) {
+	screen.Clear()
+	r.drawLayers(screen, false)
+
+	for _, area := range r.game.EnabledWalkableAreas() {
+		r.drawWalkableArea(screen, area)
+	}
+}
+
+func (r *Renderer) drawWalkableArea(screen *ebiten.Image, area model.WalkableArea) {
+	for _, polygon := range area.Polygons {
+		for from, point := range polygon {
+			to := from + 1
+			if from == len(polygon)-1 {
+				to = 0
+			}
+			vector.StrokeLine(screen, float32(point.X), float32(point.Y), float32(polygon[to].X), float32(polygon[to].Y), 1, color.White, false)
+		}
+	}
+}
+
+// drawLayers draws the layers in front of the actors, or the ones behind them, shifted by their parallax
+func (r *Renderer) drawLayers(screen *ebiten.Image, inFront bool) {
+	for _, rendered := range r.layers {
+		if rendered.layer.InFrontOfActors() != inFront {
+			continue
+		}
+		offset := r.game.LayerOffset(rendered.layer)
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(offset[0], offset[1])
+		screen.DrawImage(rendered.image, op)
+	}
+}
+
+func (r *Renderer) drawCharacter(screen *ebiten.Image, character model.Character, state *logic.CharacterState) {
+	animation, frame := state.Animation, state.AnimationFrame
+
+	// Verifica che l'animazione esista
+	if _, exists := character.Animations[animation]; !exists {
+		log.Printf("Warning: Animation '%s' not found for character '%s'", animation, character.Name)
+		return
+	}
+
+	// Verifica che il frame esista
+	if frame >= len(character.Animations[animation]) || len(character.Animations[animation]) == 0 {
+		log.Printf("Warning: Frame %d out of range for animation '%s' (length: %d)", frame, animation, len(character.Animations[animation]))
+		return
+	}
+
+	img, err := r.characterImage(character, animation, frame)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scale := r.game.CharacterScale(state.Position)
+	bounds := logic.CharacterBounds(state.Position, img.Bounds().Dx(), img.Bounds().Dy(), scale)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(float64(bounds.Min.X), float64(bounds.Min.Y))
+	screen.DrawImage(img, op)
+}
+
+func (r *Renderer) drawItem(screen *ebiten.Image, item model.Item, itemLocation model.ItemLocation) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(itemLocation.LocationPoint.X), float64(itemLocation.LocationPoint.Y))
+
+	frameData := r.game.ItemFrame(item.ID)
+	if len(frameData) == 0 {
+		// Placeholder if no static image and no animations
+		vector.FillCircle(screen, float32(itemLocation.LocationPoint.X)+10, float32(itemLocation.LocationPoint.Y)+10, 3, color.RGBA{255, 0, 0, 255}, false)
+		return
+	}
+
+	animation, frame := r.game.ItemAnimation(item.ID)
+	img, err := r.image(logic.FrameKey(item.ID, animation, frame), r.game.GetCurrentLocation().ID, frameData)
+	if err != nil {
+		vector.FillCircle(screen, float32(itemLocation.LocationPoint.X)+10, float32(itemLocation.LocationPoint.Y)+10, 3, color.RGBA{255, 0, 0, 255}, false) // Placeholder on error
+		return
+	}
+	screen.DrawImage(img, op)
+
+	// Keep drawing interaction point for debugging or gameplay
+	//vector.FillCircle(screen, float32(itemLocation.InteractionPoint.X), float32(itemLocation.InteractionPoint.Y), 2, color.RGBA{0, 255, 0, 255}, false)
+}
+
+func (r *Renderer) drawCursor(screen *ebiten.Image) {
+	data := r.game.CurrentCursor()
+	if len(data) == 0 {
+		return
+	}
+	set, animation, frame := r.game.CurrentCursorFrame()
+	img, err := r.image(logic.FrameKey("cursor/"+set, animation, frame), "", data)
+	if err != nil {
+		return
+	}
+
+	camera := r.game.GetCamera()
+	cursor := r.game.CursorPosition()
+	x, y := camera.ScreenToWorld(cursor.X, cursor.Y)
+
+	x -= float64(img.Bounds().Dx() / 2)
+	y -= float64(img.Bounds().Dy() / 2)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(x, y)
+
+	screen.DrawImage(img, op)
+
+}